@@ -11,6 +11,10 @@ import (
 
 func Init() error {
 	viper.AutomaticEnv()
+	// IMAGE_REGISTRY is the older name of the registry base, still honoured when the new one is unset
+	_ = viper.BindEnv(constants.ContainerRegistryBase, constants.ContainerRegistryBase, constants.EnvImageRegistry)
+	// OLAKE_TELEMETRY_OPT_OUT matches the UI's name for the telemetry kill switch
+	_ = viper.BindEnv(constants.EnvTelemetryDisabled, constants.EnvTelemetryDisabled, constants.EnvTelemetryOptOut)
 
 	setDefaults()
 
@@ -29,22 +33,63 @@ func setDefaults() {
 	// Registry defaults
 	viper.SetDefault("CONTAINER_REGISTRY_BASE", "registry-1.docker.io")
 	viper.SetDefault("TEMPORAL_RETENTION_PERIOD", "168h")
+	viper.SetDefault("TEMPORAL_CONNECT_MAX_RETRIES", 60)
+	viper.SetDefault("TEMPORAL_CONNECT_TIMEOUT", "5m")
 
 	// Worker defaults
 	viper.SetDefault("LOG_RETENTION_PERIOD", 30)
+	viper.SetDefault("LOG_USAGE_CHECK_INTERVAL", "10m")
+	viper.SetDefault("HEALTH_PORT", 8090)
+	viper.SetDefault("CONNECTOR_TYPED_OUTPUT_MIN_VERSION", "v0.2.0")
+	viper.SetDefault("HEALTH_LIVENESS_WINDOW", "5m")
+	viper.SetDefault("WORKER_DRAIN_TIMEOUT", "25s")
+	viper.SetDefault("SYNC_HEARTBEAT_TIMEOUT", constants.DefaultHeartbeatTimeout)
+	viper.SetDefault("HEARTBEAT_INTERVAL", constants.DefaultHeartbeatInterval)
+	viper.SetDefault("MAX_CONNECTOR_OUTPUT_BYTES", constants.DefaultMaxConnectorOutputBytes)
+	viper.SetDefault("SYNC_STATE_CHECKPOINT_INTERVAL", "10m")
+	viper.SetDefault("SYNC_STATE_CHECKPOINT_MAX_CONCURRENT", 2)
+	viper.SetDefault("STATE_REGRESSION_CHECK", "off")
+	viper.SetDefault("CONNECTOR_TRANSIENT_MAX_ATTEMPTS", 3)
+	viper.SetDefault("STREAMS_VALIDATION", "off")
+	viper.SetDefault("PERSIST_OUTPUT_TO_DB", false)
+	viper.SetDefault("PERSIST_OUTPUT_MAX_BYTES", 1<<20)
+	viper.SetDefault("STATE_HISTORY_LIMIT", 0)
+	viper.SetDefault("TIMEOUT_ACTIVITY_TEST", "2m")
+	viper.SetDefault("DISCOVER_CACHE_TTL", "0")
+	viper.SetDefault("CONNECTOR_TRIGGER_ENV", false)
 
 	// Kubernetes defaults
+	viper.SetDefault("SYNC_RUN_AS_K8S_JOB", false)
+	viper.SetDefault("SYNC_JOB_BACKOFF_LIMIT", 3)
+	viper.SetDefault("ORPHAN_POD_GRACE", "1h")
+	viper.SetDefault("OLAKE_JOB_MESH_SIDECAR_EXCLUSION", true)
 	viper.SetDefault("WORKER_NAMESPACE", "default")
+	viper.SetDefault("CONNECTOR_JVM_HEAP_HEADROOM_PERCENT", constants.DefaultJVMHeapHeadroomPercent)
+	viper.SetDefault("TREAT_SIGNAL_EXIT_AS_CANCELLATION", true)
+	viper.SetDefault("IMAGE_PULL_MAX_FAILURES", 5)
+	viper.SetDefault("IMAGE_PULL_FAILURE_WINDOW", "5m")
+	viper.SetDefault("LEADER_ELECTION_ENABLED", false)
+	viper.SetDefault("LEADER_ELECTION_LEASE_NAME", "olake-worker-leader")
 
 	// Logging defaults
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("LOG_FORMAT", "console")
+	viper.SetDefault("OTEL_SERVICE_NAME", "olake-worker")
 
 	// telemetry defaults
 	viper.SetDefault("TELEMETRY_DISABLED", false)
+	viper.SetDefault("TELEMETRY_USER_ID_REQUIRED", false)
+
+	// notification defaults
+	viper.SetDefault("PAGERDUTY_SEVERITY", "error")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("SMTP_TLS_MODE", "starttls")
+	viper.SetDefault("DISCORD_SEVERITY", "error")
 
 	// API defaults
 	viper.SetDefault("OLAKE_CALLBACK_URL", "http://olake-ui:8000/internal/worker/callback")
+	viper.SetDefault("HTTP_CLIENT_TIMEOUT", "10s")
+	viper.SetDefault("HTTP_RETRY_ATTEMPTS", 3)
 
 	// database defaults
 	viper.SetDefault("DB_HOST", "postgresql")
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+// setRequiredEnv sets the variables a docker-mode worker needs to start
+func setRequiredEnv(t *testing.T) {
+	t.Setenv(constants.EnvCallbackURL, "http://olake-ui:8000/internal/worker/callback")
+	t.Setenv(constants.EnvDatabaseURL, "postgres://olake@postgres:5432/olake")
+	t.Setenv(constants.EnvHostPersistentDir, "/tmp/olake-config")
+}
+
+func TestInitImageRegistryAlias(t *testing.T) {
+	tests := []struct {
+		name          string
+		registryBase  string
+		imageRegistry string
+		want          string
+	}{
+		{name: "unset", want: "registry-1.docker.io"},
+		{name: "alias", imageRegistry: "ghcr.io/acme", want: "ghcr.io/acme"},
+		{name: "registry base wins", registryBase: "registry.example.com", imageRegistry: "ghcr.io/acme", want: "registry.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			if tt.registryBase != "" {
+				t.Setenv(constants.ContainerRegistryBase, tt.registryBase)
+			}
+			if tt.imageRegistry != "" {
+				t.Setenv(constants.EnvImageRegistry, tt.imageRegistry)
+			}
+
+			require.NoError(t, Init())
+			require.Equal(t, tt.want, viper.GetString(constants.ContainerRegistryBase))
+		})
+	}
+}
+
+func TestInitTelemetryOptOutAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		disabled string
+		optOut   string
+		want     bool
+	}{
+		{name: "unset"},
+		{name: "opt out", optOut: "true", want: true},
+		{name: "disabled", disabled: "true", want: true},
+		{name: "disabled wins", disabled: "false", optOut: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			if tt.disabled != "" {
+				t.Setenv(constants.EnvTelemetryDisabled, tt.disabled)
+			}
+			if tt.optOut != "" {
+				t.Setenv(constants.EnvTelemetryOptOut, tt.optOut)
+			}
+
+			require.NoError(t, Init())
+			require.Equal(t, tt.want, viper.GetBool(constants.EnvTelemetryDisabled))
+		})
+	}
+}
@@ -7,13 +7,27 @@ import (
 )
 
 const (
-	DefaultDockerImagePrefix = "olakego/source"
-	ContainerStopTimeout     = 5  // in seconds
-	ContainerCleanupTimeout  = 30 // in seconds
-	DefaultSyncTimeout       = time.Hour * 24 * 30
-	TaskQueue                = "OLAKE_DOCKER_TASK_QUEUE"
-	OperationTypeKey         = "OperationType"
-	DefaultTemporalNamespace = "default"
+	DefaultDockerImagePrefix       = "olakego/source"
+	ContainerStopTimeout           = 5  // in seconds
+	ContainerCleanupTimeout        = 30 // in seconds
+	DefaultSyncTimeout             = time.Hour * 24 * 30
+	DefaultHeartbeatTimeout        = 30 * time.Second
+	DefaultHeartbeatInterval       = 5 * time.Second
+	DefaultMaxConnectorOutputBytes = 64 << 20 // connector output held in memory per stream
+	TaskQueue                      = "OLAKE_DOCKER_TASK_QUEUE"
+	OperationTypeKey               = "OperationType"
+	DefaultTemporalNamespace       = "default"
+
+	// signals and query pausing a sync workflow between activity attempts
+	PauseSyncSignal  = "pause-sync"
+	ResumeSyncSignal = "resume-sync"
+	SyncPausedQuery  = "sync-paused"
+
+	// query returning the last lines of a sync workflow's worker.log, bounded in lines and bytes
+	SyncLogTailQuery        = "sync-log-tail"
+	SyncLogTailDefaultLines = 100
+	SyncLogTailMaxLines     = 1000
+	SyncLogTailMaxBytes     = 256 << 10
 
 	// Directory paths
 	// TODO: make persistent path alias same for both docker and k8s.
@@ -21,12 +35,24 @@ const (
 	K8sPersistentDir    = "/data/olake-jobs"
 	DockerPersistentDir = "/tmp/olake-config"
 	OutputFileName      = "output.json"
+	CatalogCacheDir     = "catalog-cache"
 
 	// File and directory permissions
 	DefaultDirPermissions  = 0755
 	DefaultFilePermissions = 0644
 
 	StateFlag = "--state"
+
+	// Outputs returned by the docker executor for async runs that were adopted or already handled
+	SyncStatusCompletedMessage = "sync status: completed"
+	SyncStatusSkippedMessage   = "sync status: skipped"
+
+	// Share of the connector memory limit kept free of the JVM heap for
+	// metaspace, thread stacks and native buffers
+	DefaultJVMHeapHeadroomPercent = 25
 )
 
+// DryRunFlag asks a clear-destination run to report its plan instead of deleting
+const DryRunFlag = "--dry-run"
+
 var AsyncCommands = []types.Command{types.Sync, types.ClearDestination}
@@ -9,53 +9,194 @@ const (
 	EnvDatabasePassword      = "DB_PASSWORD"
 	EnvDatabaseDatabase      = "DB_NAME"
 	EnvDatabaseSSLMode       = "DB_SSLMODE"
+	EnvDatabaseSSLRootCert   = "DB_SSLROOTCERT"
+	EnvDatabaseSSLCert       = "DB_SSLCERT"
+	EnvDatabaseSSLKey        = "DB_SSLKEY"
+	EnvDatabaseReadHost      = "DB_READ_HOST"
+	EnvDatabaseReadURL       = "DB_READ_URL"
 	EnvDatabaseRunMode       = "RUN_MODE"
 	EnvMaxOpenConnections    = "DB_MAX_OPEN_CONNS"
 	EnvMaxIdleConnections    = "DB_MAX_IDLE_CONNS"
 	EnvConnectionMaxLifetime = "DB_CONN_MAX_LIFETIME"
 
 	// temporal
-	EnvTemporalAddress         = "TEMPORAL_ADDRESS"
-	EnvTemporalRetentionPeriod = "TEMPORAL_RETENTION_PERIOD"
-	EnvTemporalExternal        = "TEMPORAL_EXTERNAL"
-	EnvTemporalAPIKey          = "TEMPORAL_API_KEY"
-	EnvTemporalNamespace       = "TEMPORAL_NAMESPACE"
-	EnvTemporalEnableTLS       = "TEMPORAL_ENABLE_TLS"
-	EnvTemporalTaskQueue       = "TEMPORAL_TASK_QUEUE"
+	EnvTemporalAddress               = "TEMPORAL_ADDRESS"
+	EnvTemporalRetentionPeriod       = "TEMPORAL_RETENTION_PERIOD"
+	EnvTemporalExternal              = "TEMPORAL_EXTERNAL"
+	EnvTemporalAPIKey                = "TEMPORAL_API_KEY"
+	EnvTemporalNamespace             = "TEMPORAL_NAMESPACE"
+	EnvTemporalEnableTLS             = "TEMPORAL_ENABLE_TLS"
+	EnvTemporalTLSCert               = "TEMPORAL_TLS_CERT"
+	EnvTemporalTLSKey                = "TEMPORAL_TLS_KEY"
+	EnvTemporalTLSCA                 = "TEMPORAL_TLS_CA"
+	EnvTemporalTLSServerName         = "TEMPORAL_TLS_SERVER_NAME"
+	EnvTemporalTLSInsecureSkipVerify = "TEMPORAL_TLS_INSECURE_SKIP_VERIFY"
+	EnvTemporalTaskQueue             = "TEMPORAL_TASK_QUEUE"
+	EnvConnectorTaskQueues           = "TEMPORAL_CONNECTOR_TASK_QUEUES"
+	EnvTemporalConnectMaxRetries     = "TEMPORAL_CONNECT_MAX_RETRIES"
+	EnvTemporalConnectTimeout        = "TEMPORAL_CONNECT_TIMEOUT"
 
 	// registry
 	ContainerRegistryBase = "CONTAINER_REGISTRY_BASE"
+	EnvImageRegistry      = "IMAGE_REGISTRY" // alias of CONTAINER_REGISTRY_BASE
 	// Optional credentials for a generic private registry (Harbor, Nexus, Quay, GitLab,
 	// registry:2). Used to authenticate Docker-mode image pulls without `docker login`.
 	// Left empty for Docker Hub or for ECR/GCR (which authenticate via cloud IAM / host creds).
 	EnvRegistryUsername = "CONTAINER_REGISTRY_USERNAME"
 	EnvRegistryPassword = "CONTAINER_REGISTRY_PASSWORD"
+	// JSON map of source type to full image template, e.g. {"postgres":"registry.example.com/olake/pg:{version}"}
+	EnvConnectorImageOverrides = "CONNECTOR_IMAGE_OVERRIDES"
+	// JSON map of source type to the sha256 digest its connector image must have, e.g. {"postgres":"sha256:..."}
+	EnvConnectorImageDigests = "CONNECTOR_IMAGE_DIGESTS"
+	// JSON map of source type to the tag suffix of its per-architecture images, e.g. {"oracle":"-{arch}"}
+	EnvConnectorImageArchSuffixes = "CONNECTOR_IMAGE_ARCH_SUFFIXES"
+	// architecture per-architecture images are chosen for; defaults to the worker's own
+	EnvConnectorImageArch = "CONNECTOR_IMAGE_ARCH"
 
 	// worker
-	EnvLogRetentionPeriod = "LOG_RETENTION_PERIOD"
-	EnvHostPersistentDir  = "PERSISTENT_DIR"
+	EnvLogRetentionPeriod             = "LOG_RETENTION_PERIOD"
+	EnvLogCompressAfterDays           = "LOG_COMPRESS_AFTER_DAYS"
+	EnvLogMaxUsage                    = "LOG_MAX_USAGE"
+	EnvLogUsageCheckInterval          = "LOG_USAGE_CHECK_INTERVAL"
+	EnvHostPersistentDir              = "PERSISTENT_DIR"
+	EnvSyncStartJitter                = "SYNC_START_JITTER"
+	EnvSyncHeartbeatTimeout           = "SYNC_HEARTBEAT_TIMEOUT"
+	EnvSyncTimeoutOverrides           = "SYNC_TIMEOUT_OVERRIDES"
+	EnvHeartbeatInterval              = "HEARTBEAT_INTERVAL"
+	EnvSyncTimeoutWarningThreshold    = "SYNC_TIMEOUT_WARNING_THRESHOLD"
+	EnvOutputScanMaxBytes             = "OUTPUT_SCAN_MAX_BYTES"
+	EnvMaxConnectorOutputBytes        = "MAX_CONNECTOR_OUTPUT_BYTES"
+	EnvCleanupWorkdirOnSuccess        = "CLEANUP_WORKDIR_ON_SUCCESS"
+	EnvStateStore                     = "STATE_STORE"
+	EnvStateStoreBucket               = "STATE_STORE_BUCKET"
+	EnvStateStorePrefix               = "STATE_STORE_PREFIX"
+	EnvStateStoreRegion               = "STATE_STORE_REGION"
+	EnvStateStoreEndpoint             = "STATE_STORE_ENDPOINT"
+	EnvConnectorTypedOutputMinVersion = "CONNECTOR_TYPED_OUTPUT_MIN_VERSION"
+	EnvValidateConfigBefore           = "VALIDATE_CONFIG_BEFORE"
+	EnvStreamsValidation              = "STREAMS_VALIDATION"
+	EnvHealthPort                     = "HEALTH_PORT"
+	EnvWorkerAdminToken               = "WORKER_ADMIN_TOKEN"
+	EnvHealthLivenessWindow           = "HEALTH_LIVENESS_WINDOW"
+	EnvMaxConcurrentActivities        = "MAX_CONCURRENT_ACTIVITIES"
+	EnvMaxConcurrentWorkflows         = "MAX_CONCURRENT_WORKFLOWS"
+	EnvWorkerDrainTimeout             = "WORKER_DRAIN_TIMEOUT"
+	EnvPersistOutputToDB              = "PERSIST_OUTPUT_TO_DB"
+	EnvPersistOutputMaxBytes          = "PERSIST_OUTPUT_MAX_BYTES"
+	EnvStateHistoryLimit              = "STATE_HISTORY_LIMIT"
+	EnvConnectorTriggerEnv            = "CONNECTOR_TRIGGER_ENV"
+	EnvActivityTestTimeout            = "TIMEOUT_ACTIVITY_TEST"
+	EnvDiscoverCacheTTL               = "DISCOVER_CACHE_TTL"
 
 	// kubernetes
 	EnvNamespace             = "WORKER_NAMESPACE"
 	EnvStoragePVCName        = "OLAKE_STORAGE_PVC_NAME"
 	EnvJobServiceAccountName = "JOB_SERVICE_ACCOUNT_NAME"
 	EnvSecretKey             = "OLAKE_SECRET_KEY"
+	EnvSecretKeyPrevious     = "OLAKE_SECRET_KEY_PREVIOUS"
+	EnvSecretKeySecretName   = "OLAKE_SECRET_KEY_SECRET"
 	EnvPodName               = "POD_NAME"
 	EnvKubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
 
+	// leader election of singleton background tasks across worker replicas
+	EnvLeaderElectionEnabled   = "LEADER_ELECTION_ENABLED"
+	EnvLeaderElectionLeaseName = "LEADER_ELECTION_LEASE_NAME"
+
+	// sync pod/container shutdown
+	EnvSyncPodTerminationGrace       = "SYNC_POD_TERMINATION_GRACE_SECONDS"
+	EnvSyncRunAsJob                  = "SYNC_RUN_AS_K8S_JOB"
+	EnvSyncJobBackoffLimit           = "SYNC_JOB_BACKOFF_LIMIT"
+	EnvOrphanPodGrace                = "ORPHAN_POD_GRACE"
+	EnvSignalExitAsCancel            = "TREAT_SIGNAL_EXIT_AS_CANCELLATION"
+	EnvStateCheckpointInterval       = "SYNC_STATE_CHECKPOINT_INTERVAL"
+	EnvStateCheckpointMaxConcurrent  = "SYNC_STATE_CHECKPOINT_MAX_CONCURRENT"
+	EnvConnectorMetricsInterval      = "CONNECTOR_METRICS_INTERVAL"
+	EnvStateRegressionCheck          = "STATE_REGRESSION_CHECK"
+	EnvStateRegressionMarkers        = "STATE_REGRESSION_MARKERS"
+	EnvConnectorTransientPatterns    = "CONNECTOR_TRANSIENT_PATTERNS"
+	EnvConnectorFatalPatterns        = "CONNECTOR_FATAL_PATTERNS"
+	EnvConnectorTransientExitCodes   = "CONNECTOR_TRANSIENT_EXIT_CODES"
+	EnvConnectorTransientMaxAttempts = "CONNECTOR_TRANSIENT_MAX_ATTEMPTS"
+
+	// docker connector containers
+	EnvConnectorDockerNetwork     = "CONNECTOR_DOCKER_NETWORK"
+	EnvConnectorDockerMemoryLimit = "CONNECTOR_DOCKER_MEMORY_LIMIT"
+	EnvConnectorDockerCPULimit    = "CONNECTOR_DOCKER_CPU_LIMIT"
+	EnvConnectorRunAsUser         = "CONNECTOR_RUN_AS_USER"
+	EnvConnectorExtraHosts        = "CONNECTOR_EXTRA_HOSTS"
+	EnvConnectorDNS               = "CONNECTOR_DNS"
+
+	// giving up on connector pods whose image cannot be pulled
+	EnvImagePullMaxFailures   = "IMAGE_PULL_MAX_FAILURES"
+	EnvImagePullFailureWindow = "IMAGE_PULL_FAILURE_WINDOW"
+
+	// init container checking the job's config files before the connector starts
+	EnvJobConfigCheck      = "OLAKE_JOB_CONFIG_CHECK"
+	EnvJobConfigCheckImage = "OLAKE_JOB_CONFIG_CHECK_IMAGE"
+
+	// sync state on a pod-local volume
+	EnvSyncLocalStateVolume    = "SYNC_LOCAL_STATE_VOLUME"
+	EnvSyncLocalStateSizeLimit = "SYNC_LOCAL_STATE_SIZE_LIMIT"
+
 	// logging
-	EnvLogLevel  = "LOG_LEVEL"
-	EnvLogFormat = "LOG_FORMAT"
+	EnvLogLevel         = "LOG_LEVEL"
+	EnvLogFormat        = "LOG_FORMAT"
+	EnvLogSampleRate    = "LOG_SAMPLE_RATE"
+	EnvWorkerLogMaxSize = "WORKER_LOG_MAX_SIZE"
+
+	// tracing
+	EnvOTLPEndpoint    = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvOTelServiceName = "OTEL_SERVICE_NAME"
 
 	// telemetry
-	EnvTelemetryDisabled = "TELEMETRY_DISABLED"
+	EnvTelemetryDisabled       = "TELEMETRY_DISABLED"
+	EnvTelemetryOptOut         = "OLAKE_TELEMETRY_OPT_OUT"
+	EnvTelemetryUserIDRequired = "TELEMETRY_USER_ID_REQUIRED"
 
 	// api
-	EnvCallbackURL = "OLAKE_CALLBACK_URL"
+	EnvCallbackURL       = "OLAKE_CALLBACK_URL"
+	EnvCallbackToken     = "OLAKE_CALLBACK_TOKEN"
+	EnvHTTPClientTimeout = "HTTP_CLIENT_TIMEOUT"
+	EnvHTTPRetryAttempts = "HTTP_RETRY_ATTEMPTS"
+
+	// notifications
+	EnvPagerDutyRoutingKey = "PAGERDUTY_ROUTING_KEY"
+	EnvPagerDutySeverity   = "PAGERDUTY_SEVERITY"
+	EnvSMTPHost            = "SMTP_HOST"
+	EnvSMTPPort            = "SMTP_PORT"
+	EnvSMTPUsername        = "SMTP_USERNAME"
+	EnvSMTPPassword        = "SMTP_PASSWORD"
+	EnvSMTPFrom            = "SMTP_FROM"
+	EnvSMTPTo              = "SMTP_TO"
+	EnvSMTPTLSMode         = "SMTP_TLS_MODE"
+	EnvDiscordSeverity     = "DISCORD_SEVERITY"
+	EnvFallbackWebhookURL  = "FALLBACK_WEBHOOK_URL"
+	EnvPostSyncHookURL     = "POST_SYNC_HOOK_URL"
 
 	// security context
 	EnvPodSecurityContext = "POD_SECURITY_CONTEXT"
 
 	// activity pod annotations
 	EnvJobPodAnnotations = "OLAKE_JOB_POD_ANNOTATIONS"
+
+	// workflow memo fields copied onto activity pods, JSON maps of memo key to label/annotation key
+	EnvJobMemoLabels      = "OLAKE_JOB_MEMO_LABELS"
+	EnvJobMemoAnnotations = "OLAKE_JOB_MEMO_ANNOTATIONS"
+
+	// node selector of discover, check and spec pods, a JSON map of node label to value
+	EnvOperationPodNodeSelector = "OPERATION_POD_NODE_SELECTOR"
+
+	// PriorityClass of connector pods per operation, a JSON map of command to class name
+	EnvOperationPriorityClasses = "OPERATION_PRIORITY_CLASSES"
+
+	// istio ambient mesh enrollment of activity pods
+	EnvJobMeshSidecarExclusion     = "OLAKE_JOB_MESH_SIDECAR_EXCLUSION"
+	EnvJobAmbientMesh              = "OLAKE_JOB_AMBIENT_MESH"
+	EnvJobAmbientWaypoint          = "OLAKE_JOB_AMBIENT_WAYPOINT"
+	EnvJobAmbientWaypointNamespace = "OLAKE_JOB_AMBIENT_WAYPOINT_NAMESPACE"
+
+	// connector JVM sizing
+	EnvConnectorJVMHeapHeadroom = "CONNECTOR_JVM_HEAP_HEADROOM_PERCENT"
+	EnvConnectorJVMTypes        = "CONNECTOR_JVM_TYPES"
+	EnvJavaToolOptions          = "JAVA_TOOL_OPTIONS"
 )
@@ -1,7 +1,49 @@
 package constants
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrExecutionFailed is returned when a container/pod fails due to non-retryable application errors.
 // Infrastructure failures (evictions, image pull errors, etc.) are NOT wrapped with this error.
 var ErrExecutionFailed = errors.New("execution failed")
+
+// ErrExecutionCancelled is returned when a container/pod was terminated by a signal while it was
+// being shut down for cancellation, so the run is reported as cancelled rather than failed.
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+// ErrStateRegression is returned when a connector reports a final state older than the one
+// already persisted for the job and STATE_REGRESSION_CHECK is set to fail.
+var ErrStateRegression = errors.New("state regression")
+
+// ErrStaleState is returned when a state write is rejected because the job already has the
+// state of a run that started later
+var ErrStaleState = errors.New("stale state")
+
+// ErrJobAlreadyRunning is returned when a sync or clear-destination is started while another
+// workflow's pod/container for the same job is still running; the run is skipped instead.
+var ErrJobAlreadyRunning = errors.New("job already running")
+
+// Connector exit reasons reported by ConnectorExitError
+const (
+	ExitReasonOOMKilled = "OOMKilled"
+	ExitReasonError     = "Error"
+)
+
+// ConnectorExitError is an ErrExecutionFailed carrying how the connector container ended, so an
+// out-of-memory kill can be told apart from an application or config error
+type ConnectorExitError struct {
+	ExitCode int
+	Reason   string // container termination reason, e.g. OOMKilled or Error
+	Detail   string
+	Output   string // the end of the connector's logs, used to tell transient failures apart
+}
+
+func (e *ConnectorExitError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrExecutionFailed, e.Detail)
+}
+
+func (e *ConnectorExitError) Unwrap() error {
+	return ErrExecutionFailed
+}
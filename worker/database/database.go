@@ -3,28 +3,39 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/spf13/viper"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 )
 
 const (
-	pingTimeout = 5 * time.Minute
+	pingTimeout        = 5 * time.Minute
+	replicaPingTimeout = 30 * time.Second
 )
 
 type DB struct {
-	client *sql.DB
+	client *sql.DB // primary, used for writes
+	reader *sql.DB // read replica for read-only queries; same as client when no replica is configured
 	tables map[string]string
+
+	// whether past states are kept, see SaveStateHistory
+	stateHistory bool
 }
 
 // creates a database connection instance.
 func Init(ctx context.Context) (*DB, error) {
-	connStr := buildConnectionString()
+	connStr, err := buildConnectionString(viper.GetString(constants.EnvDatabaseHost))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build database connection string: %s", err)
+	}
 	tables := buildTablesMap()
 
 	conn, err := sql.Open("postgres", connStr)
@@ -32,28 +43,85 @@ func Init(ctx context.Context) (*DB, error) {
 		return nil, fmt.Errorf("failed to open database connection: %s", err)
 	}
 
-	db := &DB{client: conn, tables: tables}
+	db := &DB{client: conn, reader: conn, tables: tables}
 
 	if err := db.PingContext(ctx); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %s", err)
 	}
 
+	configurePool(db.client)
+	db.reader = openReadReplica(ctx, conn)
+
+	// the history is optional, so a role without CREATE rights only disables it
+	if viper.GetInt(constants.EnvStateHistoryLimit) > 0 {
+		if err := db.ensureStateHistoryTable(ctx); err != nil {
+			logger.Warnf("failed to create state history table, past states won't be kept: %s", err)
+		} else {
+			db.stateHistory = true
+		}
+	}
+
+	if viper.GetBool(constants.EnvPersistOutputToDB) {
+		if err := db.ensureExecutionLogTable(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create execution log table: %s", err)
+		}
+	}
+
+	return db, nil
+}
+
+// openReadReplica opens the pool for DB_READ_URL or DB_READ_HOST. Reads fall back to the
+// primary when no replica is configured or it cannot be reached at startup.
+func openReadReplica(ctx context.Context, primary *sql.DB) *sql.DB {
+	connStr := viper.GetString(constants.EnvDatabaseReadURL)
+	if connStr == "" {
+		readHost := viper.GetString(constants.EnvDatabaseReadHost)
+		if readHost == "" {
+			return primary
+		}
+		var err error
+		connStr, err = buildConnectionString(readHost)
+		if err != nil {
+			logger.Warnf("failed to build read replica connection string: %s. using primary for reads.", err)
+			return primary
+		}
+	}
+
+	replica, err := sql.Open("postgres", connStr)
+	if err != nil {
+		logger.Warnf("failed to open read replica connection: %s. using primary for reads.", err)
+		return primary
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+	if err := replica.PingContext(pingCtx); err != nil {
+		logger.Warnf("failed to ping read replica: %s. using primary for reads.", err)
+		replica.Close()
+		return primary
+	}
+
+	configurePool(replica)
+	logger.Info("using read replica for read-only queries")
+	return replica
+}
+
+func configurePool(pool *sql.DB) {
 	if maxOpen := viper.GetInt(constants.EnvMaxOpenConnections); maxOpen > 0 {
-		db.client.SetMaxOpenConns(maxOpen)
+		pool.SetMaxOpenConns(maxOpen)
 	}
 	if maxIdle := viper.GetInt(constants.EnvMaxIdleConnections); maxIdle > 0 {
-		db.client.SetMaxIdleConns(maxIdle)
+		pool.SetMaxIdleConns(maxIdle)
 	}
 	if lifetime := viper.GetInt(constants.EnvConnectionMaxLifetime); lifetime > 0 {
-		db.client.SetConnMaxLifetime(time.Duration(lifetime) * time.Second)
+		pool.SetConnMaxLifetime(time.Duration(lifetime) * time.Second)
 	}
-
-	return db, nil
 }
 
-// buildConnectionString safely constructs the Postgres connection string.
-func buildConnectionString() string {
-	host := viper.GetString(constants.EnvDatabaseHost)
+// buildConnectionString safely constructs the Postgres connection string for the given host.
+// DB_SSLROOTCERT, DB_SSLCERT and DB_SSLKEY are passed as the sslrootcert, sslcert and sslkey
+// parameters, e.g. for verify-full against a managed Postgres with a private CA.
+func buildConnectionString(host string) (string, error) {
 	port := viper.GetString(constants.EnvDatabasePort)
 	user := viper.GetString(constants.EnvDatabaseUser)
 	password := viper.GetString(constants.EnvDatabasePassword)
@@ -69,9 +137,30 @@ func buildConnectionString() string {
 
 	q := u.Query()
 	q.Set("sslmode", sslmode)
+
+	sslFiles := map[string]string{
+		"sslrootcert": viper.GetString(constants.EnvDatabaseSSLRootCert),
+		"sslcert":     viper.GetString(constants.EnvDatabaseSSLCert),
+		"sslkey":      viper.GetString(constants.EnvDatabaseSSLKey),
+	}
+	if (sslFiles["sslcert"] == "") != (sslFiles["sslkey"] == "") {
+		return "", fmt.Errorf("both %s and %s must be set for client certificate authentication", constants.EnvDatabaseSSLCert, constants.EnvDatabaseSSLKey)
+	}
+	for param, path := range sslFiles {
+		if path == "" {
+			continue
+		}
+		// the files are only read when TLS is used, so a disabled sslmode doesn't require them
+		if sslmode != "disable" {
+			if _, err := os.Stat(path); err != nil {
+				return "", fmt.Errorf("%s file %s is not readable: %s", param, path, err)
+			}
+		}
+		q.Set(param, path)
+	}
 	u.RawQuery = q.Encode()
 
-	return u.String()
+	return u.String(), nil
 }
 
 func buildTablesMap() map[string]string {
@@ -81,14 +170,52 @@ func buildTablesMap() map[string]string {
 		"source":           fmt.Sprintf("olake-%s-source", runMode),
 		"dest":             fmt.Sprintf("olake-%s-destination", runMode),
 		"project-settings": fmt.Sprintf("olake-%s-project-settings", runMode),
+		"execution-log":    fmt.Sprintf("olake-%s-execution-log", runMode),
+		"state-history":    fmt.Sprintf("olake-%s-job-state-history", runMode),
 	}
 }
 
-// Close closes the underlying database connection.
+// Close closes the underlying database connections.
 func (d *DB) Close() error {
-	return d.client.Close()
+	var readerErr error
+	if d.reader != d.client {
+		readerErr = d.reader.Close()
+	}
+	return errors.Join(d.client.Close(), readerErr)
+}
+
+// PoolStats is a snapshot of a connection pool, taken from sql.DBStats
+type PoolStats struct {
+	MaxOpenConnections  int     `json:"max_open_connections"`
+	OpenConnections     int     `json:"open_connections"`
+	InUse               int     `json:"in_use"`
+	Idle                int     `json:"idle"`
+	WaitCount           int64   `json:"wait_count"`
+	WaitDurationSeconds float64 `json:"wait_duration_seconds"`
+}
+
+// PoolStats returns the stats of the primary pool, and of the read replica pool when one is in use.
+// A growing wait count or duration with in_use at max_open_connections points to pool exhaustion.
+func (d *DB) PoolStats() map[string]PoolStats {
+	stats := map[string]PoolStats{"primary": newPoolStats(d.client.Stats())}
+	if d.reader != d.client {
+		stats["replica"] = newPoolStats(d.reader.Stats())
+	}
+	return stats
+}
+
+func newPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections:  s.MaxOpenConnections,
+		OpenConnections:     s.OpenConnections,
+		InUse:               s.InUse,
+		Idle:                s.Idle,
+		WaitCount:           s.WaitCount,
+		WaitDurationSeconds: s.WaitDuration.Seconds(),
+	}
 }
 
+// PingContext pings the primary database.
 func (d *DB) PingContext(ctx context.Context) error {
 	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
 	defer cancel()
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolStats(t *testing.T) {
+	primary := sql.OpenDB(&fakeJobTable{})
+	t.Cleanup(func() { primary.Close() })
+	primary.SetMaxOpenConns(4)
+
+	// hold one connection so the pool reports it in use
+	conn, err := primary.Conn(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db := &DB{client: primary, reader: primary}
+	stats := db.PoolStats()
+	require.Equal(t, map[string]PoolStats{
+		"primary": {MaxOpenConnections: 4, OpenConnections: 1, InUse: 1},
+	}, stats)
+
+	replica := sql.OpenDB(&fakeJobTable{})
+	t.Cleanup(func() { replica.Close() })
+	db.reader = replica
+
+	stats = db.PoolStats()
+	require.Len(t, stats, 2)
+	require.Equal(t, PoolStats{}, stats["replica"])
+}
+
+func TestBuildConnectionStringTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	for _, path := range []string{caFile, certFile, keyFile} {
+		require.NoError(t, os.WriteFile(path, []byte("pem"), 0o600))
+	}
+	missing := filepath.Join(dir, "missing.crt")
+
+	keys := []string{constants.EnvDatabasePort, constants.EnvDatabaseUser, constants.EnvDatabasePassword, constants.EnvDatabaseDatabase,
+		constants.EnvDatabaseSSLMode, constants.EnvDatabaseSSLRootCert, constants.EnvDatabaseSSLCert, constants.EnvDatabaseSSLKey}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			viper.Set(key, nil)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		sslmode    string
+		rootCert   string
+		cert       string
+		key        string
+		wantParams map[string]string
+		wantErr    string
+	}{
+		{
+			name:       "no files",
+			sslmode:    "require",
+			wantParams: map[string]string{},
+		},
+		{
+			name:       "private CA",
+			sslmode:    "verify-full",
+			rootCert:   caFile,
+			wantParams: map[string]string{"sslrootcert": caFile},
+		},
+		{
+			name:       "client certificate",
+			sslmode:    "verify-full",
+			rootCert:   caFile,
+			cert:       certFile,
+			key:        keyFile,
+			wantParams: map[string]string{"sslrootcert": caFile, "sslcert": certFile, "sslkey": keyFile},
+		},
+		{
+			name:    "certificate without key",
+			sslmode: "verify-full",
+			cert:    certFile,
+			wantErr: "both DB_SSLCERT and DB_SSLKEY must be set",
+		},
+		{
+			name:     "missing CA file",
+			sslmode:  "verify-ca",
+			rootCert: missing,
+			wantErr:  "sslrootcert file " + missing + " is not readable",
+		},
+		{
+			name:       "missing file with TLS disabled",
+			sslmode:    "disable",
+			rootCert:   missing,
+			wantParams: map[string]string{"sslrootcert": missing},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvDatabasePort, "5432")
+			viper.Set(constants.EnvDatabaseUser, "olake")
+			viper.Set(constants.EnvDatabasePassword, "secret")
+			viper.Set(constants.EnvDatabaseDatabase, "olake")
+			viper.Set(constants.EnvDatabaseSSLMode, tt.sslmode)
+			viper.Set(constants.EnvDatabaseSSLRootCert, tt.rootCert)
+			viper.Set(constants.EnvDatabaseSSLCert, tt.cert)
+			viper.Set(constants.EnvDatabaseSSLKey, tt.key)
+
+			connStr, err := buildConnectionString("postgres.internal")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			u, err := url.Parse(connStr)
+			require.NoError(t, err)
+			require.Equal(t, "postgres.internal:5432", u.Host)
+			require.Equal(t, tt.sslmode, u.Query().Get("sslmode"))
+			for _, param := range []string{"sslrootcert", "sslcert", "sslkey"} {
+				require.Equal(t, tt.wantParams[param], u.Query().Get(param), param)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/lib/pq"
+)
+
+// ensureExecutionLogTable creates the table holding persisted operation output if it does not exist
+func (db *DB) ensureExecutionLogTable(ctx context.Context) error {
+	query := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id SERIAL PRIMARY KEY,
+				workflow_id TEXT NOT NULL,
+				job_id INTEGER,
+				project_id TEXT,
+				command TEXT NOT NULL,
+				connector_type TEXT,
+				output TEXT NOT NULL,
+				truncated BOOLEAN NOT NULL DEFAULT FALSE,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)`,
+		pq.QuoteIdentifier(db.tables["execution-log"]))
+
+	return withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		_, err := db.client.ExecContext(cctx, query)
+		return err
+	})
+}
+
+// SaveExecutionOutput stores the captured output of an operation for audit. Output larger
+// than maxBytes keeps its tail, where the connector's result is, and is flagged as truncated;
+// maxBytes <= 0 stores it in full.
+func (db *DB) SaveExecutionOutput(ctx context.Context, req *types.ExecutionRequest, output string, maxBytes int) error {
+	log := logger.Log(ctx)
+
+	output, truncated := capOutput(output, maxBytes)
+	query := fmt.Sprintf(`
+			INSERT INTO %s (workflow_id, job_id, project_id, command, connector_type, output, truncated)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		pq.QuoteIdentifier(db.tables["execution-log"]))
+
+	err := withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		_, err := db.client.ExecContext(cctx, query, req.WorkflowID, req.JobID, req.ProjectID, string(req.Command), req.ConnectorType, output, truncated)
+		return err
+	})
+	if err != nil {
+		log.Error("failed to save execution output", "workflowID", req.WorkflowID, "error", err)
+		return fmt.Errorf("failed to save execution output: %s", err)
+	}
+
+	log.Debug("saved execution output", "workflowID", req.WorkflowID, "bytes", len(output), "truncated", truncated)
+	return nil
+}
+
+// capOutput keeps the last maxBytes of output without splitting a UTF-8 character
+func capOutput(output string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(output) <= maxBytes {
+		return output, false
+	}
+
+	start := len(output) - maxBytes
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return output[start:], true
+}
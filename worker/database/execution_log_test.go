@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutionLog records the rows inserted into the execution log table
+type fakeExecutionLog struct {
+	rows [][]driver.Value
+}
+
+func (f *fakeExecutionLog) Connect(context.Context) (driver.Conn, error) {
+	return fakeExecutionLogConn{f}, nil
+}
+func (f *fakeExecutionLog) Driver() driver.Driver { return nil }
+
+type fakeExecutionLogConn struct{ log *fakeExecutionLog }
+
+func (c fakeExecutionLogConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c fakeExecutionLogConn) Close() error              { return nil }
+func (c fakeExecutionLogConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c fakeExecutionLogConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	row := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		row = append(row, arg.Value)
+	}
+	c.log.rows = append(c.log.rows, row)
+	return driver.RowsAffected(1), nil
+}
+
+func TestCapOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		maxBytes      int
+		want          string
+		wantTruncated bool
+	}{
+		{name: "unlimited", output: "INFO done", want: "INFO done"},
+		{name: "within limit", output: "INFO done", maxBytes: 9, want: "INFO done"},
+		{name: "keeps the tail", output: "INFO starting\n{\"ok\":true}", maxBytes: 11, want: "{\"ok\":true}", wantTruncated: true},
+		// the cut would split "é", so it moves to the next character
+		{name: "multibyte character", output: "café!", maxBytes: 2, want: "!", wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := capOutput(tt.output, tt.maxBytes)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantTruncated, truncated)
+		})
+	}
+}
+
+func TestSaveExecutionOutput(t *testing.T) {
+	log := &fakeExecutionLog{}
+	conn := sql.OpenDB(log)
+	t.Cleanup(func() { conn.Close() })
+	db := &DB{client: conn, reader: conn, tables: map[string]string{"execution-log": "execution-log"}}
+
+	req := &types.ExecutionRequest{WorkflowID: "check-7-abc", JobID: 7, ProjectID: "project-a", Command: types.Check, ConnectorType: "postgres"}
+	require.NoError(t, db.SaveExecutionOutput(context.Background(), req, "INFO starting\n{\"ok\":true}", 11))
+
+	require.Equal(t, [][]driver.Value{{"check-7-abc", int64(7), "project-a", "check", "postgres", "{\"ok\":true}", true}}, log.rows)
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/lib/pq"
 )
 
@@ -33,10 +35,11 @@ func decryptJobData(jobData *types.JobData) error {
 	return nil
 }
 
-func (db *DB) GetJobData(ctx context.Context, jobId int) (types.JobData, error) {
+func (db *DB) GetJobData(ctx context.Context, jobId int) (_ types.JobData, err error) {
+	ctx, span := tracing.Start(ctx, "database.GetJobData", tracing.AttrJobID.Int(jobId))
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
-	cctx, cancel := context.WithTimeout(ctx, queryTimeout)
-	defer cancel()
 
 	query := fmt.Sprintf(`
 			SELECT j.name, j.streams_config, j.state, j.project_id, s.config, d.config, s.version, s.type
@@ -46,10 +49,15 @@ func (db *DB) GetJobData(ctx context.Context, jobId int) (types.JobData, error)
 			WHERE j.id = $1`,
 		db.tables["job"], db.tables["source"], db.tables["dest"])
 
-	rows := db.client.QueryRowContext(cctx, query, jobId)
-
 	var jobData types.JobData
-	if err := rows.Scan(&jobData.JobName, &jobData.Streams, &jobData.State, &jobData.ProjectID, &jobData.Source, &jobData.Destination, &jobData.Version, &jobData.Driver); err != nil {
+	err = withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		row := db.reader.QueryRowContext(cctx, query, jobId)
+		return row.Scan(&jobData.JobName, &jobData.Streams, &jobData.State, &jobData.ProjectID, &jobData.Source, &jobData.Destination, &jobData.Version, &jobData.Driver)
+	})
+	if err != nil {
 		log.Error("failed to get job data from database", "jobID", jobId, "error", err)
 		return types.JobData{}, fmt.Errorf("failed to scan job data: %w", err)
 	}
@@ -62,28 +70,78 @@ func (db *DB) GetJobData(ctx context.Context, jobId int) (types.JobData, error)
 	return jobData, nil
 }
 
-func (db *DB) UpdateJobState(ctx context.Context, jobId int, state string) error {
+// UpdateJobState saves the final state of a run and bumps the job's updated_at. runStartedAt
+// is when the run that produced the state started: once updated_at is past it, another run has
+// saved its final state since (or the job was edited, which also bumps updated_at), and the
+// write is rejected with ErrStaleState so a retried cleanup of an earlier run never overwrites
+// a newer state. A retried write of a state that is
+// already saved succeeds. A zero runStartedAt, from runs scheduled before it was recorded,
+// always writes.
+func (db *DB) UpdateJobState(ctx context.Context, jobId int, state string, runStartedAt time.Time) error {
+	return db.writeJobState(ctx, jobId, state, runStartedAt, true)
+}
+
+// CheckpointJobState saves the state of a running sync under the same rule as UpdateJobState,
+// leaving updated_at as it is so the run's own checkpoints don't make its final state stale
+func (db *DB) CheckpointJobState(ctx context.Context, jobId int, state string, runStartedAt time.Time) error {
+	return db.writeJobState(ctx, jobId, state, runStartedAt, false)
+}
+
+func (db *DB) writeJobState(ctx context.Context, jobId int, state string, runStartedAt time.Time, final bool) error {
 	log := logger.Log(ctx)
 
-	log.Info("updating job state", "jobID", jobId, "state", state)
+	log.Info("updating job state", "jobID", jobId, "state", state, "final", final)
 
 	tableName := pq.QuoteIdentifier(db.tables["job"])
 	query := fmt.Sprintf(`
 			UPDATE %s
-			SET state = $1, updated_at = NOW() 
+			SET state = $1%s
 			WHERE id = $2`,
-		tableName)
-
-	cctx, cancel := context.WithTimeout(ctx, queryTimeout)
-	defer cancel()
+		tableName, utils.Ternary(final, ", updated_at = NOW()", ""))
+	args := []any{state, jobId}
+	versioned := !runStartedAt.IsZero()
+	if versioned {
+		query += " AND updated_at <= $3"
+		args = append(args, runStartedAt.UTC())
+	}
 
-	_, err := db.client.ExecContext(cctx, query, state, jobId)
+	var updated int64
+	err := withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		result, err := db.client.ExecContext(cctx, query, args...)
+		if err != nil {
+			return err
+		}
+		updated, err = result.RowsAffected()
+		return err
+	})
 	if err != nil {
 		log.Error("failed to update job state", "jobID", jobId, "error", err)
 		return fmt.Errorf("failed to update job state: %s", err)
 	}
+	if updated == 0 && versioned {
+		if saved, err := db.savedJobState(ctx, jobId); err == nil && saved == state {
+			log.Info("job state already saved", "jobID", jobId)
+			return nil
+		}
+		log.Warn("rejected stale job state, the job was updated after the run started", "jobID", jobId, "runStartedAt", runStartedAt)
+		return fmt.Errorf("%w: job %d was updated after the run started at %s", constants.ErrStaleState, jobId, runStartedAt.UTC().Format(time.RFC3339))
+	}
 
 	log.Info("successfully updated job state", "jobID", jobId, "state", state)
 
 	return nil
 }
+
+// savedJobState reads the job's state from the primary, which a replica may not have caught up with
+func (db *DB) savedJobState(ctx context.Context, jobId int) (string, error) {
+	cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT COALESCE(state, '') FROM %s WHERE id = $1`, pq.QuoteIdentifier(db.tables["job"]))
+	var state string
+	err := db.client.QueryRowContext(cctx, query, jobId).Scan(&state)
+	return state, err
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobTable is a single job row answering the state queries of UpdateJobState the way
+// Postgres would, with now standing in for NOW()
+type fakeJobTable struct {
+	mu        sync.Mutex
+	state     string
+	updatedAt time.Time
+	now       time.Time
+}
+
+func (f *fakeJobTable) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeJobTable) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ table *fakeJobTable }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	t := c.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if strings.Contains(query, "updated_at <= $3") && t.updatedAt.After(args[2].Value.(time.Time)) {
+		return driver.RowsAffected(0), nil
+	}
+	t.state = args[0].Value.(string)
+	if strings.Contains(query, "updated_at = NOW()") {
+		t.updatedAt = t.now
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.table.mu.Lock()
+	defer c.table.mu.Unlock()
+	return &fakeRows{values: []string{c.table.state}}, nil
+}
+
+func newFakeStateDB(t *testing.T, table *fakeJobTable) *DB {
+	conn := sql.OpenDB(table)
+	t.Cleanup(func() { conn.Close() })
+	return &DB{client: conn, reader: conn, tables: map[string]string{"job": "job"}}
+}
+
+func TestUpdateJobStateRejectsStaleWrite(t *testing.T) {
+	ctx := context.Background()
+	olderRun := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	newerRun := olderRun.Add(time.Hour)
+	table := &fakeJobTable{updatedAt: olderRun.Add(-time.Hour)}
+	db := newFakeStateDB(t, table)
+
+	// the newer run finishes first
+	table.now = newerRun.Add(10 * time.Minute)
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"2"}`, newerRun))
+
+	// the older run's cleanup, retried after the newer run saved its state
+	table.now = newerRun.Add(20 * time.Minute)
+	err := db.UpdateJobState(ctx, 1, `{"lsn":"1"}`, olderRun)
+	require.ErrorIs(t, err, constants.ErrStaleState)
+	require.Equal(t, `{"lsn":"2"}`, table.state)
+
+	// a retry of the newer run's write, which already went through, is accepted
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"2"}`, newerRun))
+
+	// the next run wins over both
+	nextRun := newerRun.Add(time.Hour)
+	table.now = nextRun.Add(10 * time.Minute)
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"3"}`, nextRun))
+	require.Equal(t, `{"lsn":"3"}`, table.state)
+}
+
+func TestCheckpointJobStateKeepsFinalWrite(t *testing.T) {
+	ctx := context.Background()
+	runStartedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	table := &fakeJobTable{updatedAt: runStartedAt.Add(-time.Hour), now: runStartedAt.Add(time.Minute)}
+	db := newFakeStateDB(t, table)
+
+	// checkpoints leave updated_at alone, so the run's final write still goes through
+	require.NoError(t, db.CheckpointJobState(ctx, 1, `{"lsn":"1"}`, runStartedAt))
+	require.NoError(t, db.CheckpointJobState(ctx, 1, `{"lsn":"2"}`, runStartedAt))
+	require.Equal(t, runStartedAt.Add(-time.Hour), table.updatedAt)
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"3"}`, runStartedAt))
+	require.Equal(t, `{"lsn":"3"}`, table.state)
+
+	// a job edited after the run started rejects its checkpoints
+	table.now = runStartedAt.Add(2 * time.Hour)
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"4"}`, time.Time{}))
+	err := db.CheckpointJobState(ctx, 1, `{"lsn":"5"}`, runStartedAt)
+	require.ErrorIs(t, err, constants.ErrStaleState)
+	require.Equal(t, `{"lsn":"4"}`, table.state)
+}
+
+func TestUpdateJobStateWithoutRunStart(t *testing.T) {
+	ctx := context.Background()
+	runStartedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	table := &fakeJobTable{now: runStartedAt.Add(time.Hour)}
+	db := newFakeStateDB(t, table)
+
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"2"}`, runStartedAt))
+	// runs scheduled before the start time was recorded always write
+	require.NoError(t, db.UpdateJobState(ctx, 1, `{"lsn":"1"}`, time.Time{}))
+	require.Equal(t, `{"lsn":"1"}`, table.state)
+}
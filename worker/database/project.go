@@ -2,11 +2,18 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/lib/pq"
+
 	"github.com/datazip-inc/olake-helm/worker/types"
 )
 
+// undefinedColumnCode is the Postgres error code of a query naming a missing column
+const undefinedColumnCode = "42703"
+
 // GetProjectSettingsByProjectID fetches the project settings for a given project_id
 func (db *DB) GetProjectSettingsByProjectID(ctx context.Context, projectID string) (*types.ProjectSettings, error) {
 	if projectID == "" {
@@ -24,10 +31,52 @@ func (db *DB) GetProjectSettingsByProjectID(ctx context.Context, projectID strin
 
 	settings := &types.ProjectSettings{}
 
-	rows := db.client.QueryRowContext(cctx, query, projectID)
+	rows := db.reader.QueryRowContext(cctx, query, projectID)
 	if err := rows.Scan(&settings.ID, &settings.ProjectID, &settings.WebhookAlertURL); err != nil {
 		return nil, fmt.Errorf("failed to get project settings for project_id %s: %w", projectID, err)
 	}
 
 	return settings, nil
 }
+
+// GetPostSyncHookURL fetches the post_sync_hook_url of a project's settings. Settings tables
+// created before the column existed, and projects without settings, have no hook.
+func (db *DB) GetPostSyncHookURL(ctx context.Context, projectID string) (string, error) {
+	return db.getOptionalProjectSetting(ctx, projectID, "post_sync_hook_url")
+}
+
+// GetPagerDutyRoutingKey fetches the pagerduty_routing_key of a project's settings. Settings
+// tables created before the column existed, and projects without settings, have no key.
+func (db *DB) GetPagerDutyRoutingKey(ctx context.Context, projectID string) (string, error) {
+	return db.getOptionalProjectSetting(ctx, projectID, "pagerduty_routing_key")
+}
+
+// getOptionalProjectSetting fetches a text column of a project's settings that older settings
+// tables may lack, returning "" when the column, the settings row or the value is missing
+func (db *DB) getOptionalProjectSetting(ctx context.Context, projectID, column string) (string, error) {
+	if projectID == "" {
+		return "", nil
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(%s, '')
+		FROM %q
+		WHERE project_id = $1`,
+		pq.QuoteIdentifier(column), db.tables["project-settings"])
+
+	var value string
+	err := db.reader.QueryRowContext(cctx, query, projectID).Scan(&value)
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", nil
+	case errors.As(err, &pqErr) && pqErr.Code == undefinedColumnCode:
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to get %s for project_id %s: %w", column, projectID, err)
+	}
+	return value, nil
+}
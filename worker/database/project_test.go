@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSettings answers project settings lookups with the routing key stored per project, or
+// with err for every query
+type fakeSettings struct {
+	keys map[string]string
+	err  error
+}
+
+func (f *fakeSettings) Connect(context.Context) (driver.Conn, error) { return fakeSettingsConn{f}, nil }
+func (f *fakeSettings) Driver() driver.Driver                        { return nil }
+
+type fakeSettingsConn struct{ settings *fakeSettings }
+
+func (c fakeSettingsConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c fakeSettingsConn) Close() error { return nil }
+
+func (c fakeSettingsConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c fakeSettingsConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.settings.err != nil {
+		return nil, c.settings.err
+	}
+	key, ok := c.settings.keys[args[0].Value.(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	return &fakeRows{values: []string{key}}, nil
+}
+
+type fakeRows struct{ values []string }
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func newFakeSettingsDB(t *testing.T, settings *fakeSettings) *DB {
+	conn := sql.OpenDB(settings)
+	t.Cleanup(func() { conn.Close() })
+	return &DB{client: conn, reader: conn, tables: map[string]string{"project-settings": "project-settings"}}
+}
+
+func TestGetPagerDutyRoutingKey(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeSettingsDB(t, &fakeSettings{keys: map[string]string{"project-a": "key-a", "project-b": ""}})
+
+	key, err := db.GetPagerDutyRoutingKey(ctx, "project-a")
+	require.NoError(t, err)
+	require.Equal(t, "key-a", key)
+
+	// a project without settings, or without a key in them, falls back to the default
+	for _, projectID := range []string{"project-b", "project-c", ""} {
+		key, err = db.GetPagerDutyRoutingKey(ctx, projectID)
+		require.NoError(t, err)
+		require.Empty(t, key)
+	}
+}
+
+func TestGetPagerDutyRoutingKeyMissingColumn(t *testing.T) {
+	ctx := context.Background()
+
+	// settings tables created before the column existed
+	db := newFakeSettingsDB(t, &fakeSettings{err: &pq.Error{Code: undefinedColumnCode}})
+	key, err := db.GetPagerDutyRoutingKey(ctx, "project-a")
+	require.NoError(t, err)
+	require.Empty(t, key)
+
+	db = newFakeSettingsDB(t, &fakeSettings{err: errors.New("connection refused")})
+	_, err = db.GetPagerDutyRoutingKey(ctx, "project-a")
+	require.ErrorContains(t, err, "pagerduty_routing_key")
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/lib/pq"
+)
+
+const (
+	queryRetries       = 4
+	queryRetryDelay    = 500 * time.Millisecond
+	queryRetryMaxDelay = 5 * time.Second
+)
+
+// withRetry runs a query, retrying only transient connection failures (e.g. during a Postgres
+// failover). SQL errors such as a missing row are returned immediately, as is cancellation of ctx.
+func withRetry(ctx context.Context, query func() error) error {
+	var permanentErr error
+	err := utils.RetryWithBackoffJittered(func() error {
+		err := query()
+		if err != nil && (ctx.Err() != nil || !isTransientError(err)) {
+			permanentErr = err
+			return nil
+		}
+		return err
+	}, utils.BackoffOptions{MaxRetries: queryRetries, InitialDelay: queryRetryDelay, MaxDelay: queryRetryMaxDelay, Jitter: true})
+	if permanentErr != nil {
+		return permanentErr
+	}
+	return err
+}
+
+// isTransientError reports whether err is a connection level failure worth retrying
+func isTransientError(err error) bool {
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// class 08: connection exception; 57P01-57P03: server shutting down or not yet accepting connections
+		switch {
+		case pqErr.Code.Class() == "08":
+			return true
+		case pqErr.Code == "57P01", pqErr.Code == "57P02", pqErr.Code == "57P03":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "wrapped eof", err: fmt.Errorf("scan: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "query timeout", err: context.DeadlineExceeded, want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "connection exception", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "server shutting down", err: &pq.Error{Code: "57P01"}, want: true},
+		{name: "server starting up", err: &pq.Error{Code: "57P03"}, want: true},
+		{name: "missing row", err: sql.ErrNoRows},
+		{name: "undefined column", err: &pq.Error{Code: "42703"}},
+		{name: "other error", err: errors.New("decrypt failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isTransientError(tt.err))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Run("retries transient errors", func(t *testing.T) {
+		var calls int
+		err := withRetry(context.Background(), func() error {
+			calls++
+			if calls == 1 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("returns permanent errors at once", func(t *testing.T) {
+		var calls int
+		err := withRetry(context.Background(), func() error {
+			calls++
+			return sql.ErrNoRows
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var calls int
+		err := withRetry(ctx, func() error {
+			calls++
+			return driver.ErrBadConn
+		})
+		require.ErrorIs(t, err, driver.ErrBadConn)
+		require.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+)
+
+// ensureStateHistoryTable creates the table holding past job states if it does not exist
+func (db *DB) ensureStateHistoryTable(ctx context.Context) error {
+	tableName := pq.QuoteIdentifier(db.tables["state-history"])
+	queries := []string{
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id SERIAL PRIMARY KEY,
+				job_id INTEGER NOT NULL,
+				state TEXT NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)`, tableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (job_id, created_at)`,
+			pq.QuoteIdentifier(db.tables["state-history"]+"-job-idx"), tableName),
+	}
+
+	return withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		for _, query := range queries {
+			if _, err := db.client.ExecContext(cctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveStateHistory keeps the final state of a run in the job's history when STATE_HISTORY_LIMIT
+// is set. The history only lets a sync resume from an older state, so failing to keep it is
+// logged rather than returned.
+func (db *DB) SaveStateHistory(ctx context.Context, jobId int, state string) {
+	limit := viper.GetInt(constants.EnvStateHistoryLimit)
+	if !db.stateHistory || limit <= 0 {
+		return
+	}
+	if err := db.saveStateHistory(ctx, jobId, state, limit); err != nil {
+		logger.Log(ctx).Warn("failed to save job state history", "jobID", jobId, "error", err)
+	}
+}
+
+// saveStateHistory records a state of the job, keeping only its latest limit entries
+func (db *DB) saveStateHistory(ctx context.Context, jobId int, state string, limit int) error {
+	tableName := pq.QuoteIdentifier(db.tables["state-history"])
+	insertQuery := fmt.Sprintf(`INSERT INTO %s (job_id, state) VALUES ($1, $2)`, tableName)
+	pruneQuery := fmt.Sprintf(`
+			DELETE FROM %[1]s
+			WHERE job_id = $1 AND id NOT IN (
+				SELECT id FROM %[1]s WHERE job_id = $1 ORDER BY id DESC LIMIT $2
+			)`,
+		tableName)
+
+	return withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		if _, err := db.client.ExecContext(cctx, insertQuery, jobId, state); err != nil {
+			return err
+		}
+		_, err := db.client.ExecContext(cctx, pruneQuery, jobId, limit)
+		return err
+	})
+}
+
+// GetJobStateByRef loads a past state of the job. ref is either the id of a state history
+// entry or an RFC 3339 timestamp, which picks the latest state saved at or before it.
+func (db *DB) GetJobStateByRef(ctx context.Context, jobId int, ref string) (string, error) {
+	log := logger.Log(ctx)
+
+	tableName := pq.QuoteIdentifier(db.tables["state-history"])
+	var query string
+	var arg any
+	if id, err := strconv.Atoi(ref); err == nil {
+		query = fmt.Sprintf(`SELECT state FROM %s WHERE job_id = $1 AND id = $2`, tableName)
+		arg = id
+	} else if at, err := time.Parse(time.RFC3339, ref); err == nil {
+		query = fmt.Sprintf(`
+			SELECT state FROM %s
+			WHERE job_id = $1 AND created_at <= $2
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1`,
+			tableName)
+		arg = at
+	} else {
+		return "", fmt.Errorf("invalid state ref %q: expected a state history id or an RFC 3339 timestamp", ref)
+	}
+
+	var state string
+	err := withRetry(ctx, func() error {
+		cctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		return db.reader.QueryRowContext(cctx, query, jobId, arg).Scan(&state)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("no state found for job %d at ref %q", jobId, ref)
+	}
+	if err != nil {
+		log.Error("failed to get job state by ref", "jobID", jobId, "ref", ref, "error", err)
+		return "", fmt.Errorf("failed to get job state by ref: %s", err)
+	}
+
+	return state, nil
+}
@@ -1,13 +1,17 @@
 package docker
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,11 +20,14 @@ import (
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/moby/moby/api/pkg/stdcopy"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/registry"
 	"github.com/moby/moby/client"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -33,9 +40,12 @@ type ContainerState struct {
 	ExitCode *int
 }
 
-func (d *DockerExecutor) PullImage(ctx context.Context, imageName, version string) error {
+func (d *DockerExecutor) PullImage(ctx context.Context, imageName, version string) (err error) {
+	ctx, span := tracing.Start(ctx, "docker.PullImage", attribute.String("olake.image", imageName))
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
-	_, err := d.client.ImageInspect(ctx, imageName)
+	_, err = d.client.ImageInspect(ctx, imageName)
 	if err != nil {
 		pullCtx, cancel := context.WithTimeout(ctx, DockerPullTimeout)
 		defer cancel()
@@ -100,10 +110,14 @@ func (d *DockerExecutor) getContainerLogs(ctx context.Context, containerID strin
 	}
 	defer reader.Close()
 
-	var stdoutBuf, stderrBuf bytes.Buffer
-	if _, err := stdcopy.StdCopy(&stdoutBuf, &stderrBuf, reader); err != nil {
+	maxBytes := viper.GetInt(constants.EnvMaxConnectorOutputBytes)
+	stdoutBuf, stderrBuf := utils.NewOutputBuffer(maxBytes), utils.NewOutputBuffer(maxBytes)
+	if _, err := stdcopy.StdCopy(stdoutBuf, stderrBuf, reader); err != nil {
 		return nil, err
 	}
+	if dropped := stdoutBuf.Truncated() + stderrBuf.Truncated(); dropped > 0 {
+		logger.Log(ctx).Warn("connector output over limit, kept its start and end", "containerID", containerID, "droppedBytes", dropped, "limit", maxBytes)
+	}
 
 	// Prefer stdout, but include stderr if present
 	if stderrBuf.Len() > 0 && stdoutBuf.Len() == 0 {
@@ -115,6 +129,15 @@ func (d *DockerExecutor) getContainerLogs(ctx context.Context, containerID strin
 	return stdoutBuf.Bytes(), nil
 }
 
+// exitReason reports whether a stopped container was killed for running out of memory
+func (d *DockerExecutor) exitReason(ctx context.Context, containerID string) string {
+	inspect, err := d.client.ContainerInspect(ctx, containerID, client.ContainerInspectOptions{})
+	if err == nil && inspect.Container.State != nil && inspect.Container.State.OOMKilled {
+		return constants.ExitReasonOOMKilled
+	}
+	return constants.ExitReasonError
+}
+
 // getContainerState inspects a container and returns its state
 func (d *DockerExecutor) getContainerState(ctx context.Context, name, workflowID string) ContainerState {
 	log := logger.Log(ctx)
@@ -144,8 +167,11 @@ func (d *DockerExecutor) StopContainer(ctx context.Context, workflowID string) e
 		return fmt.Errorf("empty container name")
 	}
 
-	// Graceful stop with timeout
+	// Graceful stop with timeout, giving the connector time to flush state on SIGTERM
 	timeout := constants.ContainerStopTimeout
+	if grace := viper.GetInt(constants.EnvSyncPodTerminationGrace); grace > 0 {
+		timeout = grace
+	}
 	if _, err := d.client.ContainerStop(ctx, containerName, client.ContainerStopOptions{Timeout: &timeout}); err != nil {
 		log.Warn("docker stop failed, attempting kill", "workflowID", workflowID, "containerName", containerName, "error", err)
 		if _, kerr := d.client.ContainerKill(ctx, containerName, client.ContainerKillOptions{Signal: "SIGKILL"}); kerr != nil {
@@ -164,6 +190,27 @@ func (d *DockerExecutor) StopContainer(ctx context.Context, workflowID string) e
 	return nil
 }
 
+// verifyImageDigest checks the local image against the digest expected for its source type in
+// CONNECTOR_IMAGE_DIGESTS, so a tag moved to other content doesn't run. A registry records the
+// digest of each image pulled from it in RepoDigests.
+func (d *DockerExecutor) verifyImageDigest(ctx context.Context, imageName, expected string) error {
+	if expected == "" {
+		return nil
+	}
+
+	inspect, err := d.client.ImageInspect(ctx, imageName)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image %s: %s", imageName, err)
+	}
+	for _, repoDigest := range inspect.RepoDigests {
+		if _, digest, _ := strings.Cut(repoDigest, "@"); digest == expected {
+			logger.Log(ctx).Debug("image digest verified", "image", imageName, "digest", expected)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: image %s has digests [%s], expected %s", constants.ErrExecutionFailed, imageName, strings.Join(inspect.RepoDigests, ", "), expected)
+}
+
 // registryAuth returns the base64url-encoded registry credentials for image pulls,
 // built from CONTAINER_REGISTRY_USERNAME/PASSWORD. When unset it returns "", so the
 // Docker daemon falls back to its own credential store (host `docker login` / IAM) -
@@ -187,6 +234,150 @@ func registryAuth() string {
 	return base64.URLEncoding.EncodeToString(encoded)
 }
 
+// containerLabels identifies the job and workflow a connector container runs for
+func containerLabels(req *types.ExecutionRequest) map[string]string {
+	return map[string]string{
+		"olake.io/job-id":         strconv.Itoa(req.JobID),
+		"olake.io/workflow-id":    req.WorkflowID,
+		"olake.io/operation-type": string(req.Command),
+	}
+}
+
+// checkJobNotRunning returns ErrJobAlreadyRunning when a sync or clear-destination container of
+// another workflow is still running for the job, e.g. a scheduled sync that outlasted its
+// interval. Containers created before they were labelled are not detected.
+func (d *DockerExecutor) checkJobNotRunning(ctx context.Context, jobID int, containerName string) error {
+	filters := make(client.Filters).Add("label", fmt.Sprintf("olake.io/job-id=%d", jobID))
+	result, err := d.client.ContainerList(ctx, client.ContainerListOptions{Filters: filters})
+	if err != nil {
+		return fmt.Errorf("failed to list containers of job %d: %s", jobID, err)
+	}
+
+	for _, c := range result.Items {
+		if slices.Contains(c.Names, "/"+containerName) {
+			continue
+		}
+		if !slices.Contains(constants.AsyncCommands, types.Command(c.Labels["olake.io/operation-type"])) {
+			continue
+		}
+		return fmt.Errorf("%w: job %d has container %s of workflow %s in state %s", constants.ErrJobAlreadyRunning, jobID, strings.Join(c.Names, ","), c.Labels["olake.io/workflow-id"], c.State)
+	}
+	return nil
+}
+
+// connectorNetwork returns the network mode of connector containers from
+// CONNECTOR_DOCKER_NETWORK, or "" for the daemon's default bridge. A configured
+// network that does not exist is an error rather than a late container create failure.
+func (d *DockerExecutor) connectorNetwork(ctx context.Context) (container.NetworkMode, error) {
+	network := strings.TrimSpace(viper.GetString(constants.EnvConnectorDockerNetwork))
+	if network == "" {
+		return "", nil
+	}
+
+	if _, err := d.client.NetworkInspect(ctx, network, client.NetworkInspectOptions{}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", fmt.Errorf("docker network %q set in %s does not exist, create it or attach the worker to the stack's network", network, constants.EnvConnectorDockerNetwork)
+		}
+		return "", fmt.Errorf("failed to inspect docker network %q: %s", network, err)
+	}
+	return container.NetworkMode(network), nil
+}
+
+// connectorResources returns the memory and CPU limits of connector containers from
+// CONNECTOR_DOCKER_MEMORY_LIMIT and CONNECTOR_DOCKER_CPU_LIMIT, using Kubernetes quantity
+// syntax (2Gi, 1.5, 500m). Unset or invalid limits leave the container unlimited.
+func connectorResources() container.Resources {
+	var resources container.Resources
+
+	if value := strings.TrimSpace(viper.GetString(constants.EnvConnectorDockerMemoryLimit)); value != "" {
+		memory, err := resource.ParseQuantity(value)
+		if err != nil || memory.Value() <= 0 {
+			logger.Warnf("ignoring invalid %s value %q", constants.EnvConnectorDockerMemoryLimit, value)
+		} else {
+			resources.Memory = memory.Value()
+			// swap equal to the limit keeps a connector from swapping past it
+			resources.MemorySwap = memory.Value()
+		}
+	}
+
+	if value := strings.TrimSpace(viper.GetString(constants.EnvConnectorDockerCPULimit)); value != "" {
+		cpu, err := resource.ParseQuantity(value)
+		if err != nil || cpu.MilliValue() <= 0 {
+			logger.Warnf("ignoring invalid %s value %q", constants.EnvConnectorDockerCPULimit, value)
+		} else {
+			resources.NanoCPUs = cpu.MilliValue() * 1e6
+		}
+	}
+
+	return resources
+}
+
+// hostGateway is the ExtraHosts address Docker replaces with the host's gateway IP
+const hostGateway = "host-gateway"
+
+// connectorExtraHosts returns the /etc/hosts entries of connector containers from
+// CONNECTOR_EXTRA_HOSTS, a comma separated list of host:ip (e.g. db.internal:10.0.0.5 or
+// on-host.local:host-gateway). Invalid entries are logged and ignored.
+func connectorExtraHosts() []string {
+	var hosts []string
+	for _, entry := range strings.Split(viper.GetString(constants.EnvConnectorExtraHosts), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// the host name can't contain ':', an IPv6 address can
+		host, ip, ok := strings.Cut(entry, ":")
+		if _, err := netip.ParseAddr(ip); !ok || host == "" || (err != nil && ip != hostGateway) {
+			logger.Warnf("ignoring invalid %s entry %q, expected host:ip", constants.EnvConnectorExtraHosts, entry)
+			continue
+		}
+		hosts = append(hosts, host+":"+ip)
+	}
+	return hosts
+}
+
+// connectorDNS returns the DNS servers of connector containers from CONNECTOR_DNS, a comma
+// separated list of IPs; unset uses the daemon's resolvers. Invalid addresses are ignored.
+func connectorDNS() []netip.Addr {
+	var servers []netip.Addr
+	for _, value := range strings.Split(viper.GetString(constants.EnvConnectorDNS), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			logger.Warnf("ignoring invalid %s value %q", constants.EnvConnectorDNS, value)
+			continue
+		}
+		servers = append(servers, addr)
+	}
+	return servers
+}
+
+// runAsWorkerUser is the CONNECTOR_RUN_AS_USER value running connectors as the worker's own UID/GID
+const runAsWorkerUser = "worker"
+
+// containerUserPattern matches a Docker user: a name or UID, optionally followed by a group or GID
+var containerUserPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*(:[A-Za-z0-9_][A-Za-z0-9_.-]*)?$`)
+
+// connectorUser returns the user connector containers run as from CONNECTOR_RUN_AS_USER, so files
+// they write to the bind-mounted workdir are owned by a user the worker can read and remove.
+// "worker" resolves to the worker's UID:GID; unset or invalid values keep the image's user.
+func connectorUser() string {
+	value := strings.TrimSpace(viper.GetString(constants.EnvConnectorRunAsUser))
+	switch {
+	case value == "":
+		return ""
+	case value == runAsWorkerUser:
+		return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+	case !containerUserPattern.MatchString(value):
+		logger.Warnf("ignoring invalid %s value %q", constants.EnvConnectorRunAsUser, value)
+		return ""
+	}
+	return value
+}
+
 func (d *DockerExecutor) startContainer(ctx context.Context, containerID string) error {
 	log := logger.Log(ctx)
 	_, err := d.client.ContainerStart(ctx, containerID, client.ContainerStartOptions{})
@@ -216,12 +407,14 @@ func (d *DockerExecutor) waitForContainerCompletion(ctx context.Context, contain
 		case status := <-statusCh:
 			if status.StatusCode != 0 {
 				logOutput, _ := d.getContainerLogs(ctx, containerID)
-				log.Error("container exited with non-zero status", "containerID", containerID, "statusCode", status.StatusCode)
-				return fmt.Errorf("%w: container %s exited with status %d: %s",
-					constants.ErrExecutionFailed,
-					containerID,
-					status.StatusCode,
-					string(logOutput))
+				reason := d.exitReason(ctx, containerID)
+				log.Error("container exited with non-zero status", "containerID", containerID, "statusCode", status.StatusCode, "reason", reason)
+				return &constants.ConnectorExitError{
+					ExitCode: int(status.StatusCode),
+					Reason:   reason,
+					Detail:   fmt.Sprintf("container %s exited with status %d (reason: %s): %s", containerID, status.StatusCode, reason, string(logOutput)),
+					Output:   string(logOutput),
+				}
 			}
 			return nil
 
@@ -237,7 +430,7 @@ func (d *DockerExecutor) waitForContainerCompletion(ctx context.Context, contain
 			}
 			return nil
 
-		case <-time.After(5 * time.Second):
+		case <-time.After(utils.GetHeartbeatInterval(ctx)):
 			// continue
 		}
 	}
@@ -262,7 +455,7 @@ func (d *DockerExecutor) shouldStartOperation(ctx context.Context, req *types.Ex
 		log.Info("container exited", "workflowID", req.WorkflowID, "containerName", containerName, "exitCode", *state.ExitCode)
 
 		if *state.ExitCode == 0 {
-			return &types.Result{OK: false, Message: "sync status: completed"}, nil
+			return &types.Result{OK: false, Message: constants.SyncStatusCompletedMessage}, nil
 		}
 
 		if req.Command == types.ClearDestination {
@@ -284,5 +477,40 @@ func (d *DockerExecutor) shouldStartOperation(ctx context.Context, req *types.Ex
 
 	// Skip if container is not running, was already launched (logs exist), and no new run is needed.
 	log.Info("container already handled, skipping launch", "workflowID", req.WorkflowID, "containerName", containerName)
-	return &types.Result{OK: false, Message: "sync status: skipped"}, nil
+	return &types.Result{OK: false, Message: constants.SyncStatusSkippedMessage}, nil
+}
+
+// SampleResources reads one stats sample of the connector container. The daemon takes two
+// readings a second apart so CPU usage can be computed from their difference.
+func (d *DockerExecutor) SampleResources(ctx context.Context, req *types.ExecutionRequest) (*types.ResourceSample, error) {
+	containerName := utils.GetWorkflowDirectory(req.Command, req.WorkflowID)
+	result, err := d.client.ContainerStats(ctx, containerName, client.ContainerStatsOptions{IncludePreviousSample: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %s", err)
+	}
+	defer result.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(result.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %s", err)
+	}
+
+	var cpuCores float64
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpuCores = cpuDelta / systemDelta * float64(max(stats.CPUStats.OnlineCPUs, 1))
+	}
+
+	// page cache is reclaimable, leave it out like `docker stats` does
+	memory := stats.MemoryStats.Usage
+	if inactive := stats.MemoryStats.Stats["inactive_file"]; inactive < memory {
+		memory -= inactive
+	}
+
+	return &types.ResourceSample{
+		CPUCores:         cpuCores,
+		MemoryBytes:      int64(memory),
+		MemoryLimitBytes: int64(stats.MemoryStats.Limit),
+	}, nil
 }
@@ -0,0 +1,264 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+// newTestExecutor returns a DockerExecutor talking to a fake daemon that answers each API path
+// (without the version prefix) with a status and JSON body
+func newTestExecutor(t *testing.T, responses map[string]fakeResponse) *DockerExecutor {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if _, rest, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/"); ok && strings.HasPrefix(path, "/v") {
+			path = "/" + rest
+		}
+		resp, ok := responses[path]
+		if !ok {
+			resp = fakeResponse{status: http.StatusNotFound, body: `{"message":"not found"}`}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.New(client.WithHost("tcp://"+server.Listener.Addr().String()), client.WithAPIVersion("1.47"))
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+	return &DockerExecutor{client: cli}
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+// setEnv sets worker settings for the test, resetting them afterwards
+func setEnv(t *testing.T, env map[string]string) {
+	for key, value := range env {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+}
+
+func TestConnectorNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		daemon  map[string]fakeResponse
+		want    container.NetworkMode
+		wantErr string
+	}{
+		{name: "default bridge"},
+		{
+			name:    "existing network",
+			network: " olake-network ",
+			daemon:  map[string]fakeResponse{"/networks/olake-network": {status: http.StatusOK, body: `{"Name":"olake-network","Id":"abc"}`}},
+			want:    "olake-network",
+		},
+		{
+			name:    "missing network",
+			network: "olake-network",
+			wantErr: `docker network "olake-network" set in CONNECTOR_DOCKER_NETWORK does not exist`,
+		},
+		{
+			name:    "daemon error",
+			network: "olake-network",
+			daemon:  map[string]fakeResponse{"/networks/olake-network": {status: http.StatusInternalServerError, body: `{"message":"daemon busy"}`}},
+			wantErr: `failed to inspect docker network "olake-network"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{constants.EnvConnectorDockerNetwork: tt.network})
+			d := newTestExecutor(t, tt.daemon)
+
+			mode, err := d.connectorNetwork(context.Background())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, mode)
+		})
+	}
+}
+
+func TestConnectorResources(t *testing.T) {
+	tests := []struct {
+		name   string
+		memory string
+		cpu    string
+		want   container.Resources
+	}{
+		{name: "unlimited"},
+		{name: "binary memory", memory: "4Gi", want: container.Resources{Memory: 4 << 30, MemorySwap: 4 << 30}},
+		{name: "decimal memory", memory: " 512M ", want: container.Resources{Memory: 512e6, MemorySwap: 512e6}},
+		{name: "whole cpus", cpu: "2", want: container.Resources{NanoCPUs: 2e9}},
+		{name: "fractional cpus", cpu: "1.5", want: container.Resources{NanoCPUs: 1.5e9}},
+		{name: "millicpus", cpu: "500m", want: container.Resources{NanoCPUs: 5e8}},
+		{name: "both", memory: "1Gi", cpu: "250m", want: container.Resources{Memory: 1 << 30, MemorySwap: 1 << 30, NanoCPUs: 2.5e8}},
+		{name: "invalid memory", memory: "lots", cpu: "1", want: container.Resources{NanoCPUs: 1e9}},
+		{name: "zero memory", memory: "0", want: container.Resources{}},
+		{name: "negative cpu", memory: "1Gi", cpu: "-1", want: container.Resources{Memory: 1 << 30, MemorySwap: 1 << 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{
+				constants.EnvConnectorDockerMemoryLimit: tt.memory,
+				constants.EnvConnectorDockerCPULimit:    tt.cpu,
+			})
+			require.Equal(t, tt.want, connectorResources())
+		})
+	}
+}
+
+func TestVerifyImageDigest(t *testing.T) {
+	const (
+		image    = "olakego/source-postgres:v0.2.0"
+		pinned   = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		moved    = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+		inspects = "/images/" + image + "/json"
+	)
+
+	tests := []struct {
+		name     string
+		expected string
+		daemon   map[string]fakeResponse
+		wantErr  string
+		mismatch bool
+	}{
+		{name: "no pinned digest"},
+		{
+			name:     "matching digest",
+			expected: pinned,
+			daemon:   map[string]fakeResponse{inspects: {status: http.StatusOK, body: `{"RepoDigests":["registry.example.com/olakego/source-postgres@` + moved + `","olakego/source-postgres@` + pinned + `"]}`}},
+		},
+		{
+			name:     "digest mismatch",
+			expected: pinned,
+			daemon:   map[string]fakeResponse{inspects: {status: http.StatusOK, body: `{"RepoDigests":["olakego/source-postgres@` + moved + `"]}`}},
+			wantErr:  "has digests [olakego/source-postgres@" + moved + "], expected " + pinned,
+			mismatch: true,
+		},
+		{
+			name:     "locally built image",
+			expected: pinned,
+			daemon:   map[string]fakeResponse{inspects: {status: http.StatusOK, body: `{"RepoDigests":[]}`}},
+			wantErr:  "has digests [], expected " + pinned,
+			mismatch: true,
+		},
+		{
+			name:     "missing image",
+			expected: pinned,
+			wantErr:  "failed to inspect image " + image,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestExecutor(t, tt.daemon)
+
+			err := d.verifyImageDigest(context.Background(), image, tt.expected)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+			// a mismatch fails the run rather than being retried
+			require.Equal(t, tt.mismatch, errors.Is(err, constants.ErrExecutionFailed))
+		})
+	}
+}
+
+func TestConnectorUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "image user"},
+		{name: "uid", value: "1000", want: "1000"},
+		{name: "uid and gid", value: " 1000:1000 ", want: "1000:1000"},
+		{name: "name and group", value: "olake:staff", want: "olake:staff"},
+		{name: "worker", value: "worker", want: fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())},
+		{name: "empty group", value: "1000:", want: ""},
+		{name: "extra field", value: "1000:1000:1000", want: ""},
+		{name: "whitespace", value: "olake user", want: ""},
+		{name: "shell characters", value: "1000;rm", want: ""},
+		{name: "leading dash", value: "-1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{constants.EnvConnectorRunAsUser: tt.value})
+			require.Equal(t, tt.want, connectorUser())
+		})
+	}
+}
+
+func TestConnectorExtraHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "unset"},
+		{name: "ipv4", value: "db.internal:10.0.0.5", want: []string{"db.internal:10.0.0.5"}},
+		{name: "ipv6", value: "db6.internal:fd00::5", want: []string{"db6.internal:fd00::5"}},
+		{name: "ipv6 loopback", value: "localhost6:::1", want: []string{"localhost6:::1"}},
+		{name: "host gateway", value: "on-host.local:host-gateway", want: []string{"on-host.local:host-gateway"}},
+		{
+			name:  "list with invalid entries",
+			value: " db.internal:10.0.0.5 , no-ip, :10.0.0.6, bad.local:not-an-ip, ,db6.internal:[fd00::5], cache.local:10.0.0.7",
+			want:  []string{"db.internal:10.0.0.5", "cache.local:10.0.0.7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{constants.EnvConnectorExtraHosts: tt.value})
+			require.Equal(t, tt.want, connectorExtraHosts())
+		})
+	}
+}
+
+func TestConnectorDNS(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []netip.Addr
+	}{
+		{name: "daemon resolvers"},
+		{name: "ipv4", value: "10.0.0.2", want: []netip.Addr{netip.MustParseAddr("10.0.0.2")}},
+		{
+			name:  "mixed list",
+			value: " 10.0.0.2 ,fd00::53,, dns.internal, 8.8.8.8:53",
+			want:  []netip.Addr{netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("fd00::53")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{constants.EnvConnectorDNS: tt.value})
+			require.Equal(t, tt.want, connectorDNS())
+		})
+	}
+}
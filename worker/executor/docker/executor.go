@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/mount"
 	"github.com/moby/moby/client"
@@ -26,10 +28,19 @@ func NewDockerExecutor() (*DockerExecutor, error) {
 		return nil, fmt.Errorf("failed to create docker client: %s", err)
 	}
 
-	return &DockerExecutor{client: client, workingDir: utils.GetConfigDir()}, nil
+	d := &DockerExecutor{client: client, workingDir: utils.GetConfigDir()}
+
+	// the network may still be created after the worker starts, so runs re-check it
+	if _, err := d.connectorNetwork(context.Background()); err != nil {
+		logger.Errorf("connector containers cannot start until this is fixed: %s", err)
+	}
+	return d, nil
 }
 
-func (d *DockerExecutor) Execute(ctx context.Context, req *types.ExecutionRequest, workdir string) (string, error) {
+func (d *DockerExecutor) Execute(ctx context.Context, req *types.ExecutionRequest, workdir string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "docker.Execute", tracing.RequestAttributes(req)...)
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
 	imageName := utils.GetDockerImageName(req.ConnectorType, req.Version)
 	containerName := utils.GetWorkflowDirectory(req.Command, req.WorkflowID)
@@ -44,6 +55,9 @@ func (d *DockerExecutor) Execute(ctx context.Context, req *types.ExecutionReques
 		if !startOperation.OK {
 			return startOperation.Message, nil
 		}
+		if err := d.checkJobNotRunning(ctx, req.JobID, containerName); err != nil {
+			return "", err
+		}
 	}
 
 	if err := d.PullImage(ctx, imageName, req.Version); err != nil {
@@ -51,19 +65,44 @@ func (d *DockerExecutor) Execute(ctx context.Context, req *types.ExecutionReques
 		return "", err
 	}
 
-	// Environment variables propagation
+	expectedDigest, err := utils.GetExpectedImageDigest(req.ConnectorType)
+	if err != nil {
+		return "", err
+	}
+	if err := d.verifyImageDigest(ctx, imageName, expectedDigest); err != nil {
+		log.Error("image digest verification failed", "image", imageName, "error", err)
+		return "", err
+	}
+
+	// Environment variables propagation, the job's env overriding the worker's
+	envVars := utils.GetWorkerEnvVars()
+	maps.Copy(envVars, utils.GetJobEnvVars(req.Env))
+	maps.Copy(envVars, utils.GetTriggerEnvVars(req))
 	var envs []string
-	for k, v := range utils.GetWorkerEnvVars() {
+	for k, v := range envVars {
 		envs = append(envs, fmt.Sprintf("%s=%s", k, v))
 	}
 
 	containerConfig := &container.Config{
-		Image: imageName,
-		Cmd:   req.Args,
-		Env:   envs,
+		Image:  imageName,
+		Cmd:    utils.AppendExtraArgs(req.Args, req.ExtraArgs),
+		Env:    envs,
+		Labels: containerLabels(req),
+		User:   connectorUser(),
+	}
+
+	networkMode, err := d.connectorNetwork(ctx)
+	if err != nil {
+		log.Error("invalid connector network", "error", err)
+		return "", err
 	}
 
-	hostConfig := &container.HostConfig{}
+	hostConfig := &container.HostConfig{
+		NetworkMode: networkMode,
+		Resources:   connectorResources(),
+		ExtraHosts:  connectorExtraHosts(),
+		DNS:         connectorDNS(),
+	}
 	if workdir != "" {
 		hostOutputDir := utils.GetHostOutputDir(workdir)
 		hostConfig.Mounts = []mount.Mount{
@@ -2,8 +2,13 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/database"
@@ -12,18 +17,33 @@ import (
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/statestore"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
+	"github.com/spf13/viper"
 )
 
 // Executor interface for k8s and docker executor
 type Executor interface {
 	Execute(ctx context.Context, req *types.ExecutionRequest, workdir string) (string, error)
 	Cleanup(ctx context.Context, req *types.ExecutionRequest) error
+	SampleResources(ctx context.Context, req *types.ExecutionRequest) (*types.ResourceSample, error)
 	Close() error
 }
 
+// jobDB is the part of database.DB the executor reads and saves job data through
+type jobDB interface {
+	GetJobData(ctx context.Context, jobId int) (types.JobData, error)
+	UpdateJobState(ctx context.Context, jobId int, state string, runStartedAt time.Time) error
+	SaveStateHistory(ctx context.Context, jobId int, state string)
+	SaveExecutionOutput(ctx context.Context, req *types.ExecutionRequest, output string, maxBytes int) error
+}
+
 type AbstractExecutor struct {
-	executor Executor
-	db       *database.DB
+	executor   Executor
+	db         jobDB
+	stateStore statestore.Store
+	// the job directories, where connectors read and write their copy of the state file
+	workdirState *statestore.FileStore
 }
 
 // NewExecutor creates and returns the executor client based on the executor environment
@@ -44,76 +64,252 @@ func NewExecutor(ctx context.Context, db *database.DB) (*AbstractExecutor, error
 	if err != nil {
 		return nil, err
 	}
-	return &AbstractExecutor{executor: exec, db: db}, nil
+
+	store, err := statestore.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AbstractExecutor{executor: exec, db: db, stateStore: store, workdirState: statestore.NewFileStore()}, nil
 }
 
-func (a *AbstractExecutor) Execute(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+func (a *AbstractExecutor) Execute(ctx context.Context, req *types.ExecutionRequest) (_ *types.ExecutorResponse, err error) {
+	ctx, span := tracing.Start(ctx, "executor.Execute", tracing.RequestAttributes(req)...)
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
 	subdir, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
 
+	// rejected before the connector runs rather than after it has produced its output
+	if req.OutputCompression != types.OutputCompressionNone && req.OutputCompression != types.OutputCompressionGzip {
+		return nil, fmt.Errorf("%w: unsupported output compression: %s", constants.ErrExecutionFailed, req.OutputCompression)
+	}
+
 	// write config files only for the first/scheduled workflow execution (not for retries)
 	if !utils.WorkflowAlreadyLaunched(workdir) && req.Configs != nil {
-		if err := utils.WriteConfigFiles(workdir, req.Configs); err != nil {
+		if err := a.writeConfigFiles(ctx, subdir, workdir, req.Configs); err != nil {
 			log.Error("failed to write config files", "workdir", workdir, "error", err)
 			return nil, err
 		}
 	}
 
 	output, err := a.executor.Execute(ctx, req, workdir)
+	if errors.Is(err, constants.ErrJobAlreadyRunning) {
+		log.Warn("skipping run, job already running", "jobID", req.JobID, "command", req.Command, "error", err)
+		return &types.ExecutorResponse{Response: err.Error(), Status: types.SyncStatusSkipped}, nil
+	}
 	if err != nil {
 		log.Error("executor failed", "command", req.Command, "error", err)
 		return nil, err
 	}
+
+	// adopted or already handled async runs carry no connector output to parse
+	switch output {
+	case constants.SyncStatusCompletedMessage:
+		return &types.ExecutorResponse{Response: output, Status: types.SyncStatusCompleted}, nil
+	case constants.SyncStatusSkippedMessage:
+		return &types.ExecutorResponse{Response: output, Status: types.SyncStatusSkipped}, nil
+	}
 	if req.Command != types.Sync {
-		log.Info("executor output", "environment", utils.GetExecutorEnvironment(), "output", logger.StripANSI(output))
+		log.Info("executor output", "environment", utils.GetExecutorEnvironment(), "output", logger.StripANSI(output), logger.FinalField, true)
+	}
+
+	// keep the output of short operations for audit; a failed write never fails the operation
+	if !slices.Contains(constants.AsyncCommands, req.Command) && viper.GetBool(constants.EnvPersistOutputToDB) {
+		if err := a.db.SaveExecutionOutput(ctx, req, logger.StripANSI(output), viper.GetInt(constants.EnvPersistOutputMaxBytes)); err != nil {
+			log.Warn("failed to persist execution output", "command", req.Command, "error", err)
+		}
 	}
 
 	// generated file as response
 	if req.OutputFile != "" {
-		filePath := filepath.Join(subdir, req.OutputFile)
-		return &types.ExecutorResponse{Response: filePath}, nil
+		fileName := req.OutputFile
+		if req.OutputCompression == types.OutputCompressionGzip {
+			compressedPath, err := utils.CompressFile(filepath.Join(workdir, req.OutputFile))
+			if err != nil {
+				log.Error("failed to compress output file", "file", req.OutputFile, "error", err)
+				return nil, err
+			}
+			fileName = filepath.Base(compressedPath)
+		}
+		return &types.ExecutorResponse{Response: filepath.Join(subdir, fileName)}, nil
 	}
 
-	outputJSON, err := utils.ExtractJSONAndMarshal(output)
+	outputJSON, err := utils.ExtractConnectorOutput(output, req)
 	if err != nil {
 		log.Error("failed to extract JSON from output", "error", err)
 		return nil, err
 	}
 
-	outputPath := filepath.Join(workdir, constants.OutputFileName)
-	if err := utils.WriteFile(outputPath, outputJSON); err != nil {
-		log.Error("failed to write output file", "path", outputPath, "error", err)
+	outputPath, err := utils.WriteOutputFile(filepath.Join(workdir, constants.OutputFileName), outputJSON, req.OutputCompression)
+	if err != nil {
+		log.Error("failed to write output file", "workdir", workdir, "error", err)
 		return nil, err
 	}
 
 	// logs as response
-	return &types.ExecutorResponse{Response: filepath.Join(subdir, constants.OutputFileName)}, nil
+	return &types.ExecutorResponse{Response: filepath.Join(subdir, filepath.Base(outputPath))}, nil
+}
+
+// writeConfigFiles writes the job's config files to the workflow directory, and the state the
+// run starts from to the state store as well
+func (a *AbstractExecutor) writeConfigFiles(ctx context.Context, subdir, workdir string, configs []types.JobConfig) error {
+	files := make([]types.JobConfig, 0, len(configs))
+	for _, config := range configs {
+		if config.Name == "state.json" {
+			if err := a.stateStore.WriteState(ctx, subdir, config.Data); err != nil {
+				return fmt.Errorf("failed to write %s: %s", config.Name, err)
+			}
+			if err := a.workdirState.WriteState(ctx, subdir, config.Data); err != nil {
+				return fmt.Errorf("failed to write %s: %s", config.Name, err)
+			}
+			continue
+		}
+		files = append(files, config)
+	}
+	return utils.WriteConfigFiles(workdir, files)
 }
 
 // CleanupAndPersistState stops the container/pod and saves the state file in the database
 func (a *AbstractExecutor) CleanupAndPersistState(ctx context.Context, req *types.ExecutionRequest) error {
 	log := logger.Log(ctx)
 
+	// a run skipped for an overlapping run started nothing, and its state file predates that
+	// run; an already handled run was cleaned up and persisted when it was handled
+	if req.Status == types.SyncStatusSkipped {
+		log.Info("run skipped, leaving pod/container and saved state untouched", "jobID", req.JobID, "workflowID", req.WorkflowID)
+		return nil
+	}
+
 	if err := a.executor.Cleanup(ctx, req); err != nil {
 		log.Error("failed to cleanup executor", "workflowID", req.WorkflowID, "error", err)
 		return err
 	}
 
-	stateFile, err := utils.GetStateFileFromWorkdir(req.WorkflowID, req.Command)
+	// a dry run deletes nothing, so the persisted state stays as it was
+	if req.DryRun {
+		log.Info("dry run, skipping state persistence", "jobID", req.JobID)
+		return nil
+	}
+
+	// a run that started from an empty or past state only replaces the saved state once it completes
+	if req.Options.OverridesState() && req.Status != types.SyncStatusCompleted {
+		log.Info("sync with overridden state did not complete, keeping saved state", "jobID", req.JobID, "status", req.Status)
+		return nil
+	}
+
+	// the connector's copy holds the state it ended with
+	subdir := utils.GetWorkflowDirectory(req.Command, req.WorkflowID)
+	stateFile, err := a.workdirState.ReadState(ctx, subdir)
 	if err != nil {
 		log.Error("failed to read state file", "workflowID", req.WorkflowID, "error", err)
 		return err
 	}
 
-	if err := a.db.UpdateJobState(ctx, req.JobID, stateFile); err != nil {
+	if err := a.checkStateRegression(ctx, req, stateFile); err != nil {
+		return err
+	}
+
+	if err := a.stateStore.WriteState(ctx, subdir, stateFile); err != nil {
+		log.Error("failed to save state to the state store", "jobID", req.JobID, "error", err)
+		return err
+	}
+
+	if err := a.db.UpdateJobState(ctx, req.JobID, stateFile, req.RunStartedAt); err != nil {
 		log.Error("failed to update job state in database", "jobID", req.JobID, "error", err)
 		return err
 	}
+	// checkpoints aren't kept, so the history holds the states runs ended with
+	a.db.SaveStateHistory(ctx, req.JobID, stateFile)
 
-	log.Info("successfully cleaned up and persisted state", "jobID", req.JobID)
+	log.Info("successfully cleaned up and persisted state", "jobID", req.JobID, logger.FinalField, true)
+
+	if viper.GetBool(constants.EnvCleanupWorkdirOnSuccess) {
+		removeCompletedWorkdir(ctx, req)
+	}
 	return nil
 }
 
+// removeCompletedWorkdir deletes the configs and state file of a manually started sync that
+// completed and whose state is persisted, keeping its logs, which olake-ui shows for the run.
+// Failed or cancelled runs keep their directory, as a retry resumes from it, and so do skipped
+// runs, whose directory belongs to the run that was adopted or already handled. Runs started by
+// a schedule, which can be paused and resumed, are left to the age-based cleanup.
+func removeCompletedWorkdir(ctx context.Context, req *types.ExecutionRequest) {
+	log := logger.Log(ctx)
+	if req.Command != types.Sync || req.Status != types.SyncStatusCompleted {
+		return
+	}
+	if req.Trigger == nil || req.Trigger.Type != types.TriggerManual {
+		log.Info("keeping workflow directory of scheduled sync", "workflowID", req.WorkflowID)
+		return
+	}
+
+	_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	entries, err := os.ReadDir(workdir)
+	if err != nil {
+		log.Warn("failed to read workflow directory", "workflowID", req.WorkflowID, "workdir", workdir, "error", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.Name() == "logs" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(workdir, entry.Name())); err != nil {
+			log.Warn("failed to remove workflow file", "workflowID", req.WorkflowID, "file", entry.Name(), "error", err)
+			return
+		}
+	}
+	log.Info("removed workflow configs and state after successful sync", "workflowID", req.WorkflowID, "workdir", workdir)
+}
+
+// checkStateRegression compares the connector's configured monotonic marker in the new state
+// against the persisted one. A regression is logged in warn mode and blocks the state from
+// being persisted in fail mode.
+func (a *AbstractExecutor) checkStateRegression(ctx context.Context, req *types.ExecutionRequest, newState string) error {
+	log := logger.Log(ctx)
+
+	mode := strings.ToLower(viper.GetString(constants.EnvStateRegressionCheck))
+	if mode != utils.StateRegressionWarn && mode != utils.StateRegressionFail {
+		return nil
+	}
+	markerPath := utils.GetStateMarkerPath(req.ConnectorType)
+	if markerPath == "" {
+		return nil
+	}
+
+	jobDetails, err := a.db.GetJobData(ctx, req.JobID)
+	if err != nil {
+		log.Warn("failed to get prior state, skipping state regression check", "jobID", req.JobID, "error", err)
+		return nil
+	}
+
+	regressed, err := utils.IsStateRegressed(jobDetails.State, newState, markerPath)
+	if err != nil {
+		log.Warn("failed to compare state markers, skipping state regression check", "jobID", req.JobID, "marker", markerPath, "error", err)
+		return nil
+	}
+	if !regressed {
+		return nil
+	}
+
+	log.Warn("connector reported a state older than the persisted one", "jobID", req.JobID, "connector", req.ConnectorType, "marker", markerPath)
+	if mode == utils.StateRegressionFail {
+		return fmt.Errorf("%w: job %d marker %s moved backwards, state not persisted", constants.ErrStateRegression, req.JobID, markerPath)
+	}
+	return nil
+}
+
+// SampleResources reads the current CPU and memory usage of the request's connector container
+func (a *AbstractExecutor) SampleResources(ctx context.Context, req *types.ExecutionRequest) (*types.ResourceSample, error) {
+	return a.executor.SampleResources(ctx, req)
+}
+
+// Cleanup removes the request's pod/container without persisting its state, so a retried
+// attempt starts a new one instead of adopting the failed one
+func (a *AbstractExecutor) Cleanup(ctx context.Context, req *types.ExecutionRequest) error {
+	return a.executor.Cleanup(ctx, req)
+}
+
 func (a *AbstractExecutor) Close() {
 	a.executor.Close()
 }
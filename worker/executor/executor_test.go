@@ -0,0 +1,278 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/statestore"
+)
+
+// fakeExecutor stands in for the docker/kubernetes executor of a finished run
+type fakeExecutor struct {
+	output string
+}
+
+func (f *fakeExecutor) Execute(context.Context, *types.ExecutionRequest, string) (string, error) {
+	return f.output, nil
+}
+func (f *fakeExecutor) Cleanup(context.Context, *types.ExecutionRequest) error { return nil }
+func (f *fakeExecutor) SampleResources(context.Context, *types.ExecutionRequest) (*types.ResourceSample, error) {
+	return nil, nil
+}
+func (f *fakeExecutor) Close() error { return nil }
+
+// fakeJobDB records the job state saved by the executor
+type fakeJobDB struct {
+	state          string
+	updateStateErr error
+	history        []string
+	outputs        []string
+}
+
+func (f *fakeJobDB) GetJobData(context.Context, int) (types.JobData, error) {
+	return types.JobData{State: f.state}, nil
+}
+
+func (f *fakeJobDB) UpdateJobState(_ context.Context, _ int, state string, _ time.Time) error {
+	if f.updateStateErr != nil {
+		return f.updateStateErr
+	}
+	f.state = state
+	return nil
+}
+
+func (f *fakeJobDB) SaveStateHistory(_ context.Context, _ int, state string) {
+	f.history = append(f.history, state)
+}
+
+func (f *fakeJobDB) SaveExecutionOutput(_ context.Context, _ *types.ExecutionRequest, output string, _ int) error {
+	f.outputs = append(f.outputs, output)
+	return nil
+}
+
+// newTestExecutor returns an executor on the filesystem state store and a sync request whose
+// workflow directory holds a state file and connector logs
+func newTestExecutor(t *testing.T, db *fakeJobDB) (*AbstractExecutor, *types.ExecutionRequest, string) {
+	req := &types.ExecutionRequest{
+		Command:    types.Sync,
+		JobID:      1,
+		WorkflowID: fmt.Sprintf("sync-%s-%d", t.Name(), time.Now().UnixNano()),
+		Status:     types.SyncStatusCompleted,
+		Trigger:    &types.TriggerContext{Type: types.TriggerManual},
+	}
+	_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	require.NoError(t, os.MkdirAll(filepath.Join(workdir, "logs", "sync_1"), 0o755))
+	t.Cleanup(func() { os.RemoveAll(workdir) })
+	for name, data := range map[string]string{
+		"state.json":             `{"lsn":"2"}`,
+		"source.json":            `{}`,
+		"logs/sync_1/olake.log":  "synced",
+		"logs/sync_1/worker.log": "done",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(workdir, name), []byte(data), 0o644))
+	}
+
+	store := statestore.NewFileStore()
+	return &AbstractExecutor{executor: &fakeExecutor{}, db: db, stateStore: store, workdirState: store}, req, workdir
+}
+
+func enableWorkdirCleanup(t *testing.T) {
+	viper.Set(constants.EnvCleanupWorkdirOnSuccess, true)
+	t.Cleanup(func() { viper.Set(constants.EnvCleanupWorkdirOnSuccess, nil) })
+}
+
+func TestCleanupRemovesCompletedWorkdir(t *testing.T) {
+	enableWorkdirCleanup(t)
+	db := &fakeJobDB{state: `{"lsn":"1"}`}
+	exec, req, workdir := newTestExecutor(t, db)
+
+	require.NoError(t, exec.CleanupAndPersistState(context.Background(), req))
+	require.Equal(t, `{"lsn":"2"}`, db.state)
+	require.Equal(t, []string{`{"lsn":"2"}`}, db.history)
+
+	// configs and state are gone, the logs olake-ui shows stay
+	entries, err := os.ReadDir(workdir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "logs", entries[0].Name())
+	require.FileExists(t, filepath.Join(workdir, "logs", "sync_1", "worker.log"))
+}
+
+func TestCleanupKeepsWorkdir(t *testing.T) {
+	manual := &types.TriggerContext{Type: types.TriggerManual}
+	tests := []struct {
+		name           string
+		updateStateErr error
+		trigger        *types.TriggerContext
+		status         types.SyncStatus
+	}{
+		{name: "state not saved", updateStateErr: errors.New("failed to update job state: connection refused"), trigger: manual, status: types.SyncStatusCompleted},
+		{name: "stale state", updateStateErr: fmt.Errorf("%w: job 1 was updated after the run started", constants.ErrStaleState), trigger: manual, status: types.SyncStatusCompleted},
+		{name: "scheduled run", trigger: &types.TriggerContext{Type: types.TriggerScheduled, ScheduleID: "schedule-1"}, status: types.SyncStatusCompleted},
+		{name: "unknown trigger", status: types.SyncStatusCompleted},
+		{name: "failed run", trigger: manual, status: types.SyncStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enableWorkdirCleanup(t)
+			db := &fakeJobDB{state: `{"lsn":"1"}`, updateStateErr: tt.updateStateErr}
+			exec, req, workdir := newTestExecutor(t, db)
+			req.Trigger, req.Status = tt.trigger, tt.status
+
+			err := exec.CleanupAndPersistState(context.Background(), req)
+			if tt.updateStateErr != nil {
+				require.ErrorIs(t, err, tt.updateStateErr)
+				require.Equal(t, `{"lsn":"1"}`, db.state)
+				require.Empty(t, db.history)
+			} else {
+				require.NoError(t, err)
+			}
+			require.FileExists(t, filepath.Join(workdir, "state.json"))
+			require.FileExists(t, filepath.Join(workdir, "source.json"))
+		})
+	}
+}
+
+func TestCleanupFullRefreshState(t *testing.T) {
+	tests := []struct {
+		status    types.SyncStatus
+		wantState string
+	}{
+		// an aborted full refresh leaves the saved state for the next run
+		{status: types.SyncStatusFailed, wantState: `{"lsn":"1"}`},
+		{status: types.SyncStatusCancelled, wantState: `{"lsn":"1"}`},
+		// a completed one replaces it with the state it computed
+		{status: types.SyncStatusCompleted, wantState: `{"lsn":"2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			db := &fakeJobDB{state: `{"lsn":"1"}`}
+			exec, req, _ := newTestExecutor(t, db)
+			req.Status = tt.status
+			req.Options.UseEmptyState = true
+
+			require.NoError(t, exec.CleanupAndPersistState(context.Background(), req))
+			require.Equal(t, tt.wantState, db.state)
+		})
+	}
+}
+
+func TestCleanupStateRegression(t *testing.T) {
+	viper.Set(constants.EnvStateRegressionMarkers, `{"postgres":"lsn"}`)
+	t.Cleanup(func() {
+		viper.Set(constants.EnvStateRegressionMarkers, nil)
+		viper.Set(constants.EnvStateRegressionCheck, nil)
+	})
+
+	tests := []struct {
+		mode      string
+		wantErr   error
+		wantState string
+	}{
+		{mode: utils.StateRegressionFail, wantErr: constants.ErrStateRegression, wantState: `{"lsn":"3"}`},
+		{mode: utils.StateRegressionWarn, wantState: `{"lsn":"2"}`},
+		{mode: utils.StateRegressionOff, wantState: `{"lsn":"2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			viper.Set(constants.EnvStateRegressionCheck, tt.mode)
+			// the connector ends on an older marker than the one saved
+			db := &fakeJobDB{state: `{"lsn":"3"}`}
+			exec, req, _ := newTestExecutor(t, db)
+			req.ConnectorType = "postgres"
+
+			err := exec.CleanupAndPersistState(context.Background(), req)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantState, db.state)
+		})
+	}
+}
+
+func TestExecutePersistsOutput(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvPersistOutputToDB, nil) })
+
+	const result = `{"connectionStatus":{"status":"SUCCEEDED"}}`
+	tests := []struct {
+		name    string
+		command types.Command
+		enabled bool
+		want    []string
+	}{
+		{name: "check", command: types.Check, enabled: true, want: []string{"INFO checking\n" + result}},
+		{name: "disabled", command: types.Check},
+		{name: "sync", command: types.Sync, enabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvPersistOutputToDB, tt.enabled)
+			db := &fakeJobDB{}
+			req := &types.ExecutionRequest{Command: tt.command, JobID: 1, WorkflowID: fmt.Sprintf("%s-%d", tt.command, time.Now().UnixNano())}
+			_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+			t.Cleanup(func() { os.RemoveAll(workdir) })
+			exec := &AbstractExecutor{executor: &fakeExecutor{output: "\x1b[32mINFO\x1b[0m checking\n" + result}, db: db}
+
+			_, err := exec.Execute(context.Background(), req)
+			require.NoError(t, err)
+			// stored without terminal colors
+			require.Equal(t, tt.want, db.outputs)
+		})
+	}
+}
+
+func TestExecuteOutputCompression(t *testing.T) {
+	const catalog = `{"type":"CATALOG","catalog":{"streams":[]}}`
+	discover := func(t *testing.T, compression types.OutputCompression) (*types.ExecutionRequest, string, string) {
+		req := &types.ExecutionRequest{Command: types.Discover, WorkflowID: fmt.Sprintf("discover-%d", time.Now().UnixNano()), OutputCompression: compression}
+		subdir, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+		t.Cleanup(func() { os.RemoveAll(workdir) })
+		return req, subdir, workdir
+	}
+	exec := &AbstractExecutor{executor: &fakeExecutor{output: "INFO discovering\n" + catalog}, db: &fakeJobDB{}}
+
+	t.Run("extracted output", func(t *testing.T) {
+		req, subdir, workdir := discover(t, types.OutputCompressionGzip)
+		result, err := exec.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(subdir, "output.json.gz"), result.Response)
+		require.FileExists(t, filepath.Join(workdir, "output.json.gz"))
+		require.NoFileExists(t, filepath.Join(workdir, "output.json"))
+	})
+
+	t.Run("generated file", func(t *testing.T) {
+		req, subdir, workdir := discover(t, types.OutputCompressionGzip)
+		req.OutputFile = "streams.json"
+		require.NoError(t, utils.WriteFile(filepath.Join(workdir, "streams.json"), []byte(catalog)))
+
+		result, err := exec.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(subdir, "streams.json.gz"), result.Response)
+		require.NoFileExists(t, filepath.Join(workdir, "streams.json"))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		req, _, workdir := discover(t, "zstd")
+		_, err := exec.Execute(context.Background(), req)
+		require.ErrorIs(t, err, constants.ErrExecutionFailed)
+		// rejected before the connector ran
+		require.NoDirExists(t, workdir)
+	})
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+const (
+	configCheckContainerName = "config-check"
+	defaultConfigCheckImage  = "busybox:latest"
+)
+
+// withConfigCheck adds an init container that fails fast with a readable message when the job's
+// workdir or any file the connector is pointed at under /mnt/config is missing or empty on the
+// PVC, instead of leaving the connector to crash with an opaque exit code. It must run before
+// the connector arguments are rewritten (e.g. by applyLocalState).
+func withConfigCheck(pod *corev1.Pod, image, subDir string) {
+	if image == "" {
+		return
+	}
+
+	connector := pod.Spec.Containers[0]
+	script := fmt.Sprintf("[ -d %[1]s ] || { echo \"job directory %[2]s is missing on the job volume (mounted at %[1]s)\"; exit 1; }\n", constants.ContainerMountDir, subDir)
+	for _, path := range configFileArgs(connector.Args) {
+		script += fmt.Sprintf("[ -s %[1]s ] || { echo \"required file %[1]s is missing or empty in job directory %[2]s\"; exit 1; }\n", path, subDir)
+	}
+
+	pod.Spec.InitContainers = append([]corev1.Container{{
+		Name:                     configCheckContainerName,
+		Image:                    image,
+		Command:                  []string{"/bin/sh", "-c", script},
+		VolumeMounts:             slices.Clone(connector.VolumeMounts),
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("16Mi"),
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+			},
+		},
+	}}, pod.Spec.InitContainers...)
+}
+
+// configFileArgs returns the connector arguments that are files under the config mount
+func configFileArgs(args []string) []string {
+	var paths []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, constants.ContainerMountDir+"/") {
+			paths = append(paths, arg)
+		}
+	}
+	return paths
+}
+
+// configCheckFailure returns the message of a failed config check init container, if any
+func configCheckFailure(pod *corev1.Pod) (string, bool) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != configCheckContainerName {
+			continue
+		}
+		if term := status.State.Terminated; term != nil && term.ExitCode != 0 {
+			return strings.TrimSpace(term.Message), true
+		}
+	}
+	return "", false
+}
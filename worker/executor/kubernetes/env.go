@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// validateEnvFromSource checks a profile envFrom entry references exactly one named
+// Secret or ConfigMap
+func validateEnvFromSource(source corev1.EnvFromSource) error {
+	if (source.SecretRef == nil) == (source.ConfigMapRef == nil) {
+		return fmt.Errorf("exactly one of secretRef or configMapRef must be set")
+	}
+	name := ""
+	if source.SecretRef != nil {
+		name = source.SecretRef.Name
+	} else {
+		name = source.ConfigMapRef.Name
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid name '%s': %s", name, errs)
+	}
+	if source.Prefix != "" {
+		if errs := validation.IsEnvVarName(source.Prefix); len(errs) > 0 {
+			return fmt.Errorf("invalid prefix '%s': %s", source.Prefix, errs)
+		}
+	}
+	return nil
+}
+
+// GetEnvForJob returns the connector environment variables and env sources configured for the given jobID
+func (k *KubernetesExecutor) GetEnvForJob(jobID int, operation types.Command) (map[string]string, []corev1.EnvFromSource) {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return nil, nil
+	}
+	return profile.Env, profile.EnvFrom
+}
+
+// withJobEnv sets the job's environment variables on the connector container, replacing
+// variables the worker already set there except JAVA_TOOL_OPTIONS, which is appended to so the
+// derived heap is kept unless the job sets its own -Xmx. Its env sources are appended after
+// olake-global-env so their keys win over the propagated worker env.
+func withJobEnv(container *corev1.Container, vars map[string]string, sources []corev1.EnvFromSource) {
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		index := slices.IndexFunc(container.Env, func(env corev1.EnvVar) bool { return env.Name == name })
+		if index >= 0 && name == constants.EnvJavaToolOptions {
+			container.Env[index].Value = strings.TrimSpace(container.Env[index].Value + " " + vars[name])
+			continue
+		}
+		if index >= 0 {
+			container.Env[index] = corev1.EnvVar{Name: name, Value: vars[name]}
+			continue
+		}
+		container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: vars[name]})
+	}
+	container.EnvFrom = append(container.EnvFrom, sources...)
+}
+
+// checkEnvFromSources verifies that the required Secrets and ConfigMaps a pod loads env from
+// exist. Without this a missing one leaves the pod in CreateContainerConfigError until the
+// operation times out.
+func (k *KubernetesExecutor) checkEnvFromSources(ctx context.Context, sources []corev1.EnvFromSource) error {
+	for _, source := range sources {
+		var err error
+		switch {
+		case source.SecretRef != nil && (source.SecretRef.Optional == nil || !*source.SecretRef.Optional):
+			err = k.checkSourceExists(ctx, "secret", source.SecretRef.Name, "loaded as env")
+		case source.ConfigMapRef != nil && (source.ConfigMapRef.Optional == nil || !*source.ConfigMapRef.Optional):
+			err = k.checkSourceExists(ctx, "configmap", source.ConfigMapRef.Name, "loaded as env")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
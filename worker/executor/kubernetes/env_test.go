@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func secretEnv(name string) corev1.EnvFromSource {
+	return corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}}
+}
+
+func configMapEnv(name string) corev1.EnvFromSource {
+	return corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}}}
+}
+
+func TestLoadJobProfilesEnv(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{
+		"env":{"AWS_REGION":"us-east-1","1BAD":"x","OLAKE_WORKFLOW_ID":"x","OLAKE_TRIGGER_TYPE":"x"},
+		"envFrom":[{"secretRef":{"name":"aws-creds"}},{"configMapRef":{"name":"tuning"},"prefix":"OLAKE_"},{"secretRef":{"name":"a"},"configMapRef":{"name":"b"}},{},{"secretRef":{"name":"Bad_Name"}},{"configMapRef":{"name":"tuning"},"prefix":"1-"}]
+	}}`)
+
+	// invalid and reserved entries are dropped
+	require.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, profiles[7].Env)
+	tuning := configMapEnv("tuning")
+	tuning.Prefix = "OLAKE_"
+	require.Equal(t, []corev1.EnvFromSource{secretEnv("aws-creds"), tuning}, profiles[7].EnvFrom)
+}
+
+func TestCreatePodSpecJobEnv(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: {
+		Env:     map[string]string{"AWS_REGION": "us-east-1", "BATCH_SIZE": "100"},
+		EnvFrom: []corev1.EnvFromSource{secretEnv("aws-creds")},
+	}})
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{
+		JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync,
+		// the request's env wins over the profile's, reserved names stay the worker's
+		Env:     map[string]string{"BATCH_SIZE": "500", "OLAKE_WORKFLOW_ID": "other"},
+		Trigger: &types.TriggerContext{Type: types.TriggerManual},
+	}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	env := make(map[string]string)
+	for _, variable := range pod.Spec.Containers[0].Env {
+		env[variable.Name] = variable.Value
+	}
+	require.Equal(t, "us-east-1", env["AWS_REGION"])
+	require.Equal(t, "500", env["BATCH_SIZE"])
+	require.Equal(t, "sync-7-abc", env["OLAKE_WORKFLOW_ID"])
+
+	// job env sources come after olake-global-env, so their keys win
+	envFrom := pod.Spec.Containers[0].EnvFrom
+	require.Equal(t, "olake-global-env", envFrom[0].ConfigMapRef.Name)
+	require.Equal(t, secretEnv("aws-creds"), envFrom[len(envFrom)-1])
+}
+
+func TestCheckEnvFromSources(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "aws-creds", Namespace: "olake"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "tuning", Namespace: "olake"}},
+	)
+	k := &KubernetesExecutor{client: client, namespace: "olake"}
+
+	optional := secretEnv("overrides")
+	optional.SecretRef.Optional = ptr.To(true)
+	tests := []struct {
+		name    string
+		sources []corev1.EnvFromSource
+		wantErr string
+	}{
+		{name: "present", sources: []corev1.EnvFromSource{secretEnv("aws-creds"), configMapEnv("tuning")}},
+		{name: "missing optional source", sources: []corev1.EnvFromSource{optional}},
+		{name: "missing secret", sources: []corev1.EnvFromSource{secretEnv("gcp-creds")}, wantErr: "secret gcp-creds loaded as env by the job profile not found in namespace olake"},
+		{name: "missing configmap", sources: []corev1.EnvFromSource{secretEnv("aws-creds"), configMapEnv("limits")}, wantErr: "configmap limits loaded as env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := k.checkEnvFromSources(context.Background(), tt.sources)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, constants.ErrExecutionFailed)
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
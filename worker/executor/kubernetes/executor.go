@@ -11,6 +11,7 @@ import (
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/spf13/viper"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -27,18 +28,27 @@ type KubernetesExecutor struct {
 	namespace     string
 	config        *KubernetesConfig
 	configWatcher *ConfigMapWatcher
+	keyWatcher    *SecretKeyWatcher // nil unless OLAKE_SECRET_KEY_SECRET is set
 }
 
 type KubernetesConfig struct {
-	Namespace          string
-	PVCName            string
-	ServiceAccount     string
-	JobServiceAccount  string
-	SecretKey          string
-	BasePath           string
-	WorkerIdentity     string
-	SecurityContext    *corev1.PodSecurityContext
-	JobPodAnnotations  map[string]string
+	Namespace         string
+	PVCName           string
+	ServiceAccount    string
+	JobServiceAccount string
+	BasePath          string
+	WorkerIdentity    string
+	SecurityContext   *corev1.PodSecurityContext
+	JobPodAnnotations map[string]string
+	JobPodLabels      map[string]string
+	MemoLabels        map[string]string // workflow memo key -> pod label key
+	MemoAnnotations   map[string]string // workflow memo key -> pod annotation key
+	OperationSelector map[string]string // node selector of discover/check/spec pods; nil uses the default profile
+	PriorityClasses   map[types.Command]string
+	TerminationGrace  int64  // seconds; 0 keeps the Kubernetes default
+	ConfigCheckImage  string // image of the config-check init container; empty disables it
+	LocalState        LocalStateConfig
+	SyncJob           SyncJobConfig
 }
 
 func NewKubernetesExecutor(ctx context.Context) (*KubernetesExecutor, error) {
@@ -65,7 +75,6 @@ func NewKubernetesExecutor(ctx context.Context) (*KubernetesExecutor, error) {
 	pvcName := viper.GetString(constants.EnvStoragePVCName)
 	serviceAccount := viper.GetString(constants.EnvJobServiceAccountName)
 	jobServiceAccount := viper.GetString(constants.EnvJobServiceAccountName)
-	secretKey := viper.GetString(constants.EnvSecretKey)
 	basePath := utils.GetConfigDir()
 
 	// Parse security context JSON if available
@@ -89,40 +98,142 @@ func NewKubernetesExecutor(ctx context.Context) (*KubernetesExecutor, error) {
 		}
 	}
 
+	jobPodAnnotations = withMeshSidecarExclusion(jobPodAnnotations, viper.GetBool(constants.EnvJobMeshSidecarExclusion))
+
+	terminationGrace := viper.GetInt64(constants.EnvSyncPodTerminationGrace)
+	if terminationGrace < 0 {
+		logger.Errorf("invalid %s value %d. using default.", constants.EnvSyncPodTerminationGrace, terminationGrace)
+		terminationGrace = 0
+	}
+
+	localState, err := parseLocalStateConfig(viper.GetString(constants.EnvSyncLocalStateVolume), viper.GetString(constants.EnvSyncLocalStateSizeLimit))
+	if err != nil {
+		logger.Errorf("%s. keeping state on the job volume.", err)
+	}
+
+	jobPodLabels := buildAmbientMeshLabels(
+		viper.GetBool(constants.EnvJobAmbientMesh),
+		viper.GetString(constants.EnvJobAmbientWaypoint),
+		viper.GetString(constants.EnvJobAmbientWaypointNamespace),
+	)
+
+	var configCheckImage string
+	if viper.GetBool(constants.EnvJobConfigCheck) {
+		configCheckImage = viper.GetString(constants.EnvJobConfigCheckImage)
+		if configCheckImage == "" {
+			configCheckImage = defaultConfigCheckImage
+		}
+	}
+
+	syncJob := SyncJobConfig{Enabled: viper.GetBool(constants.EnvSyncRunAsJob)}
+	if backoffLimit := viper.GetInt(constants.EnvSyncJobBackoffLimit); backoffLimit >= 0 {
+		syncJob.BackoffLimit = int32(backoffLimit)
+	} else {
+		logger.Errorf("invalid %s value %d. using 0.", constants.EnvSyncJobBackoffLimit, backoffLimit)
+	}
+
+	memoLabels := parseMemoMapping(constants.EnvJobMemoLabels)
+	memoAnnotations := parseMemoMapping(constants.EnvJobMemoAnnotations)
+	operationSelector := parseOperationNodeSelector(viper.GetString(constants.EnvOperationPodNodeSelector))
+	priorityClasses := parsePriorityClasses(viper.GetString(constants.EnvOperationPriorityClasses))
+
 	// Set worker identity
 	podName := viper.GetString(constants.EnvPodName)
-	workerIdenttity := fmt.Sprintf("olake.io/olake-workers/%s", podName)
+	workerIdenttity := workerIdentityPrefix + podName
 
 	watcher := NewConfigMapWatcher(ctx, clientset, namespace)
 	if err := watcher.Start(); err != nil {
 		logger.Errorf("failed to start config map watcher: %s", err)
 	}
 
-	return &KubernetesExecutor{
+	var keyWatcher *SecretKeyWatcher
+	if secretName := viper.GetString(constants.EnvSecretKeySecretName); secretName != "" {
+		keyWatcher = NewSecretKeyWatcher(ctx, clientset, namespace, secretName)
+		if err := keyWatcher.Start(); err != nil {
+			logger.Errorf("failed to start encryption key watcher: %s", err)
+		}
+	}
+
+	executor := &KubernetesExecutor{
 		client:        clientset,
 		namespace:     namespace,
 		configWatcher: watcher,
+		keyWatcher:    keyWatcher,
 		config: &KubernetesConfig{
 			Namespace:         namespace,
 			PVCName:           pvcName,
 			ServiceAccount:    serviceAccount,
 			JobServiceAccount: jobServiceAccount,
-			SecretKey:         secretKey,
 			BasePath:          basePath,
 			WorkerIdentity:    workerIdenttity,
 			SecurityContext:   securityContext,
 			JobPodAnnotations: jobPodAnnotations,
+			JobPodLabels:      jobPodLabels,
+			MemoLabels:        memoLabels,
+			MemoAnnotations:   memoAnnotations,
+			OperationSelector: operationSelector,
+			PriorityClasses:   priorityClasses,
+			TerminationGrace:  terminationGrace,
+			ConfigCheckImage:  configCheckImage,
+			LocalState:        localState,
+			SyncJob:           syncJob,
 		},
-	}, nil
+	}
+
+	// runs in the background so a large namespace doesn't hold up startup
+	go executor.reconcileOrphanPods(ctx)
+
+	return executor, nil
 }
 
-func (k *KubernetesExecutor) Execute(ctx context.Context, req *types.ExecutionRequest, workdir string) (string, error) {
+func (k *KubernetesExecutor) Execute(ctx context.Context, req *types.ExecutionRequest, workdir string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "kubernetes.Execute", tracing.RequestAttributes(req)...)
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
-	imageName := utils.GetDockerImageName(req.ConnectorType, req.Version)
+	// the kubelet verifies pulled content against a pinned digest
+	expectedDigest, err := utils.GetExpectedImageDigest(req.ConnectorType)
+	if err != nil {
+		return "", err
+	}
+	// per-architecture images follow the arch the job is scheduled on, pinning the pod to it
+	arch, archImages := utils.ConnectorImageArch(), utils.UsesArchImages(req.ConnectorType)
+	if nodeArch := k.GetNodeSelectorForJob(req.JobID, req.Command)[corev1.LabelArchStable]; archImages && nodeArch != "" {
+		arch = nodeArch
+	}
+	imageName, err := utils.PinImageDigest(utils.GetDockerImageNameForArch(req.ConnectorType, req.Version, arch), expectedDigest)
+	if err != nil {
+		return "", err
+	}
 	podSpec := k.CreatePodSpec(req, workdir, imageName)
+	if archImages {
+		withNodeArch(podSpec, arch)
+	}
 	log.Info("creating pod", "podName", podSpec.Name, "image", imageName)
 
-	if _, err := k.createPod(ctx, podSpec); err != nil {
+	if err := k.checkMountSources(ctx, k.GetMountsForJob(req.JobID, req.Command)); err != nil {
+		log.Error("job profile mount not available", "podName", podSpec.Name, "error", err)
+		return "", err
+	}
+	if _, envFrom := k.GetEnvForJob(req.JobID, req.Command); len(envFrom) > 0 {
+		if err := k.checkEnvFromSources(ctx, envFrom); err != nil {
+			log.Error("job profile env source not available", "podName", podSpec.Name, "error", err)
+			return "", err
+		}
+	}
+
+	if slices.Contains(constants.AsyncCommands, req.Command) {
+		if err := k.checkJobNotRunning(ctx, req.JobID, podSpec.Name); err != nil {
+			return "", err
+		}
+	}
+
+	useJob := k.runsAsJob(req.Command)
+	if useJob {
+		if err := k.createJob(ctx, buildJob(podSpec, k.config.SyncJob.BackoffLimit, req.Timeout)); err != nil {
+			return "", err
+		}
+	} else if _, err := k.createPod(ctx, podSpec); err != nil {
 		log.Error("failed to create pod", "podName", podSpec.Name, "error", err)
 		return "", err
 	}
@@ -138,17 +249,34 @@ func (k *KubernetesExecutor) Execute(ctx context.Context, req *types.ExecutionRe
 		}()
 	}
 
-	if err := k.waitForPodCompletion(ctx, podSpec.Name, req.Timeout, req.HeartbeatFunc); err != nil {
-		log.Error("pod failed to complete", "podName", podSpec.Name, "error", err)
+	// a Job's pod has a generated name, and is only known once it has completed
+	podName := podSpec.Name
+	if useJob {
+		podName, err = k.waitForJobCompletion(ctx, podSpec.Name, req.Timeout, req.HeartbeatFunc)
+	} else {
+		err = k.waitForPodCompletion(ctx, podName, req.Timeout, req.HeartbeatFunc)
+	}
+	if err != nil {
+		log.Error("pod failed to complete", "podName", podName, "error", err)
 		return "", err
 	}
 
-	logs, err := k.getPodLogs(ctx, podSpec.Name)
+	logs, err := k.getPodLogs(ctx, podName)
 	if err != nil {
-		log.Error("failed to get pod logs", "podName", podSpec.Name, "error", err)
+		log.Error("failed to get pod logs", "podName", podName, "error", err)
 		return "", fmt.Errorf("failed to get pod logs: %s", err)
 	}
 
+	// the connector's file log goes first so its stdout result stays the last output
+	if hasLogTail(podSpec) {
+		fileLogs, err := k.getContainerLogs(ctx, podName, logTailContainerName)
+		if err != nil {
+			log.Warn("failed to get log tail sidecar logs", "podName", podName, "error", err)
+		} else {
+			logs = fileLogs + logs
+		}
+	}
+
 	return logs, nil
 }
 
@@ -157,6 +285,39 @@ func (k *KubernetesExecutor) Cleanup(ctx context.Context, req *types.ExecutionRe
 	podName := k.sanitizeName(req.WorkflowID)
 	log.Info("cleaning up pod", "podName", podName, "workflowID", req.WorkflowID)
 
+	// Give the connector its grace window to flush state.json on SIGTERM before the
+	// state file is read back by CleanupAndPersistState. With a local state volume the
+	// pod must also be gone so the sidecar has copied the final state back to the PVC.
+	var gracePeriod int64
+	if k.config.TerminationGrace > 0 || k.config.LocalState.Enabled() {
+		gracePeriod = k.config.TerminationGrace
+		if gracePeriod == 0 {
+			gracePeriod = defaultLocalStateGrace
+		}
+	}
+
+	// runs started before SYNC_RUN_AS_K8S_JOB was enabled have a bare pod
+	if k.runsAsJob(req.Command) {
+		found, err := k.cleanupJob(ctx, podName, gracePeriod)
+		if err != nil {
+			log.Error("failed to cleanup job", "jobName", podName, "error", err)
+			return fmt.Errorf("failed to cleanup job: %s", err)
+		}
+		if found {
+			log.Info("job cleanup completed", "jobName", podName)
+			return nil
+		}
+	}
+
+	if gracePeriod > 0 {
+		if err := k.terminatePod(ctx, podName, gracePeriod); err != nil {
+			log.Error("failed to terminate pod", "podName", podName, "error", err)
+			return fmt.Errorf("failed to terminate pod: %s", err)
+		}
+		log.Info("pod cleanup completed", "podName", podName)
+		return nil
+	}
+
 	if err := k.cleanupPod(ctx, podName); err != nil {
 		log.Error("failed to cleanup pod", "podName", podName, "error", err)
 		return fmt.Errorf("failed to cleanup pod: %s", err)
@@ -168,5 +329,8 @@ func (k *KubernetesExecutor) Cleanup(ctx context.Context, req *types.ExecutionRe
 
 func (k *KubernetesExecutor) Close() error {
 	k.configWatcher.cancel()
+	if k.keyWatcher != nil {
+		k.keyWatcher.Stop()
+	}
 	return nil
 }
@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func TestCleanupGracePeriod(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     KubernetesConfig
+		podExists  bool
+		wantDelete []*int64 // grace periods of the pod deletes
+	}{
+		{name: "default grace", podExists: true, wantDelete: []*int64{nil}},
+		{name: "configured grace", config: KubernetesConfig{TerminationGrace: 45}, podExists: true, wantDelete: []*int64{ptr.To(int64(45))}},
+		{name: "pod already gone", config: KubernetesConfig{TerminationGrace: 45}, wantDelete: []*int64{ptr.To(int64(45))}},
+		{name: "local state volume", config: KubernetesConfig{LocalState: LocalStateConfig{Volume: LocalStateVolumeMemory}}, podExists: true, wantDelete: []*int64{ptr.To(defaultLocalStateGrace)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tt.podExists {
+				_, err := client.CoreV1().Pods("olake").Create(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sync-7-abc", Namespace: "olake"}}, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			k := &KubernetesExecutor{client: client, namespace: "olake", config: &tt.config}
+
+			require.NoError(t, k.Cleanup(context.Background(), &types.ExecutionRequest{WorkflowID: "sync-7-abc", Command: types.Sync}))
+
+			var deletes []*int64
+			for _, action := range client.Actions() {
+				if action, ok := action.(k8stesting.DeleteActionImpl); ok && action.GetResource().Resource == "pods" {
+					require.Equal(t, "sync-7-abc", action.GetName())
+					deletes = append(deletes, action.GetDeleteOptions().GracePeriodSeconds)
+				}
+			}
+			require.Equal(t, tt.wantDelete, deletes)
+
+			_, err := client.CoreV1().Pods("olake").Get(context.Background(), "sync-7-abc", metav1.GetOptions{})
+			require.True(t, apierrors.IsNotFound(err))
+		})
+	}
+}
@@ -1,21 +1,30 @@
 package kubernetes
 
 import (
+	"encoding/json"
+	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
+	"github.com/spf13/viper"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 )
 
 // getNodeSelectorForJob returns node selector configuration for the given jobID
 // Returns empty map if no mapping is found (graceful fallback)
-// Only applies node mapping for async operations (sync, clear destination)
+// Only applies node mapping for async operations (sync, clear destination);
+// short-lived operations use OPERATION_POD_NODE_SELECTOR when it is set
 func (k *KubernetesExecutor) GetNodeSelectorForJob(jobID int, operation types.Command) map[string]string {
 	// Check profiles for async operations
 	if slices.Contains(constants.AsyncCommands, operation) {
@@ -25,6 +34,8 @@ func (k *KubernetesExecutor) GetNodeSelectorForJob(jobID int, operation types.Co
 			}
 			return map[string]string{}
 		}
+	} else if k.config.OperationSelector != nil {
+		return k.config.OperationSelector
 	}
 
 	// Check default profile
@@ -55,6 +66,40 @@ func (k *KubernetesExecutor) GetNodeSelectorForJob(jobID int, operation types.Co
 	return make(map[string]string)
 }
 
+// withNodeArch schedules the pod on nodes of the given architecture. The selector is copied
+// since it can be a job profile's own map.
+func withNodeArch(pod *corev1.Pod, arch string) {
+	selector := maps.Clone(pod.Spec.NodeSelector)
+	if selector == nil {
+		selector = map[string]string{}
+	}
+	selector[corev1.LabelArchStable] = arch
+	pod.Spec.NodeSelector = selector
+}
+
+// parseOperationNodeSelector parses and validates OPERATION_POD_NODE_SELECTOR. An unset or
+// invalid value returns nil, leaving short-lived operation pods on the default profile.
+func parseOperationNodeSelector(raw string) map[string]string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	var selector map[string]string
+	if err := json.Unmarshal([]byte(raw), &selector); err != nil {
+		logger.Errorf("failed to unmarshal %s: %s. ignoring.", constants.EnvOperationPodNodeSelector, err)
+		return nil
+	}
+
+	stats := &JobMappingStats{}
+	valid, ok := validateJobMapping(0, selector, stats)
+	if !ok {
+		logger.Errorf("invalid %s: %s. ignoring.", constants.EnvOperationPodNodeSelector, strings.Join(stats.InvalidMappings, "; "))
+		return nil
+	}
+	logger.Infof("short-lived operation pods use node selector %v", valid)
+	return valid
+}
+
 // GetTolerationsForJob returns tolerations for the given jobID
 func (k *KubernetesExecutor) GetTolerationsForJob(jobID int, operation types.Command) []corev1.Toleration {
 	// 1. Check specific profile
@@ -80,6 +125,135 @@ func (k *KubernetesExecutor) GetTolerationsForJob(jobID int, operation types.Com
 	return []corev1.Toleration{}
 }
 
+// gpuResourceName is the extended resource exposed by the NVIDIA device plugin
+const gpuResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+// profilingMountPath is where wrapped connectors write profiler output; it is backed by the
+// profiling/ directory of the job's workdir so the output outlives the pod
+const profilingMountPath = "/mnt/profiling"
+
+// classificationKey is the label and annotation compliance scanners read to find pods handling regulated data
+const classificationKey = "data.olake.io/classification"
+
+// highAvailabilityKey labels connector pods of jobs whose profile sets highAvailability
+const highAvailabilityKey = "olake.io/high-availability"
+
+// resolveJobProfile returns the profile that applies to the given jobID.
+// Job-specific profiles only apply to async operations (sync, clear destination);
+// everything else falls back to the default profile (JobID 0).
+func (k *KubernetesExecutor) resolveJobProfile(jobID int, operation types.Command) (JobSchedulingConfig, bool) {
+	if slices.Contains(constants.AsyncCommands, operation) {
+		if profile, exists := k.configWatcher.GetJobProfile(jobID); exists {
+			return profile, true
+		}
+	}
+	return k.configWatcher.GetJobProfile(0)
+}
+
+// GetResourcesForJob returns the connector container resources for the given jobID.
+// Profile requests are layered over the defaults so a profile only needs to set limits.
+func (k *KubernetesExecutor) GetResourcesForJob(jobID int, operation types.Command) corev1.ResourceRequirements {
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: k.parseQuantity("256Mi"),
+			corev1.ResourceCPU:    k.parseQuantity("100m"),
+		},
+		// No limits by default for flexibility
+	}
+
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return resources
+	}
+
+	if profile.Resources != nil {
+		maps.Copy(resources.Requests, profile.Resources.Requests)
+		if len(profile.Resources.Limits) > 0 {
+			resources.Limits = maps.Clone(profile.Resources.Limits)
+		}
+	}
+
+	// Extended resources such as GPUs are only honoured as limits
+	if profile.GPU > 0 {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[gpuResourceName] = *resource.NewQuantity(int64(profile.GPU), resource.DecimalSI)
+	}
+	return resources
+}
+
+// withGPUToleration adds a toleration for the standard GPU node taint when the pod requests GPUs,
+// unless the profile already tolerates it.
+func withGPUToleration(tolerations []corev1.Toleration, resources corev1.ResourceRequirements) []corev1.Toleration {
+	if gpus, exists := resources.Limits[gpuResourceName]; !exists || gpus.IsZero() {
+		return tolerations
+	}
+	for _, t := range tolerations {
+		if t.Key == string(gpuResourceName) {
+			return tolerations
+		}
+	}
+	return append(slices.Clone(tolerations), corev1.Toleration{
+		Key:      string(gpuResourceName),
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	})
+}
+
+// buildJVMHeapEnv derives a JVM max heap from the container memory limit, since the JVM
+// does not size its heap from the cgroup limit on its own. The heap flag goes ahead of the
+// existing JAVA_TOOL_OPTIONS, so an -Xmx set there still wins. Returns nil when no memory
+// limit is set or the connector is not JVM-based.
+func buildJVMHeapEnv(connectorType string, resources corev1.ResourceRequirements, javaToolOptions string) []corev1.EnvVar {
+	limit, exists := resources.Limits[corev1.ResourceMemory]
+	if !exists || limit.IsZero() || !isJVMConnector(connectorType) {
+		return nil
+	}
+
+	headroom := viper.GetInt(constants.EnvConnectorJVMHeapHeadroom)
+	if headroom < 0 || headroom >= 100 {
+		logger.Warnf("invalid %s value %d, using default %d", constants.EnvConnectorJVMHeapHeadroom, headroom, constants.DefaultJVMHeapHeadroomPercent)
+		headroom = constants.DefaultJVMHeapHeadroomPercent
+	}
+
+	heapMiB := limit.Value() * int64(100-headroom) / 100 / (1024 * 1024)
+	if heapMiB <= 0 {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  constants.EnvJavaToolOptions,
+			Value: strings.TrimSpace(fmt.Sprintf("-Xmx%dm %s", heapMiB, javaToolOptions)),
+		},
+	}
+}
+
+// buildSecretKeyEnv passes the current encryption key to the connector, and the keys from
+// before a rotation as a comma separated OLAKE_SECRET_KEY_PREVIOUS
+func buildSecretKeyEnv(keys utils.SecretKeys) []corev1.EnvVar {
+	env := []corev1.EnvVar{{Name: constants.EnvSecretKey, Value: keys.Current}}
+	if len(keys.Previous) > 0 {
+		env = append(env, corev1.EnvVar{Name: constants.EnvSecretKeyPrevious, Value: strings.Join(keys.Previous, ",")})
+	}
+	return env
+}
+
+// isJVMConnector reports whether the connector runs on a JVM.
+// CONNECTOR_JVM_TYPES is a comma-separated list of connector types; when unset no connector is treated as JVM-based.
+func isJVMConnector(connectorType string) bool {
+	if connectorType == "" {
+		return false
+	}
+	for _, t := range strings.Split(viper.GetString(constants.EnvConnectorJVMTypes), ",") {
+		if strings.EqualFold(strings.TrimSpace(t), connectorType) {
+			return true
+		}
+	}
+	return false
+}
+
 func (k *KubernetesExecutor) sanitizeName(name string) string {
 	name = strings.ToLower(name)
 
@@ -104,13 +278,311 @@ func (k *KubernetesExecutor) parseQuantity(s string) resource.Quantity {
 	return q
 }
 
-// buildPodAnnotations merges global job pod annotations with olake-internal ones.
-// Global annotations are applied first so internal olake.io/* keys always win on conflict.
-func (k *KubernetesExecutor) buildPodAnnotations(internal map[string]string) map[string]string {
-	annotations := make(map[string]string, len(k.config.JobPodAnnotations)+len(internal))
+// GetClassificationForJob returns the data classification of the given jobID's profile, if any
+func (k *KubernetesExecutor) GetClassificationForJob(jobID int, operation types.Command) string {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return ""
+	}
+	return profile.Classification
+}
+
+// withClassification stamps the pod with the job's data classification as both a label
+// (for selectors and policy engines) and an annotation (for scanners reading metadata)
+func withClassification(pod *corev1.Pod, classification string) {
+	if classification == "" {
+		return
+	}
+	pod.Labels[classificationKey] = classification
+	pod.Annotations[classificationKey] = classification
+}
+
+// GetSecurityContextForJob returns the pod security context from POD_SECURITY_CONTEXT with the
+// fsGroup settings of the given jobID's profile applied on a copy
+func (k *KubernetesExecutor) GetSecurityContextForJob(jobID int, operation types.Command) *corev1.PodSecurityContext {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists || (profile.FSGroup == nil && profile.FSGroupChangePolicy == nil) {
+		return k.config.SecurityContext
+	}
+
+	securityContext := &corev1.PodSecurityContext{}
+	if k.config.SecurityContext != nil {
+		securityContext = k.config.SecurityContext.DeepCopy()
+	}
+	if profile.FSGroup != nil {
+		securityContext.FSGroup = ptr.To(*profile.FSGroup)
+	}
+	if profile.FSGroupChangePolicy != nil {
+		securityContext.FSGroupChangePolicy = ptr.To(*profile.FSGroupChangePolicy)
+	}
+	return securityContext
+}
+
+// GetPodMetadataForJob returns the pod labels and annotations of the given jobID's profile, if any
+func (k *KubernetesExecutor) GetPodMetadataForJob(jobID int, operation types.Command) (map[string]string, map[string]string) {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return nil, nil
+	}
+	return profile.PodLabels, profile.PodAnnotations
+}
+
+// IsHighAvailabilityJob reports whether the given jobID's profile asks for spread placement
+func (k *KubernetesExecutor) IsHighAvailabilityJob(jobID int, operation types.Command) bool {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	return exists && profile.HighAvailability
+}
+
+// GetPriorityClassForJob returns the PriorityClass of the job's connector pods: the job profile's
+// for sync and clear-destination, then the operation's from OPERATION_PRIORITY_CLASSES, then the
+// default profile's. Empty leaves the cluster's default priority.
+func (k *KubernetesExecutor) GetPriorityClassForJob(jobID int, operation types.Command) string {
+	if slices.Contains(constants.AsyncCommands, operation) {
+		if profile, exists := k.configWatcher.GetJobProfile(jobID); exists && profile.PriorityClassName != "" {
+			return profile.PriorityClassName
+		}
+	}
+	if class, found := k.config.PriorityClasses[operation]; found {
+		return class
+	}
+	if profile, exists := k.configWatcher.GetJobProfile(0); exists {
+		return profile.PriorityClassName
+	}
+	return ""
+}
+
+// parsePriorityClasses parses OPERATION_PRIORITY_CLASSES, dropping unknown operations and
+// names that can't be a PriorityClass
+func parsePriorityClasses(raw string) map[types.Command]string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	var classes map[types.Command]string
+	if err := json.Unmarshal([]byte(raw), &classes); err != nil {
+		logger.Errorf("failed to unmarshal %s: %s. ignoring.", constants.EnvOperationPriorityClasses, err)
+		return nil
+	}
+	for operation, class := range classes {
+		if !slices.Contains(priorityClassOperations, operation) {
+			logger.Warnf("%s: unknown operation '%s'. ignoring", constants.EnvOperationPriorityClasses, operation)
+			delete(classes, operation)
+		} else if errs := validation.IsDNS1123Subdomain(class); len(errs) > 0 {
+			logger.Warnf("%s: invalid priority class '%s' for operation '%s': %s. ignoring", constants.EnvOperationPriorityClasses, class, operation, errs)
+			delete(classes, operation)
+		}
+	}
+	return classes
+}
+
+// priorityClassOperations are the operations OPERATION_PRIORITY_CLASSES can set a class for
+var priorityClassOperations = []types.Command{types.Spec, types.Check, types.Discover, types.Sync, types.ClearDestination}
+
+// withHighAvailability spreads the pods of high-availability jobs apart: a required pod
+// anti-affinity keeps two of them off the same node, and a best-effort topology spread
+// balances them across zones (best-effort so clusters without zone labels still schedule).
+// Any affinity from the profile is kept and extended on a copy.
+func withHighAvailability(pod *corev1.Pod, enabled bool) {
+	if !enabled {
+		return
+	}
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{highAvailabilityKey: "true"}}
+	pod.Labels[highAvailabilityKey] = "true"
+
+	affinity := &corev1.Affinity{}
+	if pod.Spec.Affinity != nil {
+		affinity = pod.Spec.Affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelHostname},
+	)
+	pod.Spec.Affinity = affinity
+
+	pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     selector,
+	})
+}
+
+// GetLogRoutingForJob returns the log routing annotations configured for the given jobID, if any
+func (k *KubernetesExecutor) GetLogRoutingForJob(jobID int, operation types.Command) map[string]string {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return nil
+	}
+	return profile.LogRouting
+}
+
+// withLogRouting adds the job's log routing annotations to the pod. They take precedence
+// over global job pod annotations; reserved olake.io keys are rejected when profiles load.
+func withLogRouting(pod *corev1.Pod, annotations map[string]string) {
+	maps.Copy(pod.Annotations, annotations)
+}
+
+// isOlakeAnnotation reports whether key is in an olake.io domain reserved for internal metadata
+func isOlakeAnnotation(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	return found && (prefix == "olake.io" || strings.HasSuffix(prefix, ".olake.io"))
+}
+
+// withEnv appends the given variables to the container env in key order
+func withEnv(container *corev1.Container, vars map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: vars[name]})
+	}
+}
+
+// GetExtraArgsForJob returns the extra connector args configured for the given jobID
+func (k *KubernetesExecutor) GetExtraArgsForJob(jobID int, operation types.Command) []string {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return nil
+	}
+	return profile.ExtraArgs
+}
+
+// GetWrapperForJob returns the connector entrypoint wrapper configured for the given jobID, if any
+func (k *KubernetesExecutor) GetWrapperForJob(jobID int, operation types.Command) *ConnectorWrapper {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists || profile.Wrapper == nil || len(profile.Wrapper.Command) == 0 {
+		return nil
+	}
+	return profile.Wrapper
+}
+
+// withWrapper overrides the connector entrypoint with the wrapper command and mounts the
+// profiling output directory from the job workdir
+func withWrapper(container *corev1.Container, wrapper *ConnectorWrapper, subDir string) {
+	if wrapper == nil {
+		return
+	}
+	container.Command = slices.Clone(wrapper.Command)
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "job-storage",
+		MountPath: profilingMountPath,
+		SubPath:   subDir + "/profiling",
+	})
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  "OLAKE_PROFILING_DIR",
+		Value: profilingMountPath,
+	})
+}
+
+// meshSidecarExclusionAnnotations keep Istio and Linkerd from injecting a sidecar, which would
+// keep running after the connector exits so the pod never completes
+var meshSidecarExclusionAnnotations = map[string]string{
+	"sidecar.istio.io/inject": "false",
+	"linkerd.io/inject":       "disabled",
+}
+
+// withMeshSidecarExclusion adds the mesh exclusion annotations beneath the configured job pod
+// annotations, so either can still be overridden there or in a job profile
+func withMeshSidecarExclusion(annotations map[string]string, enabled bool) map[string]string {
+	if !enabled {
+		return annotations
+	}
+	merged := maps.Clone(meshSidecarExclusionAnnotations)
+	maps.Copy(merged, annotations)
+	return merged
+}
+
+// buildAmbientMeshLabels returns the labels enrolling activity pods in an Istio ambient mesh,
+// optionally routing their egress through a waypoint. Sidecar injection is disabled for these
+// pods since a sidecar would keep a run-to-completion pod from ever finishing.
+func buildAmbientMeshLabels(enabled bool, waypoint, waypointNamespace string) map[string]string {
+	if !enabled {
+		return nil
+	}
+	labels := map[string]string{
+		"istio.io/dataplane-mode": "ambient",
+		"sidecar.istio.io/inject": "false",
+	}
+	if waypoint != "" {
+		labels["istio.io/use-waypoint"] = waypoint
+		if waypointNamespace != "" {
+			labels["istio.io/use-waypoint-namespace"] = waypointNamespace
+		}
+	}
+	return labels
+}
+
+// buildPodLabels merges the configured job pod labels, then the job profile's labels, with
+// olake-internal ones, internal keys winning on conflict
+func (k *KubernetesExecutor) buildPodLabels(profileLabels, internal map[string]string) map[string]string {
+	labels := make(map[string]string, len(k.config.JobPodLabels)+len(profileLabels)+len(internal))
+	maps.Copy(labels, k.config.JobPodLabels)
+	maps.Copy(labels, profileLabels)
+	maps.Copy(labels, internal)
+	return labels
+}
+
+// parseMemoMapping reads a JSON map of workflow memo key to pod label/annotation key from the given env
+func parseMemoMapping(key string) map[string]string {
+	raw := viper.GetString(key)
+	if raw == "" {
+		return nil
+	}
+
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		logger.Errorf("failed to unmarshal %s: %s. ignoring.", key, err)
+		return nil
+	}
+	for memoKey, podKey := range mapping {
+		if errs := validation.IsQualifiedName(podKey); len(errs) > 0 {
+			logger.Warnf("%s: invalid key '%s' for memo field '%s': %s. ignoring", key, podKey, memoKey, errs)
+			delete(mapping, memoKey)
+		}
+	}
+	return mapping
+}
+
+// withMemoMetadata copies the configured workflow memo fields onto the pod for end-to-end
+// correlation. Memo values that are not valid label values are only skipped for labels;
+// existing labels and annotations are never overwritten.
+func (k *KubernetesExecutor) withMemoMetadata(pod *corev1.Pod, memo map[string]string) {
+	for memoKey, labelKey := range k.config.MemoLabels {
+		value, exists := memo[memoKey]
+		if !exists {
+			continue
+		}
+		if _, taken := pod.Labels[labelKey]; taken {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			logger.Debugf("skipping memo field %s for label %s: %s", memoKey, labelKey, errs)
+			continue
+		}
+		pod.Labels[labelKey] = value
+	}
+	for memoKey, annotationKey := range k.config.MemoAnnotations {
+		value, exists := memo[memoKey]
+		if !exists {
+			continue
+		}
+		if _, taken := pod.Annotations[annotationKey]; !taken {
+			pod.Annotations[annotationKey] = value
+		}
+	}
+}
+
+// buildPodAnnotations merges global job pod annotations, then the job profile's annotations,
+// with olake-internal ones. Internal olake.io/* keys are applied last so they always win on conflict.
+func (k *KubernetesExecutor) buildPodAnnotations(profileAnnotations, internal map[string]string) map[string]string {
+	annotations := make(map[string]string, len(k.config.JobPodAnnotations)+len(profileAnnotations)+len(internal))
 	for key, val := range k.config.JobPodAnnotations {
 		annotations[key] = val
 	}
+	for key, val := range profileAnnotations {
+		annotations[key] = val
+	}
 	for key, val := range internal {
 		annotations[key] = val
 	}
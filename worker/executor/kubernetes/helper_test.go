@@ -0,0 +1,468 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func memoryLimit(quantity string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(quantity)},
+	}
+}
+
+func TestBuildJVMHeapEnv(t *testing.T) {
+	tests := []struct {
+		name            string
+		jvmTypes        string
+		headroom        int
+		connectorType   string
+		resources       corev1.ResourceRequirements
+		javaToolOptions string
+		want            string
+	}{
+		{
+			name:          "default headroom",
+			jvmTypes:      "mssql,db2",
+			headroom:      constants.DefaultJVMHeapHeadroomPercent,
+			connectorType: "mssql",
+			resources:     memoryLimit("8Gi"),
+			want:          "-Xmx6144m",
+		},
+		{
+			name:          "configured headroom and case insensitive type",
+			jvmTypes:      " MSSQL , db2",
+			headroom:      50,
+			connectorType: "db2",
+			resources:     memoryLimit("2Gi"),
+			want:          "-Xmx1024m",
+		},
+		{
+			name:          "invalid headroom uses the default",
+			jvmTypes:      "mssql",
+			headroom:      100,
+			connectorType: "mssql",
+			resources:     memoryLimit("4Gi"),
+			want:          "-Xmx3072m",
+		},
+		{
+			name:            "existing options are kept after the heap",
+			jvmTypes:        "mssql",
+			headroom:        constants.DefaultJVMHeapHeadroomPercent,
+			connectorType:   "mssql",
+			resources:       memoryLimit("8Gi"),
+			javaToolOptions: "-Dfile.encoding=UTF-8 -Xmx4g",
+			want:            "-Xmx6144m -Dfile.encoding=UTF-8 -Xmx4g",
+		},
+		{
+			name:          "no memory limit",
+			jvmTypes:      "mssql",
+			headroom:      constants.DefaultJVMHeapHeadroomPercent,
+			connectorType: "mssql",
+		},
+		{
+			name:          "connector not listed",
+			jvmTypes:      "mssql",
+			headroom:      constants.DefaultJVMHeapHeadroomPercent,
+			connectorType: "postgres",
+			resources:     memoryLimit("8Gi"),
+		},
+		{
+			name:          "no jvm types configured",
+			headroom:      constants.DefaultJVMHeapHeadroomPercent,
+			connectorType: "mssql",
+			resources:     memoryLimit("8Gi"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvConnectorJVMTypes, tt.jvmTypes)
+			viper.Set(constants.EnvConnectorJVMHeapHeadroom, tt.headroom)
+			t.Cleanup(func() {
+				viper.Set(constants.EnvConnectorJVMTypes, "")
+				viper.Set(constants.EnvConnectorJVMHeapHeadroom, constants.DefaultJVMHeapHeadroomPercent)
+			})
+
+			env := buildJVMHeapEnv(tt.connectorType, tt.resources, tt.javaToolOptions)
+			if tt.want == "" {
+				require.Empty(t, env)
+				return
+			}
+			require.Equal(t, []corev1.EnvVar{{Name: constants.EnvJavaToolOptions, Value: tt.want}}, env)
+		})
+	}
+}
+
+func TestWithJobEnvAppendsJavaToolOptions(t *testing.T) {
+	container := &corev1.Container{Env: []corev1.EnvVar{
+		{Name: constants.EnvJavaToolOptions, Value: "-Xmx6144m"},
+		{Name: "LOG_LEVEL", Value: "info"},
+	}}
+
+	withJobEnv(container, map[string]string{
+		constants.EnvJavaToolOptions: "-XX:+UseG1GC",
+		"LOG_LEVEL":                  "debug",
+		"BATCH_SIZE":                 "100",
+	}, nil)
+
+	require.Equal(t, []corev1.EnvVar{
+		{Name: constants.EnvJavaToolOptions, Value: "-Xmx6144m -XX:+UseG1GC"},
+		{Name: "LOG_LEVEL", Value: "debug"},
+		{Name: "BATCH_SIZE", Value: "100"},
+	}, container.Env)
+}
+
+func TestCreatePodSpecGPU(t *testing.T) {
+	gpuToleration := corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	spot := corev1.Toleration{Key: "spot", Operator: corev1.TolerationOpExists}
+
+	tests := []struct {
+		name            string
+		profile         JobSchedulingConfig
+		wantLimits      map[corev1.ResourceName]string
+		wantTolerations []corev1.Toleration
+	}{
+		{
+			name:            "gpu request",
+			profile:         JobSchedulingConfig{GPU: 2, Tolerations: []corev1.Toleration{spot}},
+			wantLimits:      map[corev1.ResourceName]string{gpuResourceName: "2"},
+			wantTolerations: []corev1.Toleration{spot, gpuToleration},
+		},
+		{
+			name: "gpu alongside profile limits",
+			profile: JobSchedulingConfig{GPU: 1, Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			}},
+			wantLimits:      map[corev1.ResourceName]string{corev1.ResourceMemory: "4Gi", gpuResourceName: "1"},
+			wantTolerations: []corev1.Toleration{gpuToleration},
+		},
+		{
+			name:            "profile already tolerates gpu nodes",
+			profile:         JobSchedulingConfig{GPU: 1, Tolerations: []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpEqual, Value: "present"}}},
+			wantLimits:      map[corev1.ResourceName]string{gpuResourceName: "1"},
+			wantTolerations: []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpEqual, Value: "present"}},
+		},
+		{
+			name:            "no gpu",
+			profile:         JobSchedulingConfig{Tolerations: []corev1.Toleration{spot}},
+			wantTolerations: []corev1.Toleration{spot},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: tt.profile})
+
+			pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			var limits map[corev1.ResourceName]string
+			for name, quantity := range pod.Spec.Containers[0].Resources.Limits {
+				if limits == nil {
+					limits = map[corev1.ResourceName]string{}
+				}
+				limits[name] = quantity.String()
+			}
+			require.Equal(t, tt.wantLimits, limits)
+			require.Equal(t, tt.wantTolerations, pod.Spec.Tolerations)
+		})
+	}
+}
+
+func TestCreatePodSpecClassification(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{0: {Classification: "internal"}, 7: {Classification: "pii"}})
+
+	tests := []struct {
+		name string
+		req  *types.ExecutionRequest
+		want string
+	}{
+		{name: "job profile", req: &types.ExecutionRequest{JobID: 7, Command: types.Sync}, want: "pii"},
+		{name: "default profile", req: &types.ExecutionRequest{JobID: 8, Command: types.Sync}, want: "internal"},
+		{name: "discover uses the default profile", req: &types.ExecutionRequest{JobID: 7, Command: types.Discover}, want: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.WorkflowID = "sync-7-abc"
+			pod := k.CreatePodSpec(tt.req, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			require.Equal(t, tt.want, pod.Labels[classificationKey])
+			require.Equal(t, tt.want, pod.Annotations[classificationKey])
+		})
+	}
+
+	// unclassified jobs get neither key
+	pod := profileExecutor(KubernetesConfig{}, nil).CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+	require.NotContains(t, pod.Labels, classificationKey)
+	require.NotContains(t, pod.Annotations, classificationKey)
+}
+
+func TestCreatePodSpecHighAvailability(t *testing.T) {
+	profileAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"etl"}}},
+		}}},
+	}}
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{
+		7: {HighAvailability: true, Affinity: profileAffinity},
+		8: {HighAvailability: true},
+		9: {Affinity: profileAffinity},
+	})
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{highAvailabilityKey: "true"}}
+
+	for _, jobID := range []int{7, 8} {
+		pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: jobID, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+		require.Equal(t, "true", pod.Labels[highAvailabilityKey])
+		require.Equal(t, []corev1.PodAffinityTerm{{LabelSelector: selector, TopologyKey: corev1.LabelHostname}},
+			pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		require.Equal(t, []corev1.TopologySpreadConstraint{{
+			MaxSkew: 1, TopologyKey: corev1.LabelTopologyZone, WhenUnsatisfiable: corev1.ScheduleAnyway, LabelSelector: selector,
+		}}, pod.Spec.TopologySpreadConstraints)
+	}
+
+	// the profile's affinity is extended on a copy
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+	require.Equal(t, profileAffinity.NodeAffinity, pod.Spec.Affinity.NodeAffinity)
+	require.Nil(t, profileAffinity.PodAntiAffinity)
+
+	// other jobs and operations are placed as before
+	for _, req := range []*types.ExecutionRequest{
+		{JobID: 9, WorkflowID: "sync-9-abc", Command: types.Sync},
+		{JobID: 7, WorkflowID: "discover-7-abc", Command: types.Discover},
+	} {
+		pod := k.CreatePodSpec(req, "/data/"+req.WorkflowID, "olakego/source-postgres:latest")
+		require.NotContains(t, pod.Labels, highAvailabilityKey)
+		require.Empty(t, pod.Spec.TopologySpreadConstraints)
+		if pod.Spec.Affinity != nil {
+			require.Nil(t, pod.Spec.Affinity.PodAntiAffinity)
+		}
+	}
+}
+
+func TestCreatePodSpecExtraArgs(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: {ExtraArgs: []string{"--threads", "4"}}})
+	args := []string{"sync", "--config", "/mnt/config/source.json"}
+
+	tests := []struct {
+		name string
+		req  *types.ExecutionRequest
+		want []string
+	}{
+		{name: "profile", req: &types.ExecutionRequest{JobID: 7, Command: types.Sync, Args: args}, want: append(args, "--threads", "4")},
+		{name: "profile then request", req: &types.ExecutionRequest{JobID: 7, Command: types.Sync, Args: args, ExtraArgs: []string{"--threads", "8"}}, want: append(args, "--threads", "4", "--threads", "8")},
+		{name: "request only", req: &types.ExecutionRequest{JobID: 8, Command: types.Sync, Args: args, ExtraArgs: []string{"--threads", "8"}}, want: append(args, "--threads", "8")},
+		{name: "reserved request flag", req: &types.ExecutionRequest{JobID: 8, Command: types.Sync, Args: args, ExtraArgs: []string{"--streams", "/tmp/streams.json"}}, want: args},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.WorkflowID = "sync-7-abc"
+			pod := k.CreatePodSpec(tt.req, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			require.Equal(t, tt.want, pod.Spec.Containers[0].Args)
+		})
+	}
+}
+
+func TestCreatePodSpecSecurityContext(t *testing.T) {
+	base := &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true), FSGroup: ptr.To[int64](2000)}
+	k := profileExecutor(KubernetesConfig{SecurityContext: base}, map[int]JobSchedulingConfig{
+		7: {FSGroup: ptr.To[int64](1000), FSGroupChangePolicy: ptr.To(corev1.FSGroupChangeOnRootMismatch)},
+		8: {Classification: "internal"},
+	})
+
+	tests := []struct {
+		name string
+		req  *types.ExecutionRequest
+		want *corev1.PodSecurityContext
+	}{
+		{name: "job fsGroup", req: &types.ExecutionRequest{JobID: 7, Command: types.Sync}, want: &corev1.PodSecurityContext{
+			RunAsNonRoot: ptr.To(true), FSGroup: ptr.To[int64](1000), FSGroupChangePolicy: ptr.To(corev1.FSGroupChangeOnRootMismatch),
+		}},
+		{name: "profile without fsGroup", req: &types.ExecutionRequest{JobID: 8, Command: types.Sync}, want: base},
+		{name: "no profile", req: &types.ExecutionRequest{JobID: 9, Command: types.Sync}, want: base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.WorkflowID = "sync-7-abc"
+			pod := k.CreatePodSpec(tt.req, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			require.Equal(t, tt.want, pod.Spec.SecurityContext)
+		})
+	}
+	// the worker-wide context is left as it was
+	require.Equal(t, ptr.To[int64](2000), base.FSGroup)
+	require.Nil(t, base.FSGroupChangePolicy)
+
+	// a profile's fsGroup applies without POD_SECURITY_CONTEXT too
+	pod := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: {FSGroup: ptr.To[int64](1000)}}).
+		CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+	require.Equal(t, &corev1.PodSecurityContext{FSGroup: ptr.To[int64](1000)}, pod.Spec.SecurityContext)
+}
+
+func TestCreatePodSpecWrapper(t *testing.T) {
+	wrapper := &ConnectorWrapper{Command: []string{"/profiler/run", "--"}}
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: {Wrapper: wrapper}})
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, Args: []string{"sync"}}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	connector := pod.Spec.Containers[0]
+	require.Equal(t, wrapper.Command, connector.Command)
+	require.Equal(t, []string{"sync"}, connector.Args)
+	require.Contains(t, connector.VolumeMounts, corev1.VolumeMount{Name: "job-storage", MountPath: profilingMountPath, SubPath: "sync-7-abc/profiling"})
+	require.Contains(t, connector.Env, corev1.EnvVar{Name: "OLAKE_PROFILING_DIR", Value: profilingMountPath})
+
+	// other jobs keep the image entrypoint
+	pod = k.CreatePodSpec(&types.ExecutionRequest{JobID: 8, WorkflowID: "sync-8-abc", Command: types.Sync, Args: []string{"sync"}}, "/data/sync-8-abc", "olakego/source-postgres:latest")
+	require.Empty(t, pod.Spec.Containers[0].Command)
+	require.Len(t, pod.Spec.Containers[0].VolumeMounts, 1)
+}
+
+func TestWithMeshSidecarExclusion(t *testing.T) {
+	configured := map[string]string{"sidecar.istio.io/inject": "true", "team": "data"}
+
+	// configured annotations override the defaults
+	require.Equal(t, map[string]string{"sidecar.istio.io/inject": "true", "linkerd.io/inject": "disabled", "team": "data"}, withMeshSidecarExclusion(configured, true))
+	require.Equal(t, meshSidecarExclusionAnnotations, withMeshSidecarExclusion(nil, true))
+	require.Equal(t, configured, withMeshSidecarExclusion(configured, false))
+}
+
+func TestBuildAmbientMeshLabels(t *testing.T) {
+	tests := []struct {
+		name              string
+		enabled           bool
+		waypoint          string
+		waypointNamespace string
+		want              map[string]string
+	}{
+		{name: "disabled", waypoint: "egress"},
+		{name: "enabled", enabled: true, want: map[string]string{"istio.io/dataplane-mode": "ambient", "sidecar.istio.io/inject": "false"}},
+		{
+			name: "waypoint", enabled: true, waypoint: "egress", waypointNamespace: "mesh",
+			want: map[string]string{"istio.io/dataplane-mode": "ambient", "sidecar.istio.io/inject": "false", "istio.io/use-waypoint": "egress", "istio.io/use-waypoint-namespace": "mesh"},
+		},
+		{
+			name: "waypoint namespace without waypoint", enabled: true, waypointNamespace: "mesh",
+			want: map[string]string{"istio.io/dataplane-mode": "ambient", "sidecar.istio.io/inject": "false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, buildAmbientMeshLabels(tt.enabled, tt.waypoint, tt.waypointNamespace))
+		})
+	}
+}
+
+func TestCreatePodSpecAmbientMesh(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{JobPodLabels: buildAmbientMeshLabels(true, "egress", "")}, nil)
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	require.Equal(t, "ambient", pod.Labels["istio.io/dataplane-mode"])
+	require.Equal(t, "egress", pod.Labels["istio.io/use-waypoint"])
+	require.Equal(t, "7", pod.Labels["olake.io/job-id"])
+}
+
+func TestParseMemoMapping(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvJobMemoLabels, nil) })
+
+	viper.Set(constants.EnvJobMemoLabels, `{"team":"olake.io/team","owner":"not a key!"}`)
+	require.Equal(t, map[string]string{"team": "olake.io/team"}, parseMemoMapping(constants.EnvJobMemoLabels))
+
+	viper.Set(constants.EnvJobMemoLabels, `{"team":`)
+	require.Nil(t, parseMemoMapping(constants.EnvJobMemoLabels))
+}
+
+func TestParsePriorityClasses(t *testing.T) {
+	require.Nil(t, parsePriorityClasses(""))
+	require.Nil(t, parsePriorityClasses(`{"check":`))
+	// unknown operations and invalid class names are dropped
+	require.Equal(t, map[types.Command]string{types.Check: "olake-interactive", types.Sync: "olake-batch"},
+		parsePriorityClasses(`{"check":"olake-interactive","sync":"olake-batch","backfill":"olake-batch","discover":"Not_A_Class"}`))
+}
+
+func TestWithNodeArch(t *testing.T) {
+	profileSelector := map[string]string{"olake.io/pool": "syncs"}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: profileSelector}}
+
+	withNodeArch(pod, "arm64")
+	require.Equal(t, map[string]string{"olake.io/pool": "syncs", corev1.LabelArchStable: "arm64"}, pod.Spec.NodeSelector)
+	// the job profile's own selector is left as it is
+	require.Equal(t, map[string]string{"olake.io/pool": "syncs"}, profileSelector)
+
+	pod = &corev1.Pod{}
+	withNodeArch(pod, "amd64")
+	require.Equal(t, map[string]string{corev1.LabelArchStable: "amd64"}, pod.Spec.NodeSelector)
+}
+
+func TestParseOperationNodeSelector(t *testing.T) {
+	require.Nil(t, parseOperationNodeSelector(""))
+	require.Equal(t, map[string]string{"olake.io/pool": "operations"}, parseOperationNodeSelector(`{" olake.io/pool ":"operations"}`))
+	// invalid values leave operation pods on the default profile
+	require.Nil(t, parseOperationNodeSelector(`{"olake.io/pool":`))
+	require.Nil(t, parseOperationNodeSelector(`{"not a key!":"operations"}`))
+}
+
+func TestGetNodeSelectorForJobOperations(t *testing.T) {
+	profiles := map[int]JobSchedulingConfig{
+		0: {NodeSelector: map[string]string{"olake.io/pool": "default"}},
+		7: {NodeSelector: map[string]string{"olake.io/pool": "syncs"}},
+	}
+	operations := map[string]string{"olake.io/pool": "operations"}
+
+	k := profileExecutor(KubernetesConfig{OperationSelector: operations}, profiles)
+	require.Equal(t, operations, k.GetNodeSelectorForJob(7, types.Discover))
+	require.Equal(t, operations, k.GetNodeSelectorForJob(0, types.Check))
+	// syncs still resolve their job profile
+	require.Equal(t, map[string]string{"olake.io/pool": "syncs"}, k.GetNodeSelectorForJob(7, types.Sync))
+	require.Equal(t, map[string]string{"olake.io/pool": "default"}, k.GetNodeSelectorForJob(9, types.Sync))
+
+	// without the selector operations fall back to the default profile
+	k = profileExecutor(KubernetesConfig{}, profiles)
+	require.Equal(t, map[string]string{"olake.io/pool": "default"}, k.GetNodeSelectorForJob(7, types.Discover))
+}
+
+func TestCreatePodSpecMemoMetadata(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{
+		MemoLabels:      map[string]string{"team": "example.com/team", "ticket": "example.com/ticket", "job": "olake.io/job-id"},
+		MemoAnnotations: map[string]string{"ticket": "example.com/ticket", "run": "olake.io/workflow-id"},
+	}, nil)
+	memo := map[string]string{"team": "growth", "ticket": "OPS 12: nightly backfill", "job": "99", "run": "other"}
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, Memo: memo}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	require.Equal(t, "growth", pod.Labels["example.com/team"])
+	// not a valid label value, but fine as an annotation
+	require.NotContains(t, pod.Labels, "example.com/ticket")
+	require.Equal(t, "OPS 12: nightly backfill", pod.Annotations["example.com/ticket"])
+	// memo fields never replace the pod's own metadata
+	require.Equal(t, "7", pod.Labels["olake.io/job-id"])
+	require.Equal(t, "sync-7-abc", pod.Annotations["olake.io/workflow-id"])
+}
+
+func TestCreatePodSpecLogRouting(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{JobPodAnnotations: map[string]string{"vector.dev/index": "olake", "team": "data"}}, map[int]JobSchedulingConfig{
+		7: {LogRouting: map[string]string{"vector.dev/index": "olake-pii"}},
+	})
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+	// the job's routing wins over the global annotation
+	require.Equal(t, "olake-pii", pod.Annotations["vector.dev/index"])
+	require.Equal(t, "data", pod.Annotations["team"])
+
+	pod = k.CreatePodSpec(&types.ExecutionRequest{JobID: 8, WorkflowID: "sync-8-abc", Command: types.Sync}, "/data/sync-8-abc", "olakego/source-postgres:latest")
+	require.Equal(t, "olake", pod.Annotations["vector.dev/index"])
+}
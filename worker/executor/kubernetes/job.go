@@ -0,0 +1,272 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
+	"github.com/spf13/viper"
+)
+
+// SyncJobConfig runs sync and clear-destination pods as batch/v1 Jobs, so a pod lost with its
+// node is recreated by the Job controller instead of waiting for the activity to time out
+type SyncJobConfig struct {
+	Enabled      bool
+	BackoffLimit int32 // retries of pods failing for reasons other than a disruption or connector exit
+}
+
+// runsAsJob reports whether the operation's pod is created through a Job
+func (k *KubernetesExecutor) runsAsJob(operation types.Command) bool {
+	return k.config.SyncJob.Enabled && slices.Contains(constants.AsyncCommands, operation)
+}
+
+// buildJob wraps the connector pod in a Job of the same name. Pods lost to a disruption (node
+// failure, drain, preemption) are replaced without counting against the backoff limit; a
+// connector that exits non-zero fails the Job right away, as it would fail a bare pod, and
+// retrying it is left to Temporal. A replacement only starts once the lost pod has terminated,
+// so two pods never write the same state.
+func buildJob(pod *corev1.Pod, backoffLimit int32, timeout time.Duration) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Namespace:   pod.Namespace,
+			Labels:      pod.Labels,
+			Annotations: pod.Annotations,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:         ptr.To(backoffLimit),
+			PodReplacementPolicy: ptr.To(batchv1.Failed),
+			PodFailurePolicy: &batchv1.PodFailurePolicy{
+				Rules: []batchv1.PodFailurePolicyRule{
+					{
+						Action:          batchv1.PodFailurePolicyActionIgnore,
+						OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue}},
+					},
+					{
+						Action: batchv1.PodFailurePolicyActionFailJob,
+						OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+							Operator: batchv1.PodFailurePolicyOnExitCodesOpNotIn,
+							Values:   []int32{0},
+						},
+					},
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      pod.Labels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+	if timeout > 0 {
+		job.Spec.ActiveDeadlineSeconds = ptr.To(int64(timeout.Seconds()))
+	}
+	return job
+}
+
+func (k *KubernetesExecutor) createJob(ctx context.Context, job *batchv1.Job) error {
+	log := logger.Log(ctx)
+	_, err := k.client.BatchV1().Jobs(k.namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			log.Error("failed to create job", "jobName", job.Name, "error", err)
+			return fmt.Errorf("failed to create job: %s", err)
+		}
+		log.Info("job already exists, resuming polling", "jobName", job.Name)
+		return nil
+	}
+
+	log.Info("successfully created job", "jobName", job.Name)
+	return nil
+}
+
+// jobPod returns the Job's succeeded pod, or else its most recently created one; nil when the
+// Job has no pod yet
+func (k *KubernetesExecutor) jobPod(ctx context.Context, jobName string) (*corev1.Pod, error) {
+	pods, err := k.client.CoreV1().Pods(k.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", batchv1.JobNameLabel, jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of job %s: %s", jobName, err)
+	}
+
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded {
+			return pod, nil
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+	}
+	return latest, nil
+}
+
+// jobPodName returns the name of the Job's current pod, or the Job name when it has none, so
+// callers addressing the pod get a not-found error rather than a listing failure
+func (k *KubernetesExecutor) jobPodName(ctx context.Context, jobName string) string {
+	if pod, err := k.jobPod(ctx, jobName); err == nil && pod != nil {
+		return pod.Name
+	}
+	return jobName
+}
+
+// waitForJobCompletion polls the Job until it succeeds or fails and returns the pod whose logs
+// hold the connector output. A failed Job is reported like a failed bare pod, from its last pod.
+func (k *KubernetesExecutor) waitForJobCompletion(ctx context.Context, jobName string, timeout time.Duration, heartbeatFunc func(context.Context, ...interface{})) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "kubernetes.waitForJobCompletion", attribute.String("olake.job_name", jobName))
+	defer func() { tracing.End(span, err) }()
+
+	log := logger.Log(ctx)
+	log.Debug("waiting for job to complete", "jobName", jobName, "timeout", timeout)
+	deadline := time.Now().Add(timeout)
+	var pullFailures []time.Time
+
+	for time.Now().Before(deadline) {
+		if heartbeatFunc != nil {
+			heartbeatFunc(ctx, fmt.Sprintf("Waiting for job %s (status check)", jobName))
+		}
+
+		job, err := k.client.BatchV1().Jobs(k.namespace).Get(ctx, jobName, metav1.GetOptions{})
+		if err != nil {
+			log.Error("failed to get job status", "jobName", jobName, "error", err)
+			return "", fmt.Errorf("failed to get job status: %s", err)
+		}
+		pod, err := k.jobPod(ctx, jobName)
+		if err != nil {
+			log.Warn("failed to get job pod, continuing to poll", "jobName", jobName, "error", err)
+		}
+
+		if job.Status.Succeeded > 0 && pod != nil {
+			log.Info("job completed successfully", "jobName", jobName, "podName", pod.Name)
+			return pod.Name, nil
+		}
+
+		// an injected mesh sidecar keeps the pod, and so the Job, running after the connector has exited
+		if term := connectorExitedInRunningPod(pod); term != nil {
+			if term.ExitCode == 0 {
+				log.Info("connector completed, job pod kept running by another container", "jobName", jobName, "podName", pod.Name)
+				return pod.Name, nil
+			}
+			return pod.Name, connectorExitFailure(ctx, pod, term)
+		}
+
+		if failed := jobFailedCondition(job); failed != nil {
+			if pod != nil && pod.Status.Phase == corev1.PodFailed {
+				return pod.Name, podFailure(ctx, pod)
+			}
+			log.Error("job failed", "jobName", jobName, "reason", failed.Reason, "message", failed.Message)
+			return "", fmt.Errorf("%w: job %s failed (reason: %s, message: %s)", constants.ErrExecutionFailed, jobName, failed.Reason, failed.Message)
+		}
+
+		// an image that can't be pulled never recovers, however often the pod is replaced
+		if image, reason, failing := podImagePullFailure(pod); failing {
+			pullFailures = recordPullFailure(pullFailures, time.Now())
+			maxFailures := viper.GetInt(constants.EnvImagePullMaxFailures)
+			log.Warn("job pod image not pulled, continuing to poll", "jobName", jobName, "image", image, "reason", reason, "failures", len(pullFailures))
+			if maxFailures > 0 && len(pullFailures) >= maxFailures {
+				log.Error("giving up on job pod image pull", "jobName", jobName, "image", image, "reason", reason, "failures", len(pullFailures))
+				if _, err := k.cleanupJob(context.WithoutCancel(ctx), jobName, 0); err != nil {
+					log.Warn("failed to delete job after image pull failures", "jobName", jobName, "error", err)
+				}
+				return "", fmt.Errorf("%w: image %s not found or not pullable (%s after %d attempts)", constants.ErrExecutionFailed, image, reason, len(pullFailures))
+			}
+		} else {
+			pullFailures = nil
+		}
+
+		select {
+		case <-time.After(utils.GetHeartbeatInterval(ctx)):
+		case <-ctx.Done():
+			log.Warn("context cancelled while waiting for job", "jobName", jobName)
+			return "", ctx.Err()
+		}
+	}
+
+	log.Error("job timed out", "jobName", jobName, "timeout", timeout)
+	return "", fmt.Errorf("job timed out after %v", timeout)
+}
+
+func podImagePullFailure(pod *corev1.Pod) (string, string, bool) {
+	if pod == nil {
+		return "", "", false
+	}
+	return imagePullFailure(pod)
+}
+
+// jobFailedCondition returns the Job's Failed condition once the Job has given up
+func jobFailedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		condition := &job.Status.Conditions[i]
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// cleanupJob deletes the Job and its pods, reporting whether it existed. With a grace period
+// it waits for the pods to go, as terminatePod does, so the connector can checkpoint on SIGTERM
+// and a local state sidecar can copy the state back; pods still around afterwards are
+// force-deleted.
+func (k *KubernetesExecutor) cleanupJob(ctx context.Context, jobName string, gracePeriod int64) (bool, error) {
+	log := logger.Log(ctx)
+	log.Info("deleting job", "jobName", jobName, "gracePeriodSeconds", gracePeriod)
+
+	propagation := utils.Ternary(gracePeriod > 0, metav1.DeletePropagationForeground, metav1.DeletePropagationBackground).(metav1.DeletionPropagation)
+	err := k.client.BatchV1().Jobs(k.namespace).Delete(ctx, jobName, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return true, fmt.Errorf("failed to delete job %s in namespace %s: %s", jobName, k.namespace, err)
+	}
+	if gracePeriod <= 0 {
+		return true, nil
+	}
+
+	deadline := time.Now().Add(time.Duration(gracePeriod)*time.Second + podTerminationBuffer)
+	for time.Now().Before(deadline) {
+		if _, err := k.client.BatchV1().Jobs(k.namespace).Get(ctx, jobName, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			log.Debug("job terminated within grace period", "jobName", jobName)
+			return true, nil
+		}
+
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+
+	log.Warn("job pods still present after grace period, force deleting", "jobName", jobName, "gracePeriodSeconds", gracePeriod)
+	pods, err := k.client.CoreV1().Pods(k.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", batchv1.JobNameLabel, jobName),
+	})
+	if err != nil {
+		return true, fmt.Errorf("failed to list pods of job %s: %s", jobName, err)
+	}
+	for _, pod := range pods.Items {
+		err := k.client.CoreV1().Pods(k.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return true, fmt.Errorf("failed to force delete pod %s of job %s in namespace %s: %s", pod.Name, jobName, k.namespace, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,144 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func TestBuildJob(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "sync-7-abc",
+			Namespace:   "olake",
+			Labels:      map[string]string{"olake.io/job-id": "7", "olake.io/operation-type": "sync"},
+			Annotations: map[string]string{"olake.io/workflow-id": "sync-7-abc"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers:    []corev1.Container{{Name: "connector", Image: "olakego/source-postgres:latest"}},
+		},
+	}
+
+	job := buildJob(pod, 2, 90*time.Minute)
+
+	require.Equal(t, pod.Name, job.Name)
+	require.Equal(t, pod.Namespace, job.Namespace)
+	require.Equal(t, pod.Labels, job.Labels)
+	require.Equal(t, pod.Annotations, job.Annotations)
+	require.Equal(t, pod.Labels, job.Spec.Template.Labels)
+	require.Equal(t, pod.Annotations, job.Spec.Template.Annotations)
+	require.Equal(t, pod.Spec, job.Spec.Template.Spec)
+
+	require.Equal(t, ptr.To(int32(2)), job.Spec.BackoffLimit)
+	require.Equal(t, ptr.To(batchv1.Failed), job.Spec.PodReplacementPolicy)
+	require.Equal(t, ptr.To(int64(5400)), job.Spec.ActiveDeadlineSeconds)
+
+	// disruptions are replaced without counting against the backoff limit, connector exits fail the Job
+	require.Equal(t, []batchv1.PodFailurePolicyRule{
+		{
+			Action:          batchv1.PodFailurePolicyActionIgnore,
+			OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue}},
+		},
+		{
+			Action: batchv1.PodFailurePolicyActionFailJob,
+			OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+				Operator: batchv1.PodFailurePolicyOnExitCodesOpNotIn,
+				Values:   []int32{0},
+			},
+		},
+	}, job.Spec.PodFailurePolicy.Rules)
+
+	require.Nil(t, buildJob(pod, 2, 0).Spec.ActiveDeadlineSeconds)
+}
+
+func TestRunsAsJob(t *testing.T) {
+	k := &KubernetesExecutor{config: &KubernetesConfig{SyncJob: SyncJobConfig{Enabled: true}}}
+	require.True(t, k.runsAsJob(types.Sync))
+	require.True(t, k.runsAsJob(types.ClearDestination))
+	require.False(t, k.runsAsJob(types.Check))
+
+	k.config.SyncJob.Enabled = false
+	require.False(t, k.runsAsJob(types.Sync))
+}
+
+func jobPodOf(jobName, name string, phase corev1.PodPhase, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "olake",
+			Labels:            map[string]string{batchv1.JobNameLabel: jobName},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestJobPod(t *testing.T) {
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		pods []*corev1.Pod
+		want string
+	}{
+		{
+			name: "no pod yet",
+		},
+		{
+			name: "replacement of a lost pod",
+			pods: []*corev1.Pod{
+				jobPodOf("sync-7", "sync-7-aaaaa", corev1.PodFailed, created),
+				jobPodOf("sync-7", "sync-7-bbbbb", corev1.PodRunning, created.Add(time.Minute)),
+			},
+			want: "sync-7-bbbbb",
+		},
+		{
+			name: "succeeded pod wins over a newer one",
+			pods: []*corev1.Pod{
+				jobPodOf("sync-7", "sync-7-ccccc", corev1.PodPending, created.Add(time.Minute)),
+				jobPodOf("sync-7", "sync-7-aaaaa", corev1.PodSucceeded, created),
+			},
+			want: "sync-7-aaaaa",
+		},
+		{
+			name: "pods of other jobs",
+			pods: []*corev1.Pod{
+				jobPodOf("sync-7", "sync-7-aaaaa", corev1.PodRunning, created),
+				jobPodOf("sync-8", "sync-8-aaaaa", corev1.PodRunning, created.Add(time.Minute)),
+			},
+			want: "sync-7-aaaaa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, pod := range tt.pods {
+				_, err := client.CoreV1().Pods("olake").Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			k := &KubernetesExecutor{client: client, namespace: "olake"}
+
+			pod, err := k.jobPod(context.Background(), "sync-7")
+			require.NoError(t, err)
+			if tt.want == "" {
+				require.Nil(t, pod)
+				require.Equal(t, "sync-7", k.jobPodName(context.Background(), "sync-7"))
+				return
+			}
+			require.NotNil(t, pod)
+			require.Equal(t, tt.want, pod.Name)
+			require.Equal(t, tt.want, k.jobPodName(context.Background(), "sync-7"))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+const (
+	leaseDuration = 15 * time.Second // how long followers wait before taking over an unrenewed lease
+	renewDeadline = 10 * time.Second // how long the leader keeps retrying a renewal before giving up
+	retryPeriod   = 2 * time.Second  // interval between acquire/renew attempts
+)
+
+// LeaderTask is a singleton background task. It is started when this worker acquires
+// leadership and must stop its work once ctx is cancelled, which happens when leadership is lost.
+type LeaderTask func(ctx context.Context)
+
+// RunLeaderTasks runs tasks on exactly one worker replica, elected through a Kubernetes Lease
+// named by LEADER_ELECTION_LEASE_NAME. It blocks until ctx is cancelled, campaigning again
+// whenever leadership is lost, and releases the lease on shutdown so a standby takes over quickly.
+func RunLeaderTasks(ctx context.Context, tasks ...LeaderTask) error {
+	clusterConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get in-cluster config: %s", err)
+	}
+	clientset, err := kubernetes.NewForConfig(clusterConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %s", err)
+	}
+
+	identity := viper.GetString(constants.EnvPodName)
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			return fmt.Errorf("failed to determine leader election identity: %s", err)
+		}
+	}
+
+	return runLeaderTasks(ctx, clientset, identity, tasks...)
+}
+
+// runLeaderTasks campaigns for the lease as identity until ctx is cancelled
+func runLeaderTasks(ctx context.Context, clientset kubernetes.Interface, identity string, tasks ...LeaderTask) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      viper.GetString(constants.EnvLeaderElectionLeaseName),
+			Namespace: viper.GetString(constants.EnvNamespace),
+		},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+
+	logger.Infof("leader election enabled using lease %s/%s as %s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name, identity)
+
+	// an elector is single use, so a fresh one campaigns after each lost term
+	for ctx.Err() == nil {
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   renewDeadline,
+			RetryPeriod:     retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            lock.LeaseMeta.Name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				// leaderCtx is cancelled by the elector as soon as leadership is lost
+				OnStartedLeading: func(leaderCtx context.Context) {
+					logger.Infof("acquired leadership, starting %d background task(s)", len(tasks))
+					for _, task := range tasks {
+						task(leaderCtx)
+					}
+				},
+				OnStoppedLeading: func() {
+					logger.Infof("no longer the leader, background tasks stopped")
+				},
+				OnNewLeader: func(leader string) {
+					if leader != identity {
+						logger.Infof("worker %s is the leader", leader)
+					}
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create leader elector: %s", err)
+		}
+		elector.Run(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+func setLeaseSettings(t *testing.T) {
+	viper.Set(constants.EnvLeaderElectionLeaseName, "olake-worker-leader")
+	viper.Set(constants.EnvNamespace, "olake")
+	t.Cleanup(func() {
+		viper.Set(constants.EnvLeaderElectionLeaseName, nil)
+		viper.Set(constants.EnvNamespace, nil)
+	})
+}
+
+func TestRunLeaderTasksAsLeader(t *testing.T) {
+	setLeaseSettings(t)
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var taskCtx context.Context
+	task := func(ctx context.Context) {
+		taskCtx = ctx
+		cancel()
+	}
+	require.NoError(t, runLeaderTasks(ctx, client, "worker-a", task))
+
+	// the task ran under leadership, which ends with the worker
+	require.NotNil(t, taskCtx)
+	require.Error(t, taskCtx.Err())
+
+	// the lease is released on shutdown so a standby takes over quickly
+	lease, err := client.CoordinationV1().Leases("olake").Get(context.Background(), "olake-worker-leader", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, ptr.Deref(lease.Spec.HolderIdentity, ""))
+}
+
+func TestRunLeaderTasksAsStandby(t *testing.T) {
+	setLeaseSettings(t)
+	now := metav1.NewMicroTime(time.Now())
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "olake-worker-leader", Namespace: "olake"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("worker-b"),
+			LeaseDurationSeconds: ptr.To(int32(leaseDuration / time.Second)),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ran := false
+	require.NoError(t, runLeaderTasks(ctx, client, "worker-a", func(context.Context) { ran = true }))
+
+	// another replica holds an unexpired lease
+	require.False(t, ran)
+	lease, err := client.CoordinationV1().Leases("olake").Get(context.Background(), "olake-worker-leader", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "worker-b", ptr.Deref(lease.Spec.HolderIdentity, ""))
+}
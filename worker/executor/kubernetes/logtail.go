@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+const (
+	logTailContainerName = "log-tail"
+	// defaultLogTailPath is where connectors write their log, relative to the job directory
+	defaultLogTailPath = "logs/*/olake.log"
+)
+
+// ConnectorLogTail streams a log file the connector writes on the job volume to the stdout of a
+// sidecar, for connectors that report progress to files rather than stdout
+type ConnectorLogTail struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path,omitempty"` // glob relative to the job directory, defaults to logs/*/olake.log
+}
+
+// GetLogTailForJob returns the log tail configured for the given jobID, if enabled
+func (k *KubernetesExecutor) GetLogTailForJob(jobID int, operation types.Command) *ConnectorLogTail {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists || profile.LogTail == nil || !profile.LogTail.Enabled {
+		return nil
+	}
+	return profile.LogTail
+}
+
+// withLogTail adds a native sidecar that waits for the connector's log file on the shared
+// /mnt/config volume and tails it to its own stdout. As a native sidecar it is stopped by the
+// kubelet once the connector exits, so it never keeps the pod running; on SIGTERM it gives
+// tail a moment to flush the last lines. Native sidecars need Kubernetes 1.29+.
+func withLogTail(pod *corev1.Pod, logTail *ConnectorLogTail, image string) {
+	if logTail == nil {
+		return
+	}
+
+	logPath := strings.TrimPrefix(logTail.Path, "/")
+	if logPath == "" {
+		logPath = defaultLogTailPath
+	}
+	logGlob := path.Join(constants.ContainerMountDir, logPath)
+
+	script := fmt.Sprintf(
+		"trap 'sleep 1; kill $pid 2>/dev/null; exit 0' TERM\n"+
+			"until ls %[1]s >/dev/null 2>&1; do sleep 1 & wait $!; done\n"+
+			"tail -n +1 -F $(ls %[1]s | tail -n 1) & pid=$!\n"+
+			"wait $pid",
+		logGlob,
+	)
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:          logTailContainerName,
+		Image:         image,
+		RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+		Command:       []string{"/bin/sh", "-c", script},
+		VolumeMounts:  slices.Clone(pod.Spec.Containers[0].VolumeMounts[:1]), // the job-storage mount at /mnt/config
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("16Mi"),
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+			},
+		},
+	})
+}
+
+// hasLogTail reports whether the pod runs the log tail sidecar
+func hasLogTail(pod *corev1.Pod) bool {
+	return slices.ContainsFunc(pod.Spec.InitContainers, func(c corev1.Container) bool {
+		return c.Name == logTailContainerName
+	})
+}
@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podMetrics is the part of a metrics.k8s.io PodMetrics object the sampler reads. It is
+// decoded by hand so the worker doesn't depend on the metrics client for a single GET.
+type podMetrics struct {
+	Containers []struct {
+		Name  string `json:"name"`
+		Usage struct {
+			CPU    resource.Quantity `json:"cpu"`
+			Memory resource.Quantity `json:"memory"`
+		} `json:"usage"`
+	} `json:"containers"`
+}
+
+// SampleResources reads the connector container's usage from the metrics API, which needs
+// metrics-server in the cluster. Metrics are averaged over the metrics-server scrape window.
+func (k *KubernetesExecutor) SampleResources(ctx context.Context, req *types.ExecutionRequest) (*types.ResourceSample, error) {
+	podName := k.sanitizeName(req.WorkflowID)
+	if k.runsAsJob(req.Command) {
+		podName = k.jobPodName(ctx, podName)
+	}
+	raw, err := k.client.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", k.namespace, "pods", podName).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %s", err)
+	}
+
+	var metrics podMetrics
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to decode pod metrics: %s", err)
+	}
+
+	for _, c := range metrics.Containers {
+		if c.Name != "connector" {
+			continue
+		}
+		sample := &types.ResourceSample{
+			CPUCores:    c.Usage.CPU.AsApproximateFloat64(),
+			MemoryBytes: c.Usage.Memory.Value(),
+		}
+		if pod, err := k.client.CoreV1().Pods(k.namespace).Get(ctx, podName, metav1.GetOptions{}); err == nil {
+			for _, spec := range pod.Spec.Containers {
+				if limit, found := spec.Resources.Limits[corev1.ResourceMemory]; spec.Name == "connector" && found {
+					sample.MemoryLimitBytes = limit.Value()
+				}
+			}
+		}
+		return sample, nil
+	}
+	return nil, fmt.Errorf("no metrics for connector container in pod %s", podName)
+}
@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// mountRoot is the directory extra mounts must live under; the job directory at
+// /mnt/config is reserved
+const mountRoot = "/mnt"
+
+// ConnectorMount mounts an existing Secret or ConfigMap from the worker namespace into the
+// connector container, read-only, for files that don't belong in the connector config
+// (TLS certificates, SSH keys, Kerberos keytabs). Set exactly one of Secret or ConfigMap.
+type ConnectorMount struct {
+	Secret    string `json:"secret,omitempty"`
+	ConfigMap string `json:"configMap,omitempty"`
+	MountPath string `json:"mountPath"` // e.g. /mnt/certs
+}
+
+// validateConnectorMount checks a profile mount, returning the cleaned mount path
+func validateConnectorMount(mount ConnectorMount) (string, error) {
+	name := mount.Secret
+	if (mount.Secret == "") == (mount.ConfigMap == "") {
+		return "", fmt.Errorf("exactly one of secret or configMap must be set")
+	}
+	if name == "" {
+		name = mount.ConfigMap
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid name '%s': %s", name, errs)
+	}
+
+	mountPath := path.Clean(mount.MountPath)
+	if !strings.HasPrefix(mountPath, mountRoot+"/") {
+		return "", fmt.Errorf("mount path '%s' must be under %s", mount.MountPath, mountRoot)
+	}
+	if mountPath == constants.ContainerMountDir || strings.HasPrefix(mountPath, constants.ContainerMountDir+"/") {
+		return "", fmt.Errorf("mount path '%s' overlaps the job directory %s", mount.MountPath, constants.ContainerMountDir)
+	}
+	return mountPath, nil
+}
+
+// GetMountsForJob returns the extra Secret and ConfigMap mounts configured for the given jobID
+func (k *KubernetesExecutor) GetMountsForJob(jobID int, operation types.Command) []ConnectorMount {
+	profile, exists := k.resolveJobProfile(jobID, operation)
+	if !exists {
+		return nil
+	}
+	return profile.Mounts
+}
+
+// withMounts adds a read-only volume and connector volume mount for each profile mount
+func withMounts(pod *corev1.Pod, mounts []ConnectorMount) {
+	for i, mount := range mounts {
+		volume := corev1.Volume{Name: fmt.Sprintf("extra-mount-%d", i)}
+		if mount.Secret != "" {
+			volume.Secret = &corev1.SecretVolumeSource{SecretName: mount.Secret}
+		} else {
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: mount.ConfigMap},
+			}
+		}
+
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: mount.MountPath,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// checkMountSources verifies that the Secrets and ConfigMaps a pod mounts exist. Without this
+// a missing one leaves the pod stuck in ContainerCreating until the operation times out.
+func (k *KubernetesExecutor) checkMountSources(ctx context.Context, mounts []ConnectorMount) error {
+	for _, mount := range mounts {
+		kind, name := "configmap", mount.ConfigMap
+		if mount.Secret != "" {
+			kind, name = "secret", mount.Secret
+		}
+		if err := k.checkSourceExists(ctx, kind, name, "mounted at "+mount.MountPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSourceExists returns ErrExecutionFailed when the Secret or ConfigMap a job profile
+// references, as described by usage, is missing from the worker namespace
+func (k *KubernetesExecutor) checkSourceExists(ctx context.Context, kind, name, usage string) error {
+	var err error
+	if kind == "secret" {
+		_, err = k.client.CoreV1().Secrets(k.namespace).Get(ctx, name, metav1.GetOptions{})
+	} else {
+		_, err = k.client.CoreV1().ConfigMaps(k.namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %s %s %s by the job profile not found in namespace %s",
+			constants.ErrExecutionFailed, kind, name, usage, k.namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s %s: %s", kind, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func TestLoadJobProfilesMounts(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"mounts":[
+		{"secret":"pg-certs","mountPath":"/mnt/certs/"},
+		{"configMap":"krb5","mountPath":"/mnt/kerberos"},
+		{"secret":"ssh-key","configMap":"ssh-config","mountPath":"/mnt/ssh"},
+		{"mountPath":"/mnt/empty"},
+		{"secret":"Bad_Name","mountPath":"/mnt/bad"},
+		{"secret":"etc","mountPath":"/etc/ssl"},
+		{"secret":"escape","mountPath":"/mnt/../etc"},
+		{"secret":"job-dir","mountPath":"/mnt/config/certs"},
+		{"configMap":"certs-again","mountPath":"/mnt/certs"}
+	]}}`)
+
+	// invalid mounts are dropped, paths are cleaned
+	require.Equal(t, []ConnectorMount{
+		{Secret: "pg-certs", MountPath: "/mnt/certs"},
+		{ConfigMap: "krb5", MountPath: "/mnt/kerberos"},
+	}, profiles[7].Mounts)
+}
+
+func TestValidateConnectorMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		mount   ConnectorMount
+		want    string
+		wantErr string
+	}{
+		{name: "secret", mount: ConnectorMount{Secret: "pg-certs", MountPath: "/mnt/certs"}, want: "/mnt/certs"},
+		{name: "configmap", mount: ConnectorMount{ConfigMap: "krb5", MountPath: "/mnt//kerberos/"}, want: "/mnt/kerberos"},
+		{name: "both sources", mount: ConnectorMount{Secret: "a", ConfigMap: "b", MountPath: "/mnt/a"}, wantErr: "exactly one of secret or configMap must be set"},
+		{name: "no source", mount: ConnectorMount{MountPath: "/mnt/a"}, wantErr: "exactly one of secret or configMap must be set"},
+		{name: "invalid name", mount: ConnectorMount{Secret: "Bad_Name", MountPath: "/mnt/a"}, wantErr: "invalid name 'Bad_Name'"},
+		{name: "mount root", mount: ConnectorMount{Secret: "a", MountPath: "/mnt"}, wantErr: "must be under /mnt"},
+		{name: "outside /mnt", mount: ConnectorMount{Secret: "a", MountPath: "/mnt/../etc"}, wantErr: "must be under /mnt"},
+		{name: "job directory", mount: ConnectorMount{Secret: "a", MountPath: constants.ContainerMountDir}, wantErr: "overlaps the job directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateConnectorMount(tt.mount)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreatePodSpecMounts(t *testing.T) {
+	k := profileExecutor(KubernetesConfig{}, map[int]JobSchedulingConfig{7: {Mounts: []ConnectorMount{
+		{Secret: "pg-certs", MountPath: "/mnt/certs"},
+		{ConfigMap: "krb5", MountPath: "/mnt/kerberos"},
+	}}})
+
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	require.Contains(t, pod.Spec.Volumes, corev1.Volume{Name: "extra-mount-0", VolumeSource: corev1.VolumeSource{
+		Secret: &corev1.SecretVolumeSource{SecretName: "pg-certs"},
+	}})
+	require.Contains(t, pod.Spec.Volumes, corev1.Volume{Name: "extra-mount-1", VolumeSource: corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "krb5"}},
+	}})
+	connector := pod.Spec.Containers[0]
+	require.Contains(t, connector.VolumeMounts, corev1.VolumeMount{Name: "extra-mount-0", MountPath: "/mnt/certs", ReadOnly: true})
+	require.Contains(t, connector.VolumeMounts, corev1.VolumeMount{Name: "extra-mount-1", MountPath: "/mnt/kerberos", ReadOnly: true})
+
+	// other jobs mount nothing extra
+	pod = k.CreatePodSpec(&types.ExecutionRequest{JobID: 8, WorkflowID: "sync-8-abc", Command: types.Sync}, "/data/sync-8-abc", "olakego/source-postgres:latest")
+	for _, volume := range pod.Spec.Volumes {
+		require.NotContains(t, volume.Name, "extra-mount")
+	}
+}
+
+func TestCheckMountSources(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pg-certs", Namespace: "olake"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "krb5", Namespace: "olake"}},
+		// objects in other namespaces can't be mounted
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ssh-key", Namespace: "default"}},
+	)
+	k := &KubernetesExecutor{client: client, namespace: "olake"}
+
+	tests := []struct {
+		name    string
+		mounts  []ConnectorMount
+		wantErr string
+	}{
+		{name: "no mounts"},
+		{name: "present", mounts: []ConnectorMount{{Secret: "pg-certs", MountPath: "/mnt/certs"}, {ConfigMap: "krb5", MountPath: "/mnt/kerberos"}}},
+		{name: "missing secret", mounts: []ConnectorMount{{Secret: "ssh-key", MountPath: "/mnt/ssh"}}, wantErr: "secret ssh-key mounted at /mnt/ssh by the job profile not found in namespace olake"},
+		{name: "missing configmap", mounts: []ConnectorMount{{Secret: "pg-certs", MountPath: "/mnt/certs"}, {ConfigMap: "hosts", MountPath: "/mnt/hosts"}}, wantErr: "configmap hosts mounted at /mnt/hosts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := k.checkMountSources(context.Background(), tt.mounts)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, constants.ErrExecutionFailed)
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+// workerIdentityPrefix prefixes the worker pod name in the olake.io/created-by-pod annotation
+const workerIdentityPrefix = "olake.io/olake-workers/"
+
+// reconcileOrphanPods removes connector pods left behind by worker pods that no longer exist,
+// e.g. after a worker was OOM killed mid-run. Orphans aren't removed right away: a retried
+// activity recreating the same pod name adopts it and reads its result, so only pods that have
+// been finished, or short-lived operations that have been running, for ORPHAN_POD_GRACE are
+// deleted. Running syncs are always left for their workflow to adopt. Pods owned by a Job are
+// cleaned up with the Job.
+func (k *KubernetesExecutor) reconcileOrphanPods(ctx context.Context) {
+	grace := viper.GetDuration(constants.EnvOrphanPodGrace)
+	if grace <= 0 {
+		return
+	}
+
+	pods, err := k.client.CoreV1().Pods(k.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/managed-by=olake-workers",
+	})
+	if err != nil {
+		logger.Warnf("failed to list connector pods for orphan cleanup: %s", err)
+		return
+	}
+
+	workerExists := map[string]bool{}
+	var deleted, adoptable int
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		creator := pod.Annotations["olake.io/created-by-pod"]
+		if creator == "" || creator == k.config.WorkerIdentity || pod.DeletionTimestamp != nil || isOwnedByJob(pod) {
+			continue
+		}
+
+		workerName := strings.TrimPrefix(creator, workerIdentityPrefix)
+		exists, checked := workerExists[workerName]
+		if !checked {
+			_, err := k.client.CoreV1().Pods(k.namespace).Get(ctx, workerName, metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				logger.Warnf("failed to check worker %s of pod %s, skipping: %s", workerName, pod.Name, err)
+				continue
+			}
+			exists = err == nil
+			workerExists[workerName] = exists
+		}
+		if exists {
+			continue
+		}
+
+		terminal := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		async := slices.Contains(constants.AsyncCommands, types.Command(pod.Labels["olake.io/operation-type"]))
+		if !terminal && async {
+			adoptable++
+			logger.Infof("orphaned pod %s of workflow %s still running, leaving it for its workflow to adopt", pod.Name, pod.Annotations["olake.io/workflow-id"])
+			continue
+		}
+		if time.Since(podFinishedAt(pod)) < grace {
+			continue
+		}
+
+		logger.Infof("deleting orphaned pod %s (phase %s) of worker %s", pod.Name, pod.Status.Phase, workerName)
+		if err := k.cleanupPod(ctx, pod.Name); err != nil {
+			logger.Warnf("failed to delete orphaned pod %s: %s", pod.Name, err)
+			continue
+		}
+		deleted++
+	}
+
+	if deleted > 0 || adoptable > 0 {
+		logger.Infof("orphan pod cleanup deleted %d pods, left %d running syncs for adoption", deleted, adoptable)
+	}
+}
+
+// podFinishedAt returns when the pod's last container terminated, or when the pod was created
+// if none has
+func podFinishedAt(pod *corev1.Pod) time.Time {
+	finishedAt := pod.CreationTimestamp.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if term := status.State.Terminated; term != nil && term.FinishedAt.After(finishedAt) {
+			finishedAt = term.FinishedAt.Time
+		}
+	}
+	return finishedAt
+}
+
+func isOwnedByJob(pod *corev1.Pod) bool {
+	return slices.ContainsFunc(pod.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "Job"
+	})
+}
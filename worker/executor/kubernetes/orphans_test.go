@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// connectorPod is a connector pod of the operation created by the given worker age ago
+func connectorPod(name, worker string, operation types.Command, phase corev1.PodPhase, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "olake",
+			Labels:            map[string]string{"app.kubernetes.io/managed-by": "olake-workers", "olake.io/operation-type": string(operation)},
+			Annotations:       map[string]string{"olake.io/created-by-pod": workerIdentityPrefix + worker},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestReconcileOrphanPods(t *testing.T) {
+	viper.Set(constants.EnvOrphanPodGrace, time.Hour)
+	t.Cleanup(func() { viper.Set(constants.EnvOrphanPodGrace, nil) })
+
+	recentlyFinished := connectorPod("sync-3-abc", "worker-gone", types.Sync, corev1.PodSucceeded, 3*time.Hour)
+	recentlyFinished.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute))},
+	}}}
+	jobPod := connectorPod("sync-4-abc-x1", "worker-gone", types.Sync, corev1.PodFailed, 3*time.Hour)
+	jobPod.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "sync-4-abc"}}
+
+	objects := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "worker-alive", Namespace: "olake"}},
+		connectorPod("sync-1-abc", "worker-gone", types.Sync, corev1.PodSucceeded, 3*time.Hour),
+		connectorPod("sync-2-abc", "worker-gone", types.Sync, corev1.PodRunning, 3*time.Hour),
+		recentlyFinished,
+		jobPod,
+		connectorPod("discover-abc", "worker-gone", types.Discover, corev1.PodRunning, 3*time.Hour),
+		connectorPod("check-abc", "worker-gone", types.Check, corev1.PodRunning, time.Minute),
+		connectorPod("sync-5-abc", "worker-alive", types.Sync, corev1.PodFailed, 3*time.Hour),
+		connectorPod("sync-6-abc", "worker-self", types.Sync, corev1.PodFailed, 3*time.Hour),
+	}
+	client := fake.NewSimpleClientset(objects...)
+	k := &KubernetesExecutor{client: client, namespace: "olake", config: &KubernetesConfig{WorkerIdentity: workerIdentityPrefix + "worker-self"}}
+
+	k.reconcileOrphanPods(context.Background())
+
+	pods, err := client.CoreV1().Pods("olake").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var remaining []string
+	for _, pod := range pods.Items {
+		remaining = append(remaining, pod.Name)
+	}
+	// finished orphans and long-running operations are removed; running syncs are left to be
+	// adopted, and pods finished within the grace are left for a retried activity to read
+	require.ElementsMatch(t, []string{"worker-alive", "sync-2-abc", "sync-3-abc", "sync-4-abc-x1", "check-abc", "sync-5-abc", "sync-6-abc"}, remaining)
+}
+
+func TestReconcileOrphanPodsDisabled(t *testing.T) {
+	viper.Set(constants.EnvOrphanPodGrace, 0)
+	t.Cleanup(func() { viper.Set(constants.EnvOrphanPodGrace, nil) })
+
+	client := fake.NewSimpleClientset(connectorPod("sync-1-abc", "worker-gone", types.Sync, corev1.PodSucceeded, 3*time.Hour))
+	k := &KubernetesExecutor{client: client, namespace: "olake", config: &KubernetesConfig{}}
+
+	k.reconcileOrphanPods(context.Background())
+
+	_, err := client.CoreV1().Pods("olake").Get(context.Background(), "sync-1-abc", metav1.GetOptions{})
+	require.NoError(t, err)
+}
@@ -1,15 +1,17 @@
 package kubernetes
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"path/filepath"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,13 +19,23 @@ import (
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
+	"github.com/spf13/viper"
 )
 
-func (k *KubernetesExecutor) waitForPodCompletion(ctx context.Context, podName string, timeout time.Duration, heartbeatFunc func(context.Context, ...interface{})) error {
+// podTerminationBuffer is the extra time allowed on top of the grace period for the kubelet to report the pod gone
+const podTerminationBuffer = 10 * time.Second
+
+func (k *KubernetesExecutor) waitForPodCompletion(ctx context.Context, podName string, timeout time.Duration, heartbeatFunc func(context.Context, ...interface{})) (err error) {
+	ctx, span := tracing.Start(ctx, "kubernetes.waitForPodCompletion", attribute.String("olake.pod_name", podName))
+	defer func() { tracing.End(span, err) }()
+
 	log := logger.Log(ctx)
 	log.Debug("waiting for pod to complete", "podName", podName, "timeout", timeout)
 	deadline := time.Now().Add(timeout)
+	var pullFailures []time.Time
 
 	for time.Now().Before(deadline) {
 		// Record heartbeat to enable cancellation detection if heartbeat function is provided
@@ -43,43 +55,40 @@ func (k *KubernetesExecutor) waitForPodCompletion(ctx context.Context, podName s
 			return nil
 		}
 
-		// Check if pod failed
-		if pod.Status.Phase == corev1.PodFailed {
-			// Check if this is a retryable infrastructure failure
-			retryableReasons := []string{"ImagePullBackOff", "ErrImagePull"}
-			if slices.Contains(retryableReasons, pod.Status.Reason) {
-				log.Warn("pod not running, continuing to poll", "podName", podName, "reason", pod.Status.Reason, "message", pod.Status.Message)
-				continue
+		// an injected mesh sidecar keeps the pod running after the connector has exited
+		if term := connectorExitedInRunningPod(pod); term != nil {
+			if term.ExitCode == 0 {
+				log.Info("connector completed, pod kept running by another container", "podName", podName)
+				return nil
 			}
+			return connectorExitFailure(ctx, pod, term)
+		}
 
-			// Common exit codes:
-			// - Exit 0: Success
-			// - Exit 1: General application error
-			// - Exit 2: Misuse of shell command or manual termination
-			// - Exit 137: SIGKILL (OOMKilled or manual kill)
-			// - Exit 143: SIGTERM (graceful termination)
-			var containerInfo string
-			if len(pod.Status.ContainerStatuses) > 0 {
-				status := pod.Status.ContainerStatuses[0]
-				if status.State.Terminated != nil {
-					term := status.State.Terminated
-					containerInfo = fmt.Sprintf("exit code: %d, reason: %s", term.ExitCode, term.Reason)
-				} else {
-					// The only other two ContainerState options are Waiting and Running, so if it's not Terminated, it must be one of those
-					// refer: https://pkg.go.dev/k8s.io/api/core/v1#ContainerState
-					// Not expected as the pod is in Failed state with only one container, the container shouldnot be in Waiting or Running state, but logging for debugging purposes
-					containerInfo = fmt.Sprintf("container not terminated; reason: %s, message: %s", pod.Status.Reason, pod.Status.Message)
+		// Image pull failures are retried by the kubelet, but an image that doesn't exist never
+		// recovers; give up once they keep happening instead of polling until the timeout
+		if image, reason, failing := imagePullFailure(pod); failing {
+			pullFailures = recordPullFailure(pullFailures, time.Now())
+			maxFailures := viper.GetInt(constants.EnvImagePullMaxFailures)
+			log.Warn("pod image not pulled, continuing to poll", "podName", podName, "image", image, "reason", reason, "failures", len(pullFailures))
+			if maxFailures > 0 && len(pullFailures) >= maxFailures {
+				log.Error("giving up on pod image pull", "podName", podName, "image", image, "reason", reason, "failures", len(pullFailures))
+				if err := k.cleanupPod(context.WithoutCancel(ctx), podName); err != nil {
+					log.Warn("failed to delete pod after image pull failures", "podName", podName, "error", err)
 				}
-			} else {
-				containerInfo = fmt.Sprintf("containerStatus not found; reason: %s, message: %s", pod.Status.Reason, pod.Status.Message)
+				return fmt.Errorf("%w: image %s not found or not pullable (%s after %d attempts)", constants.ErrExecutionFailed, image, reason, len(pullFailures))
 			}
-			log.Error("pod failed", "podName", podName, "containerInfo", containerInfo)
-			return fmt.Errorf("%w: pod %s failed (%s)", constants.ErrExecutionFailed, podName, containerInfo)
+		} else {
+			pullFailures = nil
+		}
+
+		// Check if pod failed
+		if pod.Status.Phase == corev1.PodFailed && len(pullFailures) == 0 {
+			return podFailure(ctx, pod)
 		}
 
 		// Wait before checking again, with responsive cancellation
 		select {
-		case <-time.After(5 * time.Second):
+		case <-time.After(utils.GetHeartbeatInterval(ctx)):
 			// Continue to next iteration
 		case <-ctx.Done():
 			log.Warn("context cancelled while waiting for pod", "podName", podName)
@@ -91,10 +100,143 @@ func (k *KubernetesExecutor) waitForPodCompletion(ctx context.Context, podName s
 	return fmt.Errorf("pod timed out after %v", timeout)
 }
 
+// podFailure returns the error a failed connector pod is reported with: a failed config check,
+// a cancellation, or how the connector container exited
+func podFailure(ctx context.Context, pod *corev1.Pod) error {
+	log := logger.Log(ctx)
+	podName := pod.Name
+	if message, failed := configCheckFailure(pod); failed {
+		log.Error("pod config check failed", "podName", podName, "message", message)
+		return fmt.Errorf("%w: pod %s config check failed: %s", constants.ErrExecutionFailed, podName, message)
+	}
+
+	// Common exit codes:
+	// - Exit 0: Success
+	// - Exit 1: General application error
+	// - Exit 2: Misuse of shell command or manual termination
+	// - Exit 137: SIGKILL (OOMKilled or manual kill)
+	// - Exit 143: SIGTERM (graceful termination)
+	// The connector is looked up by name: an injected sidecar can come first in the statuses.
+	var containerInfo string
+	if status := connectorStatus(pod); status != nil {
+		if term := status.State.Terminated; term != nil {
+			// the connector finished its work; the pod failed because of another container
+			if term.ExitCode == 0 {
+				log.Warn("pod failed after the connector completed, treating as success", "podName", podName, "reason", pod.Status.Reason, "message", pod.Status.Message)
+				return nil
+			}
+			return connectorExitFailure(ctx, pod, term)
+		}
+		// The only other two ContainerState options are Waiting and Running, so if it's not Terminated, it must be one of those
+		// refer: https://pkg.go.dev/k8s.io/api/core/v1#ContainerState
+		// Not expected as the pod is in Failed state, the connector should not be in Waiting or Running state, but logging for debugging purposes
+		containerInfo = fmt.Sprintf("container not terminated; reason: %s, message: %s", pod.Status.Reason, pod.Status.Message)
+	} else {
+		containerInfo = fmt.Sprintf("containerStatus not found; reason: %s, message: %s", pod.Status.Reason, pod.Status.Message)
+	}
+	log.Error("pod failed", "podName", podName, "containerInfo", containerInfo)
+	return fmt.Errorf("%w: pod %s failed (%s)", constants.ErrExecutionFailed, podName, containerInfo)
+}
+
+// connectorExitedInRunningPod returns how the connector container ended when the pod is still
+// running only because another regular container is, such as a mesh sidecar that was injected
+// anyway. Native sidecars (log tail, local state) are init containers the kubelet stops itself,
+// so the pod is left to finish on its own while they do.
+func connectorExitedInRunningPod(pod *corev1.Pod) *corev1.ContainerStateTerminated {
+	if pod == nil || pod.Status.Phase != corev1.PodRunning {
+		return nil
+	}
+	connector := connectorStatus(pod)
+	if connector == nil || connector.State.Terminated == nil {
+		return nil
+	}
+	othersRunning := slices.ContainsFunc(pod.Status.ContainerStatuses, func(status corev1.ContainerStatus) bool {
+		return status.Name != connector.Name && status.State.Running != nil
+	})
+	if !othersRunning {
+		return nil
+	}
+	return connector.State.Terminated
+}
+
+// connectorStatus returns the status of the pod's connector container, nil before it is reported
+func connectorStatus(pod *corev1.Pod) *corev1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == "connector" {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// connectorExitFailure reports a connector that exited non-zero, unless it was stopped for
+// cancellation
+func connectorExitFailure(ctx context.Context, pod *corev1.Pod, term *corev1.ContainerStateTerminated) error {
+	containerInfo := fmt.Sprintf("exit code: %d, reason: %s", term.ExitCode, term.Reason)
+	if isCancellationExit(ctx, pod, term) {
+		logger.Log(ctx).Info("pod terminated during cancellation", "podName", pod.Name, "containerInfo", containerInfo)
+		return fmt.Errorf("%w: pod %s terminated (%s)", constants.ErrExecutionCancelled, pod.Name, containerInfo)
+	}
+	logger.Log(ctx).Error("connector failed", "podName", pod.Name, "containerInfo", containerInfo)
+	return &constants.ConnectorExitError{
+		ExitCode: int(term.ExitCode),
+		Reason:   term.Reason,
+		Detail:   fmt.Sprintf("pod %s failed (%s)", pod.Name, containerInfo),
+		Output:   term.Message,
+	}
+}
+
+// imagePullReasons are the pod and container reasons of an image the kubelet failed to pull
+var imagePullReasons = []string{"ImagePullBackOff", "ErrImagePull"}
+
+// imagePullFailure reports the image and reason when the pod or one of its containers is
+// waiting on an image that could not be pulled
+func imagePullFailure(pod *corev1.Pod) (string, string, bool) {
+	statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && slices.Contains(imagePullReasons, status.State.Waiting.Reason) {
+			return status.Image, status.State.Waiting.Reason, true
+		}
+	}
+	if slices.Contains(imagePullReasons, pod.Status.Reason) && len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Image, pod.Status.Reason, true
+	}
+	return "", "", false
+}
+
+// recordPullFailure adds a failed image pull, dropping earlier ones that fell out of IMAGE_PULL_FAILURE_WINDOW
+func recordPullFailure(failures []time.Time, now time.Time) []time.Time {
+	window := viper.GetDuration(constants.EnvImagePullFailureWindow)
+	failures = append(failures, now)
+	if window <= 0 {
+		return failures
+	}
+	return slices.DeleteFunc(failures, func(at time.Time) bool {
+		return now.Sub(at) > window
+	})
+}
+
+// isCancellationExit reports whether the connector was killed by SIGTERM (143) or SIGKILL (137)
+// because the pod was being deleted or the activity was cancelled. An OOM kill also exits
+// with 137 but is a genuine failure, so it is never treated as a cancellation.
+func isCancellationExit(ctx context.Context, pod *corev1.Pod, term *corev1.ContainerStateTerminated) bool {
+	if !viper.GetBool(constants.EnvSignalExitAsCancel) {
+		return false
+	}
+	if term.ExitCode != 143 && (term.ExitCode != 137 || term.Reason == "OOMKilled") {
+		return false
+	}
+	return pod.DeletionTimestamp != nil || ctx.Err() != nil
+}
+
 func (k *KubernetesExecutor) getPodLogs(ctx context.Context, podName string) (string, error) {
+	return k.getContainerLogs(ctx, podName, "connector")
+}
+
+func (k *KubernetesExecutor) getContainerLogs(ctx context.Context, podName, container string) (string, error) {
 	log := logger.Log(ctx)
 	req := k.client.CoreV1().Pods(k.namespace).GetLogs(podName, &corev1.PodLogOptions{
-		Container: "connector",
+		Container: container,
 	})
 
 	logs, err := req.Stream(ctx)
@@ -108,12 +250,16 @@ func (k *KubernetesExecutor) getPodLogs(ctx context.Context, podName string) (st
 		}
 	}()
 
-	buf := new(bytes.Buffer)
+	maxBytes := viper.GetInt(constants.EnvMaxConnectorOutputBytes)
+	buf := utils.NewOutputBuffer(maxBytes)
 	_, err = io.Copy(buf, logs)
 	if err != nil {
 		log.Error("failed to read pod logs", "podName", podName, "error", err)
 		return "", fmt.Errorf("failed to read pod logs: %s", err)
 	}
+	if dropped := buf.Truncated(); dropped > 0 {
+		log.Warn("connector output over limit, kept its start and end", "podName", podName, "container", container, "droppedBytes", dropped, "limit", maxBytes)
+	}
 
 	return buf.String(), nil
 }
@@ -138,8 +284,48 @@ func (k *KubernetesExecutor) cleanupPod(ctx context.Context, podName string) err
 	return nil
 }
 
+// terminatePod deletes the pod with the given grace period and waits for it to be removed,
+// so the connector can checkpoint on SIGTERM. If the pod is still around once the window
+// has elapsed it is force-deleted.
+func (k *KubernetesExecutor) terminatePod(ctx context.Context, podName string, gracePeriod int64) error {
+	log := logger.Log(ctx)
+	log.Info("terminating pod", "podName", podName, "gracePeriodSeconds", gracePeriod)
+
+	err := k.client.CoreV1().Pods(k.namespace).Delete(ctx, podName, metav1.DeleteOptions{GracePeriodSeconds: ptr.To(gracePeriod)})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("pod already deleted", "podName", podName, "namespace", k.namespace)
+			return nil
+		}
+		return fmt.Errorf("failed to delete pod %s in namespace %s: %s", podName, k.namespace, err)
+	}
+
+	deadline := time.Now().Add(time.Duration(gracePeriod)*time.Second + podTerminationBuffer)
+	for time.Now().Before(deadline) {
+		if _, err := k.client.CoreV1().Pods(k.namespace).Get(ctx, podName, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			log.Debug("pod terminated within grace period", "podName", podName)
+			return nil
+		}
+
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	log.Warn("pod still present after grace period, force deleting", "podName", podName, "gracePeriodSeconds", gracePeriod)
+	err = k.client.CoreV1().Pods(k.namespace).Delete(ctx, podName, metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to force delete pod %s in namespace %s: %s", podName, k.namespace, err)
+	}
+	return nil
+}
+
 func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir, imageName string) *corev1.Pod {
 	subDir := filepath.Base(workDir)
+	resources := k.GetResourcesForJob(req.JobID, req.Command)
+	profileLabels, profileAnnotations := k.GetPodMetadataForJob(req.JobID, req.Command)
 
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -147,7 +333,7 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 			Namespace: k.namespace,                    // Target namespace for pod creation
 
 			// Labels are used for querying, filtering, and organizing pods
-			Labels: map[string]string{
+			Labels: k.buildPodLabels(profileLabels, map[string]string{
 				// Standard Kubernetes labels for ecosystem compatibility
 				"app.kubernetes.io/name":       "olake",                                                      // Application name
 				"app.kubernetes.io/component":  fmt.Sprintf("%s-%s", req.ConnectorType, string(req.Command)), // Component identifier
@@ -158,12 +344,12 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 				"olake.io/connector":      req.ConnectorType,              // mysql, postgres, etc.
 				"olake.io/job-id":         strconv.Itoa(req.JobID),        // Database job reference
 				"olake.io/workflow-id":    k.sanitizeName(req.WorkflowID), // Sanitized workflow ID
-			},
+			}),
 
 			// Annotations store metadata that doesn't affect pod selection/scheduling.
-			// Global job pod annotations (global.podAnnotations) are merged via buildPodAnnotations;
-			// olake.io/* internal keys always take precedence over user-supplied ones.
-			Annotations: k.buildPodAnnotations(map[string]string{
+			// Global job pod annotations (global.podAnnotations) and the job profile's podAnnotations are
+			// merged via buildPodAnnotations; olake.io/* internal keys always take precedence over user-supplied ones.
+			Annotations: k.buildPodAnnotations(profileAnnotations, map[string]string{
 				"olake.io/created-by-pod": k.config.WorkerIdentity,
 				"olake.io/created-at":     time.Now().Format(time.RFC3339),
 				"olake.io/workflow-id":    req.WorkflowID,
@@ -173,17 +359,18 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 			}),
 		},
 		Spec: corev1.PodSpec{
-			RestartPolicy:   corev1.RestartPolicyNever,
-			NodeSelector:    k.GetNodeSelectorForJob(req.JobID, req.Command),
-			Tolerations:     k.GetTolerationsForJob(req.JobID, req.Command),
-			Affinity:        k.BuildAffinityForJob(req.JobID, req.Command),
-			SecurityContext: k.config.SecurityContext,
+			RestartPolicy:     corev1.RestartPolicyNever,
+			PriorityClassName: k.GetPriorityClassForJob(req.JobID, req.Command),
+			NodeSelector:      k.GetNodeSelectorForJob(req.JobID, req.Command),
+			Tolerations:       withGPUToleration(k.GetTolerationsForJob(req.JobID, req.Command), resources),
+			Affinity:          k.BuildAffinityForJob(req.JobID, req.Command),
+			SecurityContext:   k.GetSecurityContextForJob(req.JobID, req.Command),
 			Containers: []corev1.Container{
 				{
 					Name:    "connector",
 					Image:   imageName,
 					Command: []string{},
-					Args:    req.Args,
+					Args:    utils.AppendExtraArgs(req.Args, k.GetExtraArgsForJob(req.JobID, req.Command), req.ExtraArgs),
 					VolumeMounts: []corev1.VolumeMount{
 						{
 							Name:      "job-storage",
@@ -191,23 +378,15 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 							SubPath:   subDir,
 						},
 					},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceMemory: k.parseQuantity("256Mi"),
-							corev1.ResourceCPU:    k.parseQuantity("100m"),
-						},
-						// No limits for flexibility
-					},
-					Env: []corev1.EnvVar{
+					Resources: resources,
+					// the last log lines of a failed connector are kept in its termination message
+					TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					Env: append([]corev1.EnvVar{
 						{
 							Name:  "OLAKE_WORKFLOW_ID",
 							Value: req.WorkflowID,
 						},
-						{
-							Name:  "OLAKE_SECRET_KEY",
-							Value: k.config.SecretKey,
-						},
-					},
+					}, append(buildSecretKeyEnv(utils.GetSecretKeys()), buildJVMHeapEnv(req.ConnectorType, resources, viper.GetString(constants.EnvJavaToolOptions))...)...),
 					EnvFrom: []corev1.EnvFromSource{
 						{
 							ConfigMapRef: &corev1.ConfigMapEnvSource{
@@ -233,14 +412,34 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 		},
 	}
 
+	withClassification(pod, k.GetClassificationForJob(req.JobID, req.Command))
+	withHighAvailability(pod, k.IsHighAvailabilityJob(req.JobID, req.Command))
+	withLogRouting(pod, k.GetLogRoutingForJob(req.JobID, req.Command))
+	k.withMemoMetadata(pod, req.Memo)
+	withWrapper(&pod.Spec.Containers[0], k.GetWrapperForJob(req.JobID, req.Command), subDir)
+	withConfigCheck(pod, k.config.ConfigCheckImage, subDir)
+	withLogTail(pod, k.GetLogTailForJob(req.JobID, req.Command), imageName)
+	withMounts(pod, k.GetMountsForJob(req.JobID, req.Command))
+	profileEnv, profileEnvFrom := k.GetEnvForJob(req.JobID, req.Command)
+	withJobEnv(&pod.Spec.Containers[0], utils.GetJobEnvVars(profileEnv, req.Env), profileEnvFrom)
+	withEnv(&pod.Spec.Containers[0], utils.GetTriggerEnvVars(req))
+
 	// Set ServiceAccountName only if configured (non-empty)
 	// If empty, Kubernetes will use the namespace's default service account
 	if k.config.JobServiceAccount != "" && k.config.JobServiceAccount != "default" {
 		pod.Spec.ServiceAccountName = k.config.JobServiceAccount
 	}
 
-	// Add liveness probe for long-running sync operations
+	// Add liveness probe and shutdown grace for long-running sync operations
 	if slices.Contains(constants.AsyncCommands, req.Command) {
+		if k.config.TerminationGrace > 0 {
+			pod.Spec.TerminationGracePeriodSeconds = ptr.To(k.config.TerminationGrace)
+		}
+
+		if k.config.LocalState.Enabled() {
+			applyLocalState(pod, k.config.LocalState, imageName)
+		}
+
 		pod.Spec.Containers[0].LivenessProbe = &corev1.Probe{
 			ProbeHandler: corev1.ProbeHandler{
 				Exec: &corev1.ExecAction{
@@ -262,12 +461,44 @@ func (k *KubernetesExecutor) CreatePodSpec(req *types.ExecutionRequest, workDir,
 	return pod
 }
 
+// checkJobNotRunning returns ErrJobAlreadyRunning when a sync or clear-destination pod of
+// another workflow is still running for the job, e.g. a scheduled sync that outlasted its
+// interval. Both would write to the same destination and job state. The workflow's own pod,
+// or its Job's pods, are left to createPod/createJob, which resume polling them.
+func (k *KubernetesExecutor) checkJobNotRunning(ctx context.Context, jobID int, podName string) error {
+	operations := make([]string, 0, len(constants.AsyncCommands))
+	for _, command := range constants.AsyncCommands {
+		operations = append(operations, string(command))
+	}
+	selector := fmt.Sprintf("olake.io/job-id=%d,olake.io/operation-type in (%s)", jobID, strings.Join(operations, ","))
+
+	pods, err := k.client.CoreV1().Pods(k.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list pods of job %d: %s", jobID, err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Name == podName || pod.Labels[batchv1.JobNameLabel] == podName || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		return fmt.Errorf("%w: job %d has pod %s of workflow %s in phase %s", constants.ErrJobAlreadyRunning, jobID, pod.Name, pod.Annotations["olake.io/workflow-id"], pod.Status.Phase)
+	}
+	return nil
+}
+
 func (k *KubernetesExecutor) createPod(ctx context.Context, podSpec *corev1.Pod) (*corev1.Pod, error) {
 	log := logger.Log(ctx)
 	result, err := k.client.CoreV1().Pods(k.namespace).Create(ctx, podSpec, metav1.CreateOptions{})
 	if err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			log.Error("failed to create pod", "podName", podSpec.Name, "error", err)
+			// the priority admission plugin rejects pods naming a missing class, which retries can't fix
+			if class := podSpec.Spec.PriorityClassName; class != "" && apierrors.IsForbidden(err) && strings.Contains(err.Error(), "PriorityClass") {
+				return nil, fmt.Errorf("%w: priority class %q of pod %s does not exist: %s", constants.ErrExecutionFailed, class, podSpec.Name, err)
+			}
 			return nil, fmt.Errorf("failed to create pod: %s", err)
 		}
 
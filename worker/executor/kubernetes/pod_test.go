@@ -0,0 +1,475 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// profileExecutor builds an executor whose watcher holds the given job profiles, 0 being the default
+func profileExecutor(config KubernetesConfig, profiles map[int]JobSchedulingConfig) *KubernetesExecutor {
+	return &KubernetesExecutor{
+		namespace:     "olake",
+		config:        &config,
+		configWatcher: &ConfigMapWatcher{jobProfiles: profiles},
+	}
+}
+
+func TestCreatePodSpecTolerations(t *testing.T) {
+	dedicated := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "olake", Effect: corev1.TaintEffectNoSchedule}}
+	spot := []corev1.Toleration{{Key: "spot", Operator: corev1.TolerationOpExists}}
+
+	tests := []struct {
+		name     string
+		profiles map[int]JobSchedulingConfig
+		req      *types.ExecutionRequest
+		want     []corev1.Toleration
+	}{
+		{
+			name:     "job profile",
+			profiles: map[int]JobSchedulingConfig{0: {Tolerations: spot}, 7: {Tolerations: dedicated}},
+			req:      &types.ExecutionRequest{JobID: 7, Command: types.Sync},
+			want:     dedicated,
+		},
+		{
+			name:     "unmapped job uses the default profile",
+			profiles: map[int]JobSchedulingConfig{0: {Tolerations: spot}, 7: {Tolerations: dedicated}},
+			req:      &types.ExecutionRequest{JobID: 8, Command: types.Sync},
+			want:     spot,
+		},
+		{
+			name:     "discover uses the default profile",
+			profiles: map[int]JobSchedulingConfig{0: {Tolerations: spot}, 7: {Tolerations: dedicated}},
+			req:      &types.ExecutionRequest{JobID: 7, Command: types.Discover},
+			want:     spot,
+		},
+		{
+			name:     "job profile without tolerations",
+			profiles: map[int]JobSchedulingConfig{0: {Tolerations: spot}, 7: {}},
+			req:      &types.ExecutionRequest{JobID: 7, Command: types.Sync},
+			want:     []corev1.Toleration{},
+		},
+		{
+			name: "no profiles",
+			req:  &types.ExecutionRequest{JobID: 7, Command: types.Sync},
+			want: []corev1.Toleration{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.WorkflowID = "sync-7-abc"
+			k := profileExecutor(KubernetesConfig{PVCName: "olake-jobs"}, tt.profiles)
+
+			pod := k.CreatePodSpec(tt.req, "/data/olake-jobs/sync-7-abc", "olakego/source-postgres:latest")
+
+			require.Equal(t, tt.want, pod.Spec.Tolerations)
+		})
+	}
+}
+
+func jobPodFixture(name, jobID, operation string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "olake",
+			Labels: map[string]string{
+				"olake.io/job-id":         jobID,
+				"olake.io/operation-type": operation,
+			},
+			Annotations: map[string]string{"olake.io/workflow-id": name},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestCheckJobNotRunning(t *testing.T) {
+	terminating := jobPodFixture("sync-terminating", "7", "sync", corev1.PodRunning)
+	terminating.DeletionTimestamp = &metav1.Time{}
+	ownJobPod := jobPodFixture("sync-own-abcde", "7", "sync", corev1.PodRunning)
+	ownJobPod.Labels[batchv1.JobNameLabel] = "sync-own"
+
+	tests := []struct {
+		name    string
+		pods    []*corev1.Pod
+		wantErr bool
+	}{
+		{
+			name:    "running sync of the same job",
+			pods:    []*corev1.Pod{jobPodFixture("sync-other", "7", "sync", corev1.PodRunning)},
+			wantErr: true,
+		},
+		{
+			name:    "pending clear destination of the same job",
+			pods:    []*corev1.Pod{jobPodFixture("clear-other", "7", "clear-destination", corev1.PodPending)},
+			wantErr: true,
+		},
+		{
+			name: "own pod",
+			pods: []*corev1.Pod{jobPodFixture("sync-own", "7", "sync", corev1.PodRunning), ownJobPod},
+		},
+		{
+			name: "finished pods",
+			pods: []*corev1.Pod{
+				jobPodFixture("sync-done", "7", "sync", corev1.PodSucceeded),
+				jobPodFixture("sync-failed", "7", "sync", corev1.PodFailed),
+			},
+		},
+		{
+			name: "terminating pod",
+			pods: []*corev1.Pod{terminating},
+		},
+		{
+			name: "other job",
+			pods: []*corev1.Pod{jobPodFixture("sync-other", "8", "sync", corev1.PodRunning)},
+		},
+		{
+			name: "non async operation",
+			pods: []*corev1.Pod{jobPodFixture("check-other", "7", "check", corev1.PodRunning)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, pod := range tt.pods {
+				_, err := client.CoreV1().Pods("olake").Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			k := &KubernetesExecutor{client: client, namespace: "olake"}
+
+			err := k.checkJobNotRunning(context.Background(), 7, "sync-own")
+			if tt.wantErr {
+				require.ErrorIs(t, err, constants.ErrJobAlreadyRunning)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCreatePodSpecAffinity(t *testing.T) {
+	profileAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"etl"}}},
+		}}},
+	}}
+	// unmapped syncs keep off the nodes reserved for mapped jobs
+	reservedAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "pool", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"reserved"}}},
+		}}},
+	}}
+
+	tests := []struct {
+		name     string
+		profiles map[int]JobSchedulingConfig
+		mapping  map[int]map[string]string
+		req      *types.ExecutionRequest
+		want     *corev1.Affinity
+	}{
+		{name: "job profile", profiles: map[int]JobSchedulingConfig{7: {Affinity: profileAffinity}}, req: &types.ExecutionRequest{JobID: 7, Command: types.Sync}, want: profileAffinity},
+		{name: "default profile", profiles: map[int]JobSchedulingConfig{0: {Affinity: profileAffinity}}, req: &types.ExecutionRequest{JobID: 8, Command: types.Sync}, want: profileAffinity},
+		{name: "profile without affinity", profiles: map[int]JobSchedulingConfig{7: {}}, req: &types.ExecutionRequest{JobID: 7, Command: types.Sync}},
+		{name: "unmapped sync", mapping: map[int]map[string]string{7: {"pool": "reserved"}}, req: &types.ExecutionRequest{JobID: 8, Command: types.Sync}, want: reservedAffinity},
+		{name: "mapped sync", mapping: map[int]map[string]string{7: {"pool": "reserved"}}, req: &types.ExecutionRequest{JobID: 7, Command: types.Sync}},
+		{name: "unmapped discover", mapping: map[int]map[string]string{7: {"pool": "reserved"}}, req: &types.ExecutionRequest{JobID: 8, Command: types.Discover}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.WorkflowID = "sync-7-abc"
+			k := profileExecutor(KubernetesConfig{}, tt.profiles)
+			k.configWatcher.jobMapping = tt.mapping
+
+			pod := k.CreatePodSpec(tt.req, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			require.Equal(t, tt.want, pod.Spec.Affinity)
+		})
+	}
+}
+
+func TestIsCancellationExit(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvSignalExitAsCancel, nil) })
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	deleting := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{}}}
+
+	tests := []struct {
+		name     string
+		disabled bool
+		ctx      context.Context
+		pod      *corev1.Pod
+		term     corev1.ContainerStateTerminated
+		want     bool
+	}{
+		{name: "sigterm while deleting", ctx: context.Background(), pod: deleting, term: corev1.ContainerStateTerminated{ExitCode: 143}, want: true},
+		{name: "sigkill after cancellation", ctx: cancelled, pod: &corev1.Pod{}, term: corev1.ContainerStateTerminated{ExitCode: 137, Reason: "Error"}, want: true},
+		{name: "oom kill while deleting", ctx: context.Background(), pod: deleting, term: corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+		{name: "sigterm of a running pod", ctx: context.Background(), pod: &corev1.Pod{}, term: corev1.ContainerStateTerminated{ExitCode: 143}},
+		{name: "connector error while deleting", ctx: context.Background(), pod: deleting, term: corev1.ContainerStateTerminated{ExitCode: 1}},
+		{name: "disabled", disabled: true, ctx: context.Background(), pod: deleting, term: corev1.ContainerStateTerminated{ExitCode: 143}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvSignalExitAsCancel, !tt.disabled)
+			require.Equal(t, tt.want, isCancellationExit(tt.ctx, tt.pod, &tt.term))
+		})
+	}
+}
+
+func TestCreatePodSpecTriggerEnv(t *testing.T) {
+	viper.Set(constants.EnvConnectorTriggerEnv, true)
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorTriggerEnv, nil) })
+	k := profileExecutor(KubernetesConfig{}, nil)
+
+	req := &types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, Trigger: &types.TriggerContext{Type: types.TriggerScheduled, ScheduleID: "schedule-sync-7"}}
+	pod := k.CreatePodSpec(req, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+	env := pod.Spec.Containers[0].Env
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "OLAKE_TRIGGER_SCHEDULE_ID", Value: "schedule-sync-7"},
+		{Name: "OLAKE_TRIGGER_TYPE", Value: types.TriggerScheduled},
+	}, env[len(env)-2:])
+}
+
+// pullingPod is a pending connector pod whose image can't be pulled
+func pullingPod(reason string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sync-7-abc", Namespace: "olake"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "connector", Image: "olakego/source-postgres:missing"}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "connector",
+				Image: "olakego/source-postgres:missing",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+			}},
+		},
+	}
+}
+
+func TestImagePullFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		wantImage  string
+		wantReason string
+	}{
+		{name: "container backing off", pod: pullingPod("ImagePullBackOff"), wantImage: "olakego/source-postgres:missing", wantReason: "ImagePullBackOff"},
+		{name: "container pull error", pod: pullingPod("ErrImagePull"), wantImage: "olakego/source-postgres:missing", wantReason: "ErrImagePull"},
+		{name: "init container", pod: &corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{{
+			Image: "busybox:missing", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+		}}}}, wantImage: "busybox:missing", wantReason: "ErrImagePull"},
+		{name: "pod reason", pod: &corev1.Pod{
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{{Image: "olakego/source-postgres:missing"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "ImagePullBackOff"},
+		}, wantImage: "olakego/source-postgres:missing", wantReason: "ImagePullBackOff"},
+		{name: "creating", pod: pullingPod("ContainerCreating")},
+		{name: "running", pod: &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, reason, failing := imagePullFailure(tt.pod)
+			require.Equal(t, tt.wantReason != "", failing)
+			require.Equal(t, tt.wantImage, image)
+			require.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestRecordPullFailure(t *testing.T) {
+	viper.Set(constants.EnvImagePullFailureWindow, time.Minute)
+	t.Cleanup(func() { viper.Set(constants.EnvImagePullFailureWindow, nil) })
+
+	now := time.Now()
+	failures := []time.Time{now.Add(-2 * time.Minute), now.Add(-30 * time.Second)}
+	// failures outside the window are forgotten
+	require.Equal(t, []time.Time{now.Add(-30 * time.Second), now}, recordPullFailure(failures, now))
+
+	viper.Set(constants.EnvImagePullFailureWindow, 0)
+	require.Len(t, recordPullFailure([]time.Time{now.Add(-time.Hour)}, now), 2)
+}
+
+func TestWaitForPodCompletionImagePull(t *testing.T) {
+	viper.Set(constants.EnvHeartbeatInterval, time.Millisecond)
+	viper.Set(constants.EnvSyncHeartbeatTimeout, 30*time.Second)
+	viper.Set(constants.EnvImagePullFailureWindow, time.Minute)
+	t.Cleanup(func() {
+		viper.Set(constants.EnvHeartbeatInterval, nil)
+		viper.Set(constants.EnvSyncHeartbeatTimeout, nil)
+		viper.Set(constants.EnvImagePullFailureWindow, nil)
+		viper.Set(constants.EnvImagePullMaxFailures, nil)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		viper.Set(constants.EnvImagePullMaxFailures, 3)
+		client := fake.NewSimpleClientset(pullingPod("ImagePullBackOff"))
+		k := &KubernetesExecutor{client: client, namespace: "olake"}
+		var checks int
+
+		err := k.waitForPodCompletion(context.Background(), "sync-7-abc", time.Minute, func(context.Context, ...interface{}) { checks++ })
+		require.ErrorIs(t, err, constants.ErrExecutionFailed)
+		require.ErrorContains(t, err, "image olakego/source-postgres:missing not found or not pullable (ImagePullBackOff after 3 attempts)")
+		require.Equal(t, 3, checks)
+
+		// the stuck pod is deleted
+		_, err = client.CoreV1().Pods("olake").Get(context.Background(), "sync-7-abc", metav1.GetOptions{})
+		require.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		viper.Set(constants.EnvImagePullMaxFailures, 0)
+		k := &KubernetesExecutor{client: fake.NewSimpleClientset(pullingPod("ErrImagePull")), namespace: "olake"}
+
+		err := k.waitForPodCompletion(context.Background(), "sync-7-abc", 50*time.Millisecond, nil)
+		require.EqualError(t, err, "pod timed out after 50ms")
+	})
+}
+
+func TestCreatePodSpecPriorityClass(t *testing.T) {
+	profiles := map[int]JobSchedulingConfig{
+		0: {PriorityClassName: "olake-default"},
+		7: {PriorityClassName: "olake-critical"},
+	}
+	operations := map[types.Command]string{types.Check: "olake-interactive", types.Sync: "olake-batch"}
+
+	tests := []struct {
+		name      string
+		jobID     int
+		command   types.Command
+		profiles  map[int]JobSchedulingConfig
+		operation map[types.Command]string
+		want      string
+	}{
+		{name: "job profile", jobID: 7, command: types.Sync, profiles: profiles, operation: operations, want: "olake-critical"},
+		{name: "operation class", jobID: 9, command: types.Sync, profiles: profiles, operation: operations, want: "olake-batch"},
+		// job profiles only apply to syncs and clear-destination
+		{name: "operation class for check", jobID: 7, command: types.Check, profiles: profiles, operation: operations, want: "olake-interactive"},
+		{name: "default profile", jobID: 7, command: types.Discover, profiles: profiles, operation: operations, want: "olake-default"},
+		{name: "unset", jobID: 7, command: types.Discover},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := profileExecutor(KubernetesConfig{PriorityClasses: tt.operation}, tt.profiles)
+			pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: tt.jobID, WorkflowID: "run-7-abc", Command: tt.command}, "/data/run-7-abc", "olakego/source-postgres:latest")
+			require.Equal(t, tt.want, pod.Spec.PriorityClassName)
+		})
+	}
+}
+
+func TestCreatePodMissingPriorityClass(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("pods"), "sync-7-abc", errors.New("no PriorityClass with name olake-batch was found"))
+	})
+	k := &KubernetesExecutor{client: client, namespace: "olake"}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sync-7-abc"}, Spec: corev1.PodSpec{PriorityClassName: "olake-batch"}}
+	_, err := k.createPod(context.Background(), pod)
+	require.ErrorIs(t, err, constants.ErrExecutionFailed)
+	require.ErrorContains(t, err, `priority class "olake-batch" of pod sync-7-abc does not exist`)
+
+	// other rejections are left to the activity's retries
+	pod.Spec.PriorityClassName = ""
+	_, err = k.createPod(context.Background(), pod)
+	require.NotErrorIs(t, err, constants.ErrExecutionFailed)
+}
+
+// sidecarPod is a running pod whose connector exited with the exit code while a mesh sidecar keeps running
+func sidecarPod(exitCode int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sync-7-abc", Namespace: "olake"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "connector", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: "Completed"}}},
+				{Name: "istio-proxy", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+}
+
+func TestConnectorExitedInRunningPod(t *testing.T) {
+	require.NotNil(t, connectorExitedInRunningPod(sidecarPod(0)))
+
+	// a connector still running, or alone in the pod, leaves the pod to finish on its own
+	running := sidecarPod(0)
+	running.Status.ContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	require.Nil(t, connectorExitedInRunningPod(running))
+	alone := sidecarPod(0)
+	alone.Status.ContainerStatuses = alone.Status.ContainerStatuses[:1]
+	require.Nil(t, connectorExitedInRunningPod(alone))
+	succeeded := sidecarPod(0)
+	succeeded.Status.Phase = corev1.PodSucceeded
+	require.Nil(t, connectorExitedInRunningPod(succeeded))
+	require.Nil(t, connectorExitedInRunningPod(nil))
+}
+
+func TestWaitForPodCompletionMeshSidecar(t *testing.T) {
+	viper.Set(constants.EnvHeartbeatInterval, time.Millisecond)
+	viper.Set(constants.EnvSyncHeartbeatTimeout, 30*time.Second)
+	t.Cleanup(func() {
+		viper.Set(constants.EnvHeartbeatInterval, nil)
+		viper.Set(constants.EnvSyncHeartbeatTimeout, nil)
+	})
+
+	k := &KubernetesExecutor{client: fake.NewSimpleClientset(sidecarPod(0)), namespace: "olake"}
+	require.NoError(t, k.waitForPodCompletion(context.Background(), "sync-7-abc", time.Minute, nil))
+
+	k = &KubernetesExecutor{client: fake.NewSimpleClientset(sidecarPod(2)), namespace: "olake"}
+	err := k.waitForPodCompletion(context.Background(), "sync-7-abc", time.Minute, nil)
+	var exitErr *constants.ConnectorExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 2, exitErr.ExitCode)
+}
+
+func TestPodFailure(t *testing.T) {
+	// failedPod is a failed pod whose sidecar is reported before the connector
+	failedPod := func(connector *corev1.ContainerStateTerminated) *corev1.Pod {
+		statuses := []corev1.ContainerStatus{{Name: "istio-proxy", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}}}}
+		if connector != nil {
+			statuses = append(statuses, corev1.ContainerStatus{Name: "connector", State: corev1.ContainerState{Terminated: connector}})
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "sync-7-abc"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", ContainerStatuses: statuses},
+		}
+	}
+
+	t.Run("connector completed", func(t *testing.T) {
+		require.NoError(t, podFailure(context.Background(), failedPod(&corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"})))
+	})
+
+	t.Run("connector failed", func(t *testing.T) {
+		err := podFailure(context.Background(), failedPod(&corev1.ContainerStateTerminated{ExitCode: 3, Reason: "Error"}))
+		var exitErr *constants.ConnectorExitError
+		require.ErrorAs(t, err, &exitErr)
+		// the connector's exit is reported, not the sidecar's
+		require.Equal(t, 3, exitErr.ExitCode)
+	})
+
+	t.Run("no connector status", func(t *testing.T) {
+		err := podFailure(context.Background(), failedPod(nil))
+		require.ErrorIs(t, err, constants.ErrExecutionFailed)
+		require.ErrorContains(t, err, "containerStatus not found; reason: Evicted")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -22,9 +23,48 @@ type JobMappingStats struct {
 
 // JobSchedulingConfig defines the scheduling constraints for a job
 type JobSchedulingConfig struct {
-	NodeSelector map[string]string   `json:"nodeSelector,omitempty"`
-	Tolerations  []corev1.Toleration `json:"tolerations,omitempty"`
-	Affinity     *corev1.Affinity    `json:"affinity,omitempty"`
+	NodeSelector map[string]string            `json:"nodeSelector,omitempty"`
+	Tolerations  []corev1.Toleration          `json:"tolerations,omitempty"`
+	Affinity     *corev1.Affinity             `json:"affinity,omitempty"`
+	Resources    *corev1.ResourceRequirements `json:"resources,omitempty"`
+	GPU          int                          `json:"gpu,omitempty"` // number of nvidia.com/gpu devices
+	// Classification tags connector pods for compliance scanners (e.g. "pii", "confidential")
+	Classification string `json:"classification,omitempty"`
+	// Wrapper runs the connector under a profiler or tracer without rebuilding the image
+	Wrapper *ConnectorWrapper `json:"wrapper,omitempty"`
+	// LogRouting annotations tag connector pods for log collectors (e.g. a Fluent Bit or Vector index)
+	LogRouting map[string]string `json:"logRouting,omitempty"`
+	// HighAvailability keeps the job's pods off nodes running other high-availability jobs and
+	// spreads them across zones
+	HighAvailability bool `json:"highAvailability,omitempty"`
+	// PriorityClassName is the existing PriorityClass of the job's connector pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// PodLabels and PodAnnotations are added to connector pods (e.g. cost-allocation labels or
+	// Datadog tags); reserved olake.io keys are dropped
+	PodLabels      map[string]string `json:"podLabels,omitempty"`
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// LogTail streams a connector log file from the job volume to a sidecar's stdout
+	LogTail *ConnectorLogTail `json:"logTail,omitempty"`
+	// Mounts adds Secrets or ConfigMaps as read-only files under /mnt (e.g. a CA bundle at /mnt/certs)
+	Mounts []ConnectorMount `json:"mounts,omitempty"`
+	// Env and EnvFrom set connector environment variables (e.g. JAVA_OPTS, AWS_REGION), taking
+	// precedence over the env propagated from the worker
+	Env     map[string]string      `json:"env,omitempty"`
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// ExtraArgs are appended to the connector args (e.g. ["--threads", "8"]); flags pointing at
+	// the job's config and state files are reserved
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+	// FSGroup has Kubernetes give the job volume to the group a non-root connector image runs
+	// as; both fields override the ones in POD_SECURITY_CONTEXT
+	FSGroup             *int64                         `json:"fsGroup,omitempty"`
+	FSGroupChangePolicy *corev1.PodFSGroupChangePolicy `json:"fsGroupChangePolicy,omitempty"`
+}
+
+// ConnectorWrapper replaces the connector image entrypoint. Command must end with the
+// connector binary (e.g. ["strace", "-f", "-o", "/mnt/profiling/trace", "<connector-entrypoint>"]);
+// the connector arguments are passed after it unchanged.
+type ConnectorWrapper struct {
+	Command []string `json:"command"`
 }
 
 func validateLabelPair(jobID int, key, value string, stats *JobMappingStats) error {
@@ -158,6 +198,105 @@ func LoadJobProfiles(profiles string) map[int]JobSchedulingConfig {
 		return map[int]JobSchedulingConfig{}
 	}
 
+	for jobID, profile := range result {
+		if profile.GPU < 0 {
+			logger.Warnf("JobID %d: invalid gpu count %d, must be a positive integer. ignoring gpu request", jobID, profile.GPU)
+			profile.GPU = 0
+			result[jobID] = profile
+		}
+		if profile.Wrapper != nil && len(profile.Wrapper.Command) == 0 {
+			logger.Warnf("JobID %d: wrapper has no command. ignoring wrapper", jobID)
+			profile.Wrapper = nil
+			result[jobID] = profile
+		}
+		for key, value := range profile.PodLabels {
+			if errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...); len(errs) > 0 {
+				logger.Warnf("JobID %d: invalid pod label '%s=%s': %s. ignoring label", jobID, key, value, errs)
+				delete(profile.PodLabels, key)
+			} else if isOlakeAnnotation(key) {
+				logger.Warnf("JobID %d: pod label '%s' uses a reserved olake.io prefix. ignoring label", jobID, key)
+				delete(profile.PodLabels, key)
+			}
+		}
+		for key := range profile.PodAnnotations {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				logger.Warnf("JobID %d: invalid pod annotation '%s': %s. ignoring annotation", jobID, key, errs)
+				delete(profile.PodAnnotations, key)
+			} else if isOlakeAnnotation(key) {
+				logger.Warnf("JobID %d: pod annotation '%s' uses a reserved olake.io prefix. ignoring annotation", jobID, key)
+				delete(profile.PodAnnotations, key)
+			}
+		}
+		for key := range profile.LogRouting {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				logger.Warnf("JobID %d: invalid log routing annotation '%s': %s. ignoring annotation", jobID, key, errs)
+				delete(profile.LogRouting, key)
+			} else if isOlakeAnnotation(key) {
+				logger.Warnf("JobID %d: log routing annotation '%s' uses a reserved olake.io prefix. ignoring annotation", jobID, key)
+				delete(profile.LogRouting, key)
+			}
+		}
+		if len(profile.Mounts) > 0 {
+			var mounts []ConnectorMount
+			mountPaths := make(map[string]bool)
+			for _, mount := range profile.Mounts {
+				mountPath, err := validateConnectorMount(mount)
+				if err == nil && mountPaths[mountPath] {
+					err = fmt.Errorf("mount path '%s' is used twice", mountPath)
+				}
+				if err != nil {
+					logger.Warnf("JobID %d: invalid mount: %s. ignoring mount", jobID, err)
+					continue
+				}
+				mountPaths[mountPath] = true
+				mount.MountPath = mountPath
+				mounts = append(mounts, mount)
+			}
+			profile.Mounts = mounts
+			result[jobID] = profile
+		}
+		for name := range profile.Env {
+			if errs := validation.IsEnvVarName(name); len(errs) > 0 || utils.IsReservedConnectorEnv(name) {
+				logger.Warnf("JobID %d: invalid or reserved env variable '%s'. ignoring variable", jobID, name)
+				delete(profile.Env, name)
+			}
+		}
+		if len(profile.EnvFrom) > 0 {
+			var sources []corev1.EnvFromSource
+			for _, source := range profile.EnvFrom {
+				if err := validateEnvFromSource(source); err != nil {
+					logger.Warnf("JobID %d: invalid envFrom: %s. ignoring envFrom", jobID, err)
+					continue
+				}
+				sources = append(sources, source)
+			}
+			profile.EnvFrom = sources
+			result[jobID] = profile
+		}
+		if err := utils.ValidateExtraArgs(profile.ExtraArgs); err != nil {
+			logger.Warnf("JobID %d: invalid extraArgs: %s. ignoring extraArgs", jobID, err)
+			profile.ExtraArgs = nil
+			result[jobID] = profile
+		}
+		if profile.FSGroup != nil && *profile.FSGroup < 0 {
+			logger.Warnf("JobID %d: invalid fsGroup %d, must not be negative. ignoring fsGroup", jobID, *profile.FSGroup)
+			profile.FSGroup = nil
+			result[jobID] = profile
+		}
+		if policy := profile.FSGroupChangePolicy; policy != nil && *policy != corev1.FSGroupChangeAlways && *policy != corev1.FSGroupChangeOnRootMismatch {
+			logger.Warnf("JobID %d: invalid fsGroupChangePolicy '%s', must be Always or OnRootMismatch. ignoring fsGroupChangePolicy", jobID, *policy)
+			profile.FSGroupChangePolicy = nil
+			result[jobID] = profile
+		}
+		if profile.Classification != "" {
+			if errs := validation.IsValidLabelValue(profile.Classification); len(errs) > 0 {
+				logger.Warnf("JobID %d: invalid classification '%s': %s. ignoring classification", jobID, profile.Classification, errs)
+				profile.Classification = ""
+				result[jobID] = profile
+			}
+		}
+	}
+
 	logger.Infof("job profiles loaded: %d entries", len(result))
 
 	if len(result) > 0 {
@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestLoadJobProfilesClassification(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"classification":"pii"},"8":{"classification":"pii & finance"}}`)
+
+	require.Equal(t, "pii", profiles[7].Classification)
+	// not a valid label value, so dropped rather than failing pod creation
+	require.Empty(t, profiles[8].Classification)
+}
+
+func TestLoadJobProfilesWrapper(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"wrapper":{"command":["/profiler/run","--"]}},"8":{"wrapper":{"command":[]}}}`)
+
+	require.Equal(t, &ConnectorWrapper{Command: []string{"/profiler/run", "--"}}, profiles[7].Wrapper)
+	require.Nil(t, profiles[8].Wrapper)
+}
+
+func TestLoadJobProfilesLogRouting(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"logRouting":{"fluentbit.io/parser":"json","vector.dev/index":"olake-pii","olake.io/job-id":"8","bad key!":"x"}}}`)
+
+	// reserved and invalid keys are dropped
+	require.Equal(t, map[string]string{"fluentbit.io/parser": "json", "vector.dev/index": "olake-pii"}, profiles[7].LogRouting)
+}
+
+func TestLoadJobProfilesExtraArgs(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"extraArgs":["--threads","8"]},"8":{"extraArgs":["--threads","8","--state","/tmp/state.json"]}}`)
+
+	require.Equal(t, []string{"--threads", "8"}, profiles[7].ExtraArgs)
+	// reserved flags drop the whole set
+	require.Nil(t, profiles[8].ExtraArgs)
+}
+
+func TestLoadJobProfilesFSGroup(t *testing.T) {
+	profiles := LoadJobProfiles(`{"7":{"fsGroup":1000,"fsGroupChangePolicy":"OnRootMismatch"},"8":{"fsGroup":-1,"fsGroupChangePolicy":"Sometimes"}}`)
+
+	require.Equal(t, ptr.To[int64](1000), profiles[7].FSGroup)
+	require.Equal(t, ptr.To(corev1.FSGroupChangeOnRootMismatch), profiles[7].FSGroupChangePolicy)
+	require.Nil(t, profiles[8].FSGroup)
+	require.Nil(t, profiles[8].FSGroupChangePolicy)
+}
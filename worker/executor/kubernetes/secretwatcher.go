@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+)
+
+// SecretKeyWatcher watches the Secret holding the encryption keys so a rotated key is used
+// for decryption and new connector pods without restarting the worker
+type SecretKeyWatcher struct {
+	clientset       kubernetes.Interface
+	informerFactory informers.SharedInformerFactory
+	namespace       string
+	secretName      string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewSecretKeyWatcher(ctx context.Context, clientset kubernetes.Interface, namespace, secretName string) *SecretKeyWatcher {
+	ctx, cancel := context.WithCancel(ctx)
+	return &SecretKeyWatcher{
+		clientset:  clientset,
+		namespace:  namespace,
+		secretName: secretName,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+func (w *SecretKeyWatcher) Start() error {
+	logger.Infof("starting encryption key watcher for secret %s/%s", w.namespace, w.secretName)
+
+	// only the key Secret is listed, so other Secrets in the namespace are never cached
+	w.informerFactory = informers.NewSharedInformerFactoryWithOptions(
+		w.clientset,
+		30*time.Second, // Resync period
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.secretName).String()
+		}),
+	)
+
+	secretInformer := w.informerFactory.Core().V1().Secrets()
+
+	_, err := secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			if secret, valid := obj.(*corev1.Secret); valid && secret.Name == w.secretName {
+				w.updateKeys(secret)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldSecret, oldValid := oldObj.(*corev1.Secret)
+			newSecret, newValid := newObj.(*corev1.Secret)
+
+			// skip resync events, see ConfigMapWatcher
+			if oldValid && newValid && oldSecret.ResourceVersion == newSecret.ResourceVersion {
+				return
+			}
+
+			if newValid && newSecret.Name == w.secretName {
+				w.updateKeys(newSecret)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if secret, valid := obj.(*corev1.Secret); valid && secret.Name == w.secretName {
+				logger.Warnf("secret %s deleted - keeping cached encryption keys", w.secretName)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add secret handler: %s", err)
+	}
+
+	w.informerFactory.Start(w.ctx.Done())
+	if !cache.WaitForCacheSync(w.ctx.Done(), secretInformer.Informer().HasSynced) {
+		return fmt.Errorf("failed to sync secret cache")
+	}
+
+	logger.Infof("encryption key watcher started")
+	return nil
+}
+
+func (w *SecretKeyWatcher) Stop() {
+	logger.Infof("stopping encryption key watcher")
+	w.cancel()
+}
+
+// updateKeys loads the OLAKE_SECRET_KEY and OLAKE_SECRET_KEY_PREVIOUS entries of the Secret.
+// A Secret without a current key is ignored rather than turning decryption off.
+func (w *SecretKeyWatcher) updateKeys(secret *corev1.Secret) {
+	current := string(secret.Data[constants.EnvSecretKey])
+	if current == "" {
+		logger.Warnf("secret %s has no %s entry - keeping cached encryption keys", w.secretName, constants.EnvSecretKey)
+		return
+	}
+
+	keys := utils.SecretKeys{
+		Current:  current,
+		Previous: utils.ParseSecretKeyList(string(secret.Data[constants.EnvSecretKeyPrevious])),
+	}
+	utils.SetSecretKeys(keys)
+	logger.Infof("loaded encryption keys from secret %s (%d previous)", w.secretName, len(keys.Previous))
+}
@@ -0,0 +1,61 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+func keySecret(name, resourceVersion string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "olake", ResourceVersion: resourceVersion},
+		Data:       map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+func TestSecretKeyWatcherRotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(
+		keySecret("olake-keys", "1", map[string]string{constants.EnvSecretKey: "key-1"}),
+		keySecret("other", "1", map[string]string{constants.EnvSecretKey: "not-ours"}),
+	)
+	t.Cleanup(func() { utils.SetSecretKeys(utils.SecretKeys{}) })
+
+	watcher := NewSecretKeyWatcher(ctx, client, "olake", "olake-keys")
+	require.NoError(t, watcher.Start())
+	t.Cleanup(watcher.Stop)
+	require.Eventually(t, func() bool { return utils.GetSecretKeys().Current == "key-1" }, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, utils.GetSecretKeys().Previous)
+
+	// the rotated key becomes current and the old one is kept for older configs
+	rotated := keySecret("olake-keys", "2", map[string]string{
+		constants.EnvSecretKey:         "key-2",
+		constants.EnvSecretKeyPrevious: "key-1",
+	})
+	_, err := client.CoreV1().Secrets("olake").Update(ctx, rotated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	want := utils.SecretKeys{Current: "key-2", Previous: []string{"key-1"}}
+	require.Eventually(t, func() bool {
+		keys := utils.GetSecretKeys()
+		return keys.Current == want.Current && len(keys.Previous) == 1 && keys.Previous[0] == want.Previous[0]
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a Secret without a current key, or another Secret, never replaces the keys
+	watcher.updateKeys(keySecret("olake-keys", "3", map[string]string{constants.EnvSecretKeyPrevious: "key-2"}))
+	_, err = client.CoreV1().Secrets("olake").Update(ctx, keySecret("other", "2", map[string]string{constants.EnvSecretKey: "still-not-ours"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Never(t, func() bool { return utils.GetSecretKeys().Current != "key-2" }, 200*time.Millisecond, 10*time.Millisecond)
+	require.Equal(t, want, utils.GetSecretKeys())
+}
@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+// Local state volume modes for SYNC_LOCAL_STATE_VOLUME
+const (
+	LocalStateVolumeMemory = "memory" // tmpfs backed emptyDir
+	LocalStateVolumeDisk   = "disk"   // node-local emptyDir (local SSD on most node pools)
+)
+
+const (
+	localStateVolumeName = "local-state"
+	localStateMountPath  = "/mnt/local-state"
+	durableStatePath     = "/mnt/config/state.json"
+	localStatePath       = localStateMountPath + "/state.json"
+
+	// localStateSyncInterval is how often the sidecar checkpoints the local state to the PVC (seconds)
+	localStateSyncInterval = 30
+	// defaultLocalStateGrace gives the sidecar time to copy the final state back when the pod is deleted
+	defaultLocalStateGrace int64 = 30
+)
+
+// LocalStateConfig places the sync state file on a pod-local volume instead of the job PVC
+type LocalStateConfig struct {
+	Volume    string             // "", "memory" or "disk"; empty keeps state on the PVC
+	SizeLimit *resource.Quantity // optional emptyDir size limit
+}
+
+func (c LocalStateConfig) Enabled() bool {
+	return c.Volume != ""
+}
+
+// parseLocalStateConfig validates the local state settings, disabling the feature on invalid input
+func parseLocalStateConfig(volume, sizeLimit string) (LocalStateConfig, error) {
+	volume = strings.ToLower(strings.TrimSpace(volume))
+	if volume == "" {
+		return LocalStateConfig{}, nil
+	}
+	if volume != LocalStateVolumeMemory && volume != LocalStateVolumeDisk {
+		return LocalStateConfig{}, fmt.Errorf("invalid %s %q: expected %s or %s", constants.EnvSyncLocalStateVolume, volume, LocalStateVolumeMemory, LocalStateVolumeDisk)
+	}
+
+	cfg := LocalStateConfig{Volume: volume}
+	if sizeLimit = strings.TrimSpace(sizeLimit); sizeLimit != "" {
+		quantity, err := resource.ParseQuantity(sizeLimit)
+		if err != nil {
+			return LocalStateConfig{}, fmt.Errorf("invalid %s %q: %s", constants.EnvSyncLocalStateSizeLimit, sizeLimit, err)
+		}
+		cfg.SizeLimit = &quantity
+	}
+	return cfg, nil
+}
+
+// applyLocalState moves the connector's state file onto a local emptyDir volume.
+// An init container seeds it from the PVC and a native sidecar copies it back periodically
+// and once more on termination, so the PVC holds the final state after the pod completes
+// or is deleted during cleanup. Native sidecars need Kubernetes 1.29+.
+func applyLocalState(pod *corev1.Pod, cfg LocalStateConfig, image string) {
+	emptyDir := &corev1.EmptyDirVolumeSource{SizeLimit: cfg.SizeLimit}
+	if cfg.Volume == LocalStateVolumeMemory {
+		emptyDir.Medium = corev1.StorageMediumMemory
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name:         localStateVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+	})
+
+	connector := &pod.Spec.Containers[0]
+	connector.Args = withLocalStateArg(connector.Args)
+	connector.VolumeMounts = append(connector.VolumeMounts, corev1.VolumeMount{
+		Name:      localStateVolumeName,
+		MountPath: localStateMountPath,
+	})
+
+	mounts := slices.Clone(connector.VolumeMounts)
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+		},
+	}
+
+	copyBack := fmt.Sprintf("[ -f %[1]s ] && cp -f %[1]s %[2]s.tmp && mv -f %[2]s.tmp %[2]s", localStatePath, durableStatePath)
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers,
+		corev1.Container{
+			Name:         "state-restore",
+			Image:        image,
+			Command:      []string{"/bin/sh", "-c", fmt.Sprintf("if [ -f %[1]s ]; then cp -f %[1]s %[2]s; fi", durableStatePath, localStatePath)},
+			VolumeMounts: mounts,
+			Resources:    resources,
+		},
+		corev1.Container{
+			Name:          "state-sync",
+			Image:         image,
+			RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways),
+			Command: []string{"/bin/sh", "-c", fmt.Sprintf(
+				"trap '%[1]s; exit 0' TERM; while true; do sleep %[2]d & wait $!; %[1]s; done",
+				copyBack, localStateSyncInterval,
+			)},
+			VolumeMounts: mounts,
+			Resources:    resources,
+		},
+	)
+
+	// the sidecar needs its grace window to copy the final state back
+	if pod.Spec.TerminationGracePeriodSeconds == nil {
+		pod.Spec.TerminationGracePeriodSeconds = ptr.To(defaultLocalStateGrace)
+	}
+}
+
+// withLocalStateArg points the connector's --state flag at the local volume
+func withLocalStateArg(args []string) []string {
+	args = slices.Clone(args)
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == constants.StateFlag {
+			args[i+1] = localStatePath
+		}
+	}
+	return args
+}
@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func TestParseLocalStateConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		volume    string
+		sizeLimit string
+		want      LocalStateConfig
+		wantErr   string
+	}{
+		{name: "disabled"},
+		{name: "memory", volume: " Memory ", want: LocalStateConfig{Volume: LocalStateVolumeMemory}},
+		{name: "disk with size limit", volume: "disk", sizeLimit: "1Gi", want: LocalStateConfig{Volume: LocalStateVolumeDisk, SizeLimit: ptr.To(resource.MustParse("1Gi"))}},
+		{name: "size limit without volume", sizeLimit: "1Gi"},
+		{name: "unknown volume", volume: "ssd", wantErr: `invalid SYNC_LOCAL_STATE_VOLUME "ssd"`},
+		{name: "bad size limit", volume: "disk", sizeLimit: "lots", wantErr: `invalid SYNC_LOCAL_STATE_SIZE_LIMIT "lots"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLocalStateConfig(tt.volume, tt.sizeLimit)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want.Volume, got.Volume)
+			if tt.want.SizeLimit == nil {
+				require.Nil(t, got.SizeLimit)
+				return
+			}
+			require.Equal(t, tt.want.SizeLimit.String(), got.SizeLimit.String())
+		})
+	}
+}
+
+func TestCreatePodSpecLocalState(t *testing.T) {
+	stateArgs := []string{"sync", "--config", "/mnt/config/config.json", "--state", "/mnt/config/state.json"}
+
+	tests := []struct {
+		name       string
+		config     KubernetesConfig
+		wantMedium corev1.StorageMedium
+		wantGrace  *int64
+	}{
+		{name: "memory volume", config: KubernetesConfig{LocalState: LocalStateConfig{Volume: LocalStateVolumeMemory}}, wantMedium: corev1.StorageMediumMemory, wantGrace: ptr.To(defaultLocalStateGrace)},
+		{name: "disk volume keeps the configured grace", config: KubernetesConfig{TerminationGrace: 90, LocalState: LocalStateConfig{Volume: LocalStateVolumeDisk}}, wantGrace: ptr.To(int64(90))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := profileExecutor(tt.config, nil)
+
+			pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, Args: stateArgs}, "/data/sync-7-abc", "olakego/source-postgres:latest")
+
+			connector := pod.Spec.Containers[0]
+			require.Equal(t, []string{"sync", "--config", "/mnt/config/config.json", "--state", localStatePath}, connector.Args)
+			require.Contains(t, connector.VolumeMounts, corev1.VolumeMount{Name: localStateVolumeName, MountPath: localStateMountPath})
+
+			volume := pod.Spec.Volumes[len(pod.Spec.Volumes)-1]
+			require.Equal(t, localStateVolumeName, volume.Name)
+			require.Equal(t, tt.wantMedium, volume.EmptyDir.Medium)
+
+			// the state is seeded from the PVC and copied back by a native sidecar
+			require.Len(t, pod.Spec.InitContainers, 2)
+			require.Equal(t, "state-restore", pod.Spec.InitContainers[0].Name)
+			require.Nil(t, pod.Spec.InitContainers[0].RestartPolicy)
+			require.Equal(t, "state-sync", pod.Spec.InitContainers[1].Name)
+			require.Equal(t, ptr.To(corev1.ContainerRestartPolicyAlways), pod.Spec.InitContainers[1].RestartPolicy)
+			require.Equal(t, tt.wantGrace, pod.Spec.TerminationGracePeriodSeconds)
+		})
+	}
+
+	// discover and check pods keep their files on the PVC
+	k := profileExecutor(KubernetesConfig{LocalState: LocalStateConfig{Volume: LocalStateVolumeMemory}}, nil)
+	pod := k.CreatePodSpec(&types.ExecutionRequest{JobID: 7, WorkflowID: "discover-7-abc", Command: types.Discover, Args: stateArgs}, "/data/discover-7-abc", "olakego/source-postgres:latest")
+	require.Equal(t, stateArgs, pod.Spec.Containers[0].Args)
+	require.Empty(t, pod.Spec.InitContainers)
+	require.Nil(t, pod.Spec.TerminationGracePeriodSeconds)
+}
@@ -6,17 +6,20 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/constants/config"
 	"github.com/datazip-inc/olake-helm/worker/database"
 	"github.com/datazip-inc/olake-helm/worker/executor"
 	_ "github.com/datazip-inc/olake-helm/worker/executor/docker"
-	_ "github.com/datazip-inc/olake-helm/worker/executor/kubernetes"
+	"github.com/datazip-inc/olake-helm/worker/executor/kubernetes"
 	"github.com/datazip-inc/olake-helm/worker/temporal"
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/telemetry"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/spf13/viper"
 )
 
@@ -36,6 +39,20 @@ func main() {
 
 	logger.Infof("starting OLake worker")
 	logger.Infof("executor environment: %s", utils.GetExecutorEnvironment())
+	utils.LogProxySettings()
+	telemetry.LogStatus()
+
+	shutdownTracing, err := tracing.Init()
+	if err != nil {
+		logger.Fatalf("failed to initialize tracing: %s", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warnf("failed to flush traces: %s", err)
+		}
+	}()
 
 	// Initialize database
 	db, err := database.Init(ctx)
@@ -52,6 +69,20 @@ func main() {
 	}
 	defer exec.Close()
 
+	// start health server for kubernetes environment before connecting to Temporal, so the
+	// pod reports not ready rather than crash looping while Temporal is still starting
+	// TODO: add health check for docker environment as well
+	var healthServer *temporal.Server
+	if utils.GetExecutorEnvironment() == string(types.Kubernetes) {
+		healthServer = temporal.NewHealthServer(db)
+		go func() {
+			err := healthServer.Start()
+			if err != nil {
+				logger.Fatalf("failed to start Kubernetes health server: %s", err)
+			}
+		}()
+	}
+
 	tClient, err := temporal.NewClient()
 	if err != nil {
 		logger.Fatalf("failed to create Temporal client: %s", err)
@@ -69,16 +100,8 @@ func main() {
 		logger.Fatalf("failed to create Temporal worker: %s", err)
 	}
 
-	// start health server for kubernetes environment
-	// TODO: add health check for docker environment as well
-	if utils.GetExecutorEnvironment() == string(types.Kubernetes) {
-		healthServer := temporal.NewHealthServer(worker, db)
-		go func() {
-			err := healthServer.Start()
-			if err != nil {
-				logger.Fatalf("failed to start Kubernetes health server: %s", err)
-			}
-		}()
+	if healthServer != nil {
+		healthServer.SetWorker(worker)
 	}
 
 	// Start the Temporal worker in a separate goroutine so the main goroutine
@@ -91,8 +114,19 @@ func main() {
 		}
 	}()
 
-	// Initialize log cleaner
-	utils.InitLogCleaner(utils.GetConfigDir(), viper.GetInt(constants.EnvLogRetentionPeriod))
+	// Initialize log cleaner, on the elected replica only when leader election is enabled
+	logCleaner := func(ctx context.Context) {
+		utils.InitLogCleaner(ctx, utils.GetConfigDir(), viper.GetInt(constants.EnvLogRetentionPeriod))
+	}
+	if utils.GetExecutorEnvironment() == string(types.Kubernetes) && viper.GetBool(constants.EnvLeaderElectionEnabled) {
+		go func() {
+			if err := kubernetes.RunLeaderTasks(ctx, logCleaner); err != nil {
+				logger.Fatalf("failed to run leader election: %s", err)
+			}
+		}()
+	} else {
+		logCleaner(ctx)
+	}
 
 	// setup signal handling for graceful shutdown
 	signalChan := make(chan os.Signal, 1)
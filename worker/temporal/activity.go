@@ -4,6 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/database"
@@ -13,14 +18,33 @@ import (
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 	"github.com/datazip-inc/olake-helm/worker/utils/notifications"
 	"github.com/datazip-inc/olake-helm/worker/utils/telemetry"
+	"github.com/spf13/viper"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/temporal"
 )
 
+// activityDB is the part of database.DB the activities read job and project data through
+type activityDB interface {
+	GetJobData(ctx context.Context, jobId int) (types.JobData, error)
+	GetJobStateByRef(ctx context.Context, jobId int, ref string) (string, error)
+	CheckpointJobState(ctx context.Context, jobId int, state string, runStartedAt time.Time) error
+	GetProjectSettingsByProjectID(ctx context.Context, projectID string) (*types.ProjectSettings, error)
+	GetPagerDutyRoutingKey(ctx context.Context, projectID string) (string, error)
+	GetPostSyncHookURL(ctx context.Context, projectID string) (string, error)
+}
+
+// activityExecutor is the part of executor.AbstractExecutor the activities run connectors through
+type activityExecutor interface {
+	Execute(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error)
+	CleanupAndPersistState(ctx context.Context, req *types.ExecutionRequest) error
+	SampleResources(ctx context.Context, req *types.ExecutionRequest) (*types.ResourceSample, error)
+	Cleanup(ctx context.Context, req *types.ExecutionRequest) error
+}
+
 type Activity struct {
-	executor   *executor.AbstractExecutor
-	db         *database.DB
+	executor   activityExecutor
+	db         activityDB
 	tempClient client.Client
 }
 
@@ -49,11 +73,98 @@ func (a *Activity) ExecuteActivity(ctx context.Context, req *types.ExecutionRequ
 		if err := utils.UpdateConfigForClearDestination(jobDetails, req); err != nil {
 			return nil, err
 		}
+
+		if req.DryRun && !slices.Contains(req.Args, constants.DryRunFlag) {
+			log.Info("running clear-destination as dry run", "jobID", req.JobID)
+			req.Args = append(req.Args, constants.DryRunFlag)
+		}
+	}
+
+	if err := utils.ApplyCheckTarget(req); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidCheckTarget", err)
+	}
+
+	if err := a.validateConnectorConfig(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if req.Command == types.Check {
+		return a.executeConnectionTest(ctx, req)
+	}
+
+	if req.Command == types.Discover {
+		return a.executeDiscover(ctx, req)
 	}
 
 	return a.executor.Execute(ctx, req)
 }
 
+// executeConnectionTest runs a check under TIMEOUT_ACTIVITY_TEST, which is usually far
+// shorter than the activity timeout: a source or destination that can't be reached would
+// otherwise hold the pod/container and the workflow slot until the activity times out.
+func (a *Activity) executeConnectionTest(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+	testTimeout := viper.GetDuration(constants.EnvActivityTestTimeout)
+	if testTimeout <= 0 {
+		return a.executor.Execute(ctx, req)
+	}
+
+	testCtx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+	if req.Timeout <= 0 || req.Timeout > testTimeout {
+		req.Timeout = testTimeout
+	}
+
+	result, err := a.executor.Execute(testCtx, req)
+	// the executors remove the pod/container once the context is done
+	if err != nil && ctx.Err() == nil && errors.Is(testCtx.Err(), context.DeadlineExceeded) {
+		logger.Log(ctx).Warn("connection test timed out", "sourceType", req.ConnectorType, "timeout", testTimeout, "error", err)
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("connection timed out after %s, check that the host is reachable from the worker and the credentials are correct", testTimeout), "ConnectionTimeout", err)
+	}
+	return result, err
+}
+
+// executeDiscover returns the catalog cached by an identical discover within DISCOVER_CACHE_TTL,
+// written to this workflow's output file as if the connector had run, unless the request
+// forces a refresh. Cache failures never fail the discover. Requests for a generated or
+// compressed output file always run the connector.
+func (a *Activity) executeDiscover(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+	ttl := viper.GetDuration(constants.EnvDiscoverCacheTTL)
+	if ttl <= 0 || req.OutputFile != "" || req.OutputCompression != types.OutputCompressionNone {
+		return a.executor.Execute(ctx, req)
+	}
+
+	log := logger.Log(ctx)
+	key := utils.CatalogCacheKey(req)
+	subdir, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	outputPath := filepath.Join(workdir, constants.OutputFileName)
+
+	if !req.ForceRefresh {
+		if catalog, ok := utils.ReadCachedCatalog(key, ttl); ok {
+			err := utils.WriteFile(outputPath, catalog)
+			if err == nil {
+				log.Info("using cached catalog", "sourceType", req.ConnectorType, "version", req.Version, "cacheKey", key)
+				return &types.ExecutorResponse{Response: filepath.Join(subdir, constants.OutputFileName)}, nil
+			}
+			log.Warn("failed to write cached catalog, running discover", "error", err)
+		}
+	}
+
+	result, err := a.executor.Execute(ctx, req)
+	if err != nil || result == nil || result.Status != "" {
+		return result, err
+	}
+
+	catalog, err := os.ReadFile(outputPath)
+	if err != nil {
+		log.Warn("failed to read catalog for caching", "path", outputPath, "error", err)
+		return result, nil
+	}
+	if err := utils.WriteCachedCatalog(key, catalog, ttl); err != nil {
+		log.Warn("failed to cache catalog", "error", err)
+	}
+	return result, nil
+}
+
 func (a *Activity) SyncActivity(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
 	log := logger.Log(ctx)
 	log.Info("executing sync activity", "jobID", req.JobID)
@@ -75,18 +186,36 @@ func (a *Activity) SyncActivity(ctx context.Context, req *types.ExecutionRequest
 		utils.UpdateSyncRequestForLegacy(jobDetails, req)
 	}
 
+	if err := a.resolveRunState(ctx, req, &jobDetails); err != nil {
+		return nil, err
+	}
+
 	// update the configs with latest job details
 	utils.UpdateConfigWithJobDetails(jobDetails, req)
 
 	// Remove --state flag if state is empty
-	if utils.IsStateEmpty(jobDetails.State) {
+	if req.Options.UseEmptyState || utils.IsStateEmpty(jobDetails.State) {
 		req.Args = utils.RemoveFlagFromArgs(req.Args, constants.StateFlag)
 	}
 
+	a.resolveTriggerCron(ctx, req)
+
+	if err := validateStreamsConfig(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if err := a.validateConnectorConfig(ctx, req); err != nil {
+		return nil, err
+	}
+
 	// Send telemetry event - "sync started"
 	telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventStarted)
 
+	stopCheckpointer := a.startStateCheckpointer(ctx, req)
+	stopSampler := a.startResourceSampler(ctx, req)
 	result, err := a.executor.Execute(ctx, req)
+	stopSampler()
+	stopCheckpointer()
 	if err != nil {
 		// CRITICAL: Check if error is because context was cancelled
 		if ctx.Err() != nil {
@@ -94,6 +223,22 @@ func (a *Activity) SyncActivity(ctx context.Context, req *types.ExecutionRequest
 			return nil, temporal.NewCanceledError("sync activity cancelled")
 		}
 
+		if errors.Is(err, constants.ErrExecutionCancelled) {
+			log.Info("sync pod terminated for cancellation", "jobID", req.JobID, "error", err)
+			return nil, temporal.NewCanceledError("sync activity cancelled")
+		}
+
+		var exitErr *constants.ConnectorExitError
+		if errors.As(err, &exitErr) {
+			if retryErr := a.transientSyncFailure(ctx, req, exitErr); retryErr != nil {
+				return nil, retryErr
+			}
+			telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventFailed)
+			log.Error("sync connector failed", "jobID", req.JobID, "exitCode", exitErr.ExitCode, "exitReason", exitErr.Reason)
+			return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("execution failed: %s", exitErr.Reason), "ExecutionFailed", err,
+				types.ConnectorExit{ExitCode: exitErr.ExitCode, ExitReason: exitErr.Reason})
+		}
+
 		if errors.Is(err, constants.ErrExecutionFailed) {
 			telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventFailed)
 			return nil, temporal.NewNonRetryableApplicationError("execution failed", "ExecutionFailed", err)
@@ -107,6 +252,74 @@ func (a *Activity) SyncActivity(ctx context.Context, req *types.ExecutionRequest
 	return result, nil
 }
 
+// transientSyncFailure returns a retryable error when the connector failed in a way
+// IsTransientConnectorFailure classifies as transient, such as a source briefly refusing
+// connections, and the sync has attempts left under CONNECTOR_TRANSIENT_MAX_ATTEMPTS. The failed
+// pod/container is removed so the retry starts a new one. Returns nil for failures that stay fatal.
+func (a *Activity) transientSyncFailure(ctx context.Context, req *types.ExecutionRequest, exitErr *constants.ConnectorExitError) error {
+	log := logger.Log(ctx)
+	if !utils.IsTransientConnectorFailure(exitErr.ExitCode, exitErr.Reason, exitErr.Output) {
+		return nil
+	}
+
+	// attempts driven by the workflow each run as the activity's first attempt
+	attempt := max(int(activity.GetInfo(ctx).Attempt), req.Attempt)
+	if maxAttempts := viper.GetInt(constants.EnvConnectorTransientMaxAttempts); attempt >= maxAttempts {
+		log.Warn("transient connector failure out of attempts", "jobID", req.JobID, "attempt", attempt, "maxAttempts", maxAttempts)
+		return nil
+	}
+
+	if err := a.executor.Cleanup(ctx, req); err != nil {
+		log.Warn("failed to remove failed connector before retrying", "jobID", req.JobID, "error", err)
+	}
+	log.Warn("sync connector failed with a transient error, retrying", "jobID", req.JobID, "attempt", attempt, "exitCode", exitErr.ExitCode, "exitReason", exitErr.Reason)
+	return temporal.NewApplicationErrorWithCause(fmt.Sprintf("transient failure: %s", exitErr.Reason), "TransientConnectorFailure", exitErr,
+		types.ConnectorExit{ExitCode: exitErr.ExitCode, ExitReason: exitErr.Reason})
+}
+
+// resolveRunState swaps the job's saved state for the one the run was asked to start from.
+// Only the state file of this run changes; the saved state is replaced once the run completes.
+func (a *Activity) resolveRunState(ctx context.Context, req *types.ExecutionRequest, jobDetails *types.JobData) error {
+	log := logger.Log(ctx)
+	opts := req.Options
+
+	if opts.UseEmptyState && opts.FromStateRef != "" {
+		return temporal.NewNonRetryableApplicationError("use_empty_state and from_state_ref cannot be set together", "InvalidOptions", nil)
+	}
+
+	if opts.FromStateRef != "" {
+		state, err := a.db.GetJobStateByRef(ctx, req.JobID, opts.FromStateRef)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to load state %s: %s", opts.FromStateRef, err)
+			return temporal.NewNonRetryableApplicationError(errMsg, "StateNotFound", err)
+		}
+		log.Info("starting sync from past state", "jobID", req.JobID, "ref", opts.FromStateRef)
+		jobDetails.State = state
+	}
+	if opts.UseEmptyState {
+		log.Info("starting sync from empty state for a full refresh", "jobID", req.JobID)
+	}
+
+	return nil
+}
+
+// resolveTriggerCron fills the cron expressions of the schedule that started the run, for the
+// trigger env passed to the connector. Failing to describe the schedule only omits them.
+func (a *Activity) resolveTriggerCron(ctx context.Context, req *types.ExecutionRequest) {
+	if req.Trigger == nil || req.Trigger.ScheduleID == "" || !viper.GetBool(constants.EnvConnectorTriggerEnv) {
+		return
+	}
+
+	desc, err := a.tempClient.ScheduleClient().GetHandle(ctx, req.Trigger.ScheduleID).Describe(ctx)
+	if err != nil {
+		logger.Log(ctx).Warn("failed to describe schedule for trigger context", "scheduleID", req.Trigger.ScheduleID, "error", err)
+		return
+	}
+	if desc.Schedule.Spec != nil {
+		req.Trigger.Cron = strings.Join(desc.Schedule.Spec.CronExpressions, ";")
+	}
+}
+
 func (a *Activity) PostSyncActivity(ctx context.Context, req *types.ExecutionRequest) error {
 	log := logger.Log(ctx)
 	log.Info("cleaning up sync for job", "jobID", req.JobID)
@@ -120,11 +333,41 @@ func (a *Activity) PostSyncActivity(ctx context.Context, req *types.ExecutionReq
 		utils.UpdateSyncRequestForLegacy(jobDetails, req)
 	}
 
+	// the summary is read before cleanup, which may delete the workflow directory
+	hookURL, hookPayload := a.preparePostSyncHook(ctx, req, jobDetails.JobName)
+
 	if err := a.executor.CleanupAndPersistState(ctx, req); err != nil {
+		if errors.Is(err, constants.ErrStaleState) {
+			return temporal.NewNonRetryableApplicationError(err.Error(), "StaleState", err)
+		}
 		return temporal.NewNonRetryableApplicationError(err.Error(), "cleanup failed", err)
 	}
 
-	telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventCompleted)
+	// best effort: a hook that can't be reached never fails the sync
+	if hookURL != "" {
+		if err := notifications.SendPostSyncHook(ctx, hookPayload, hookURL); err != nil {
+			log.Warn("failed to send post-sync hook", "jobID", req.JobID, "error", err)
+		} else {
+			log.Info("post-sync hook sent", "jobID", req.JobID, "streams", len(hookPayload.Streams))
+		}
+	}
+
+	// a successful run resolves any open incident raised by earlier failures
+	if routingKey := a.pagerDutyRoutingKey(ctx, req.ProjectID); routingKey != "" && (req.Status == "" || req.Status == types.SyncStatusCompleted) {
+		args := types.WebhookNotificationArgs{JobID: req.JobID, ProjectID: req.ProjectID}
+		if err := notifications.SendPagerDutyEvent(ctx, routingKey, notifications.PagerDutyResolve, "", args, jobDetails.JobName); err != nil {
+			log.Warn("failed to resolve pagerduty incident", "jobID", req.JobID, "error", err)
+		}
+	}
+
+	switch req.Status {
+	case types.SyncStatusSkipped:
+		telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventSkipped)
+	case types.SyncStatusFailed, types.SyncStatusCancelled:
+		// failures are reported by SyncActivity, cancellations are not reported
+	default:
+		telemetry.SendEvent(req.JobID, utils.GetExecutorEnvironment(), req.WorkflowID, telemetry.TelemetryEventCompleted)
+	}
 	return nil
 }
 
@@ -145,6 +388,9 @@ func (a *Activity) PostClearActivity(ctx context.Context, req *types.ExecutionRe
 	log.Info("cleaning up clear-destination for job", "jobID", req.JobID)
 
 	if err := a.executor.CleanupAndPersistState(ctx, req); err != nil {
+		if errors.Is(err, constants.ErrStaleState) {
+			return temporal.NewNonRetryableApplicationError(err.Error(), "StaleState", err)
+		}
 		return err
 	}
 
@@ -155,7 +401,7 @@ func (a *Activity) PostClearActivity(ctx context.Context, req *types.ExecutionRe
 	scheduleID := fmt.Sprintf("schedule-%s", workflowID)
 	handle := a.tempClient.ScheduleClient().GetHandle(ctx, scheduleID)
 
-	taskQueue := utils.GetTemporalTaskQueue()
+	taskQueue := utils.GetTaskQueueForConnector(req.ConnectorType)
 
 	err := handle.Update(ctx, client.ScheduleUpdateOptions{
 		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
@@ -201,26 +447,184 @@ func (a *Activity) SendWebhookNotificationActivity(ctx context.Context, req type
 	log := logger.Log(ctx)
 	log.Info("Sending webhook alert", "jobID", req.JobID, "projectID", req.ProjectID)
 
-	projectID := req.ProjectID
-	if projectID == "" {
-		// TODO: introduce a dedicated migration to backfill project_id into schedules for older jobs and remove this hardcoded fallback.
-		projectID = "123"
-		log.Info("project_id is empty, defaulting to fallback project_id", "jobID", req.JobID, "fallbackProjectID", projectID)
+	projectID := resolveProjectID(ctx, req.JobID, req.ProjectID)
+
+	jobDetails, err := a.db.GetJobData(ctx, req.JobID)
+	if err != nil {
+		log.Warn("failed to get job data for webhook notification", "jobID", req.JobID, "error", err)
+	}
+	jobName := jobDetails.JobName
+
+	// PagerDuty and email are dispatched independently so a missing or failing webhook never suppresses them
+	var channelErr error
+	channelsConfigured := false
+
+	if routingKey := a.pagerDutyRoutingKey(ctx, projectID); routingKey != "" {
+		channelsConfigured = true
+		severity := viper.GetString(constants.EnvPagerDutySeverity)
+		if err := notifications.SendPagerDutyEvent(ctx, routingKey, notifications.PagerDutyTrigger, severity, req, jobName); err != nil {
+			log.Error("failed to send pagerduty event", "jobID", req.JobID, "error", err)
+			channelErr = errors.Join(channelErr, err)
+		}
 	}
 
-	settings, err := a.db.GetProjectSettingsByProjectID(ctx, projectID)
+	if emailConfig := getEmailConfig(); emailConfig.Enabled() {
+		if err := emailConfig.Validate(); err != nil {
+			// a half-configured SMTP setup must not fail the workflow
+			log.Warn("smtp not fully configured, skipping email notification", "jobID", req.JobID, "error", err)
+		} else {
+			channelsConfigured = true
+			if err := notifications.SendEmailNotification(ctx, emailConfig, req, jobName); err != nil {
+				log.Error("failed to send email notification", "jobID", req.JobID, "error", err)
+				channelErr = errors.Join(channelErr, fmt.Errorf("failed to send email notification: %w", err))
+			}
+		}
+	}
+
+	webhookURL, err := a.projectWebhookURL(ctx, projectID)
 	if err != nil {
-		return fmt.Errorf("failed to get project settings: %w", err)
+		if channelsConfigured {
+			log.Warn("failed to get project settings, skipping webhook", "jobID", req.JobID, "error", err)
+			return channelErr
+		}
+		return err
+	}
+
+	if channelsConfigured && strings.TrimSpace(webhookURL) == "" {
+		return channelErr
 	}
 
+	if notifications.IsDiscordWebhook(webhookURL) {
+		severity := viper.GetString(constants.EnvDiscordSeverity)
+		if err := notifications.SendDiscordNotification(ctx, req, jobName, webhookURL, severity); err != nil {
+			return errors.Join(fmt.Errorf("failed to send discord notification: %w", err), channelErr)
+		}
+		return channelErr
+	}
+
+	if err := notifications.SendWebhookNotification(ctx, req, jobName, webhookURL); err != nil {
+		return errors.Join(fmt.Errorf("failed to send webhook notification: %w", err), channelErr)
+	}
+	return channelErr
+}
+
+// SendTimeoutWarningActivity tells the project webhook that a sync has crossed the timeout
+// warning threshold. Unlike failures it is not sent to PagerDuty or email.
+func (a *Activity) SendTimeoutWarningActivity(ctx context.Context, req types.TimeoutWarningArgs) error {
+	log := logger.Log(ctx)
+	log.Info("sending timeout warning", "jobID", req.JobID, "projectID", req.ProjectID, "elapsed", req.Elapsed)
+
+	projectID := resolveProjectID(ctx, req.JobID, req.ProjectID)
+
 	jobDetails, err := a.db.GetJobData(ctx, req.JobID)
 	if err != nil {
-		log.Warn("failed to get job data for webhook notification", "jobID", req.JobID, "error", err)
+		log.Warn("failed to get job data for timeout warning", "jobID", req.JobID, "error", err)
 	}
-	jobName := jobDetails.JobName
 
-	if err := notifications.SendWebhookNotification(ctx, req, jobName, settings.WebhookAlertURL); err != nil {
-		return fmt.Errorf("failed to send webhook notification: %w", err)
+	webhookURL, err := a.projectWebhookURL(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(webhookURL) == "" {
+		log.Info("webhook not configured, skipping timeout warning", "jobID", req.JobID)
+		return nil
+	}
+
+	return notifications.SendTimeoutWarningNotification(ctx, req, jobDetails.JobName, webhookURL)
+}
+
+// fallbackProjectID is the project of jobs whose schedules were created before project_id
+// was recorded in them
+// TODO: introduce a dedicated migration to backfill project_id into schedules for older jobs and remove this hardcoded fallback.
+const fallbackProjectID = "123"
+
+// resolveProjectID returns the project whose settings a job's notifications use
+func resolveProjectID(ctx context.Context, jobID int, projectID string) string {
+	if projectID != "" {
+		return projectID
+	}
+	logger.Log(ctx).Info("project_id is empty, defaulting to fallback project_id", "jobID", jobID, "fallbackProjectID", fallbackProjectID)
+	return fallbackProjectID
+}
+
+// pagerDutyRoutingKey returns the PagerDuty routing key of a project, from its settings or
+// PAGERDUTY_ROUTING_KEY. An empty key means the project has no PagerDuty alerts.
+func (a *Activity) pagerDutyRoutingKey(ctx context.Context, projectID string) string {
+	routingKey, err := a.db.GetPagerDutyRoutingKey(ctx, projectID)
+	if err != nil {
+		logger.Log(ctx).Warn("failed to get pagerduty routing key, using default", "projectID", projectID, "error", err)
+	}
+	if routingKey = strings.TrimSpace(routingKey); routingKey != "" {
+		return routingKey
+	}
+	return strings.TrimSpace(viper.GetString(constants.EnvPagerDutyRoutingKey))
+}
+
+// preparePostSyncHook returns the post-sync hook of a completed sync's project, from its
+// settings or POST_SYNC_HOOK_URL, with the summary to post. Other runs have no hook.
+func (a *Activity) preparePostSyncHook(ctx context.Context, req *types.ExecutionRequest, jobName string) (string, types.PostSyncHookPayload) {
+	log := logger.Log(ctx)
+	if req.Command != types.Sync || (req.Status != "" && req.Status != types.SyncStatusCompleted) {
+		return "", types.PostSyncHookPayload{}
+	}
+
+	hookURL, err := a.db.GetPostSyncHookURL(ctx, req.ProjectID)
+	if err != nil {
+		log.Warn("failed to get post-sync hook, using default", "projectID", req.ProjectID, "error", err)
+	}
+	if hookURL = strings.TrimSpace(hookURL); hookURL == "" {
+		hookURL = strings.TrimSpace(viper.GetString(constants.EnvPostSyncHookURL))
+	}
+	if hookURL == "" {
+		return "", types.PostSyncHookPayload{}
+	}
+
+	payload := types.PostSyncHookPayload{
+		JobID:      req.JobID,
+		JobName:    jobName,
+		ProjectID:  req.ProjectID,
+		WorkflowID: req.WorkflowID,
+		Status:     types.SyncStatusCompleted,
+		FinishedAt: time.Now().UTC(),
+	}
+	_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	if err := utils.ReadSyncSummary(workdir, &payload); err != nil {
+		log.Warn("sending post-sync hook without full summary", "jobID", req.JobID, "error", err)
+	}
+	return hookURL, payload
+}
+
+// projectWebhookURL returns the project's webhook URL, or FALLBACK_WEBHOOK_URL when the project
+// has none or its settings can't be read, so alerts aren't lost for projects without a webhook
+func (a *Activity) projectWebhookURL(ctx context.Context, projectID string) (string, error) {
+	log := logger.Log(ctx)
+	fallbackURL := strings.TrimSpace(viper.GetString(constants.EnvFallbackWebhookURL))
+
+	settings, err := a.db.GetProjectSettingsByProjectID(ctx, projectID)
+	if err != nil {
+		if fallbackURL == "" {
+			return "", fmt.Errorf("failed to get project settings: %w", err)
+		}
+		log.Warn("failed to get project settings, using fallback webhook", "projectID", projectID, "error", err)
+		return fallbackURL, nil
+	}
+
+	if strings.TrimSpace(settings.WebhookAlertURL) == "" && fallbackURL != "" {
+		log.Info("project has no webhook configured, using fallback webhook", "projectID", projectID)
+		return fallbackURL, nil
+	}
+	return settings.WebhookAlertURL, nil
+}
+
+// getEmailConfig builds the SMTP settings for failure emails from the worker environment
+func getEmailConfig() notifications.EmailConfig {
+	return notifications.EmailConfig{
+		Host:     viper.GetString(constants.EnvSMTPHost),
+		Port:     viper.GetInt(constants.EnvSMTPPort),
+		Username: viper.GetString(constants.EnvSMTPUsername),
+		Password: viper.GetString(constants.EnvSMTPPassword),
+		From:     viper.GetString(constants.EnvSMTPFrom),
+		To:       notifications.ParseEmailRecipients(viper.GetString(constants.EnvSMTPTo)),
+		TLSMode:  strings.ToLower(strings.TrimSpace(viper.GetString(constants.EnvSMTPTLSMode))),
 	}
-	return nil
 }
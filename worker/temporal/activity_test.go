@@ -0,0 +1,402 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+// fakeActivityDB answers the job and project lookups of the activities
+type fakeActivityDB struct {
+	job         types.JobData
+	settings    *types.ProjectSettings
+	settingsErr error
+	hookURL     string
+}
+
+func (f *fakeActivityDB) GetJobData(context.Context, int) (types.JobData, error) {
+	return f.job, nil
+}
+
+func (f *fakeActivityDB) GetJobStateByRef(context.Context, int, string) (string, error) {
+	return "", errors.New("not supported")
+}
+
+func (f *fakeActivityDB) CheckpointJobState(context.Context, int, string, time.Time) error {
+	return nil
+}
+
+func (f *fakeActivityDB) GetProjectSettingsByProjectID(context.Context, string) (*types.ProjectSettings, error) {
+	return f.settings, f.settingsErr
+}
+
+func (f *fakeActivityDB) GetPagerDutyRoutingKey(context.Context, string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeActivityDB) GetPostSyncHookURL(context.Context, string) (string, error) {
+	return f.hookURL, nil
+}
+
+// fakeExecutor answers every connector run with the same result
+type fakeExecutor struct {
+	result  *types.ExecutorResponse
+	err     error
+	samples []*types.ResourceSample
+	runs    []*types.ExecutionRequest
+	// hang keeps runs going until their context is done, like an unreachable source
+	hang bool
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+	f.runs = append(f.runs, req)
+	if f.hang {
+		<-ctx.Done()
+		return nil, fmt.Errorf("pod check-7-abc removed: %s", ctx.Err())
+	}
+	return f.result, f.err
+}
+
+func (f *fakeExecutor) CleanupAndPersistState(context.Context, *types.ExecutionRequest) error {
+	return nil
+}
+
+func (f *fakeExecutor) SampleResources(context.Context, *types.ExecutionRequest) (*types.ResourceSample, error) {
+	if len(f.samples) == 0 {
+		return nil, errors.New("no sample")
+	}
+	sample := f.samples[0]
+	f.samples = f.samples[1:]
+	return sample, nil
+}
+
+func (f *fakeExecutor) Cleanup(context.Context, *types.ExecutionRequest) error {
+	return nil
+}
+
+// syncRequest is a sync of job 7
+func syncRequest() *types.ExecutionRequest {
+	return &types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, ConnectorType: "postgres"}
+}
+
+// syncActivity returns activities running connectors on exec for the job "orders" with the given source config
+func syncActivity(exec *fakeExecutor, source string) *Activity {
+	return &Activity{executor: exec, db: &fakeActivityDB{job: types.JobData{JobName: "orders", Source: source}}}
+}
+
+// runSyncActivity runs the activities' SyncActivity for the request
+func runSyncActivity(t *testing.T, a *Activity, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+	viper.Set(constants.EnvTelemetryDisabled, true)
+	t.Cleanup(func() { viper.Set(constants.EnvTelemetryDisabled, nil) })
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(a.SyncActivity, activity.RegisterOptions{Name: SyncActivity})
+
+	value, err := env.ExecuteActivity(SyncActivity, req)
+	if err != nil {
+		return nil, err
+	}
+	var result *types.ExecutorResponse
+	require.NoError(t, value.Get(&result))
+	return result, nil
+}
+
+// webhookReceiver returns a webhook server and the bodies posted to it
+func webhookReceiver(t *testing.T) (string, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &bodies
+}
+
+func TestResolveProjectID(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "project-a", resolveProjectID(ctx, 7, "project-a"))
+	// schedules created before project_id was recorded
+	require.Equal(t, fallbackProjectID, resolveProjectID(ctx, 7, ""))
+}
+
+func TestSendWebhookNotificationFallback(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvFallbackWebhookURL, nil) })
+	req := types.WebhookNotificationArgs{JobID: 7, ProjectID: "project-a", ErrorMessage: "connection refused"}
+
+	tests := []struct {
+		name         string
+		projectURL   bool
+		settingsErr  error
+		fallback     bool
+		wantProject  int
+		wantFallback int
+		wantErr      string
+	}{
+		{name: "project without webhook", fallback: true, wantFallback: 1},
+		{name: "unreadable settings", settingsErr: errors.New("connection refused"), fallback: true, wantFallback: 1},
+		{name: "project webhook", projectURL: true, fallback: true, wantProject: 1},
+		{name: "no webhook anywhere", wantErr: "webhook_alert_url not configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectURL, projectBodies := webhookReceiver(t)
+			fallbackURL, fallbackBodies := webhookReceiver(t)
+
+			settings := &types.ProjectSettings{ProjectID: "project-a"}
+			if tt.projectURL {
+				settings.WebhookAlertURL = projectURL
+			}
+			viper.Set(constants.EnvFallbackWebhookURL, "")
+			if tt.fallback {
+				viper.Set(constants.EnvFallbackWebhookURL, fallbackURL)
+			}
+			a := &Activity{db: &fakeActivityDB{job: types.JobData{JobName: "orders"}, settings: settings, settingsErr: tt.settingsErr}}
+
+			err := a.SendWebhookNotificationActivity(context.Background(), req)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, *projectBodies, tt.wantProject)
+			require.Len(t, *fallbackBodies, tt.wantFallback)
+			for _, body := range append(*projectBodies, *fallbackBodies...) {
+				require.Contains(t, body, "*Job Name:* `orders`")
+			}
+		})
+	}
+}
+
+func TestSyncActivityCancelledExecution(t *testing.T) {
+	_, err := runSyncActivity(t, syncActivity(&fakeExecutor{err: fmt.Errorf("%w: pod sync-7-abc terminated (exit code: 143)", constants.ErrExecutionCancelled)}, ""), syncRequest())
+	var canceledErr *temporal.CanceledError
+	require.ErrorAs(t, err, &canceledErr)
+
+	// a connector failing on its own stays a failure
+	_, err = runSyncActivity(t, syncActivity(&fakeExecutor{err: fmt.Errorf("%w: exit code 1", constants.ErrExecutionFailed)}, ""), syncRequest())
+	require.False(t, errors.As(err, &canceledErr))
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, "ExecutionFailed", appErr.Type())
+	require.True(t, appErr.NonRetryable())
+
+	result, err := runSyncActivity(t, syncActivity(&fakeExecutor{result: &types.ExecutorResponse{Response: "done"}}, ""), syncRequest())
+	require.NoError(t, err)
+	require.Equal(t, "done", result.Response)
+}
+
+func TestSyncActivityValidatesConfig(t *testing.T) {
+	viper.Set(constants.EnvValidateConfigBefore, "sync, discover")
+	t.Cleanup(func() { viper.Set(constants.EnvValidateConfigBefore, nil) })
+
+	specFile := fmt.Sprintf("spec-%d.json", time.Now().UnixNano())
+	specPath := filepath.Join(utils.GetConfigDir(), specFile)
+	require.NoError(t, os.MkdirAll(utils.GetConfigDir(), 0o755))
+	t.Cleanup(func() { os.Remove(specPath) })
+	require.NoError(t, os.WriteFile(specPath, []byte(`{"spec":{"jsonschema":{"type":"object","required":["host"],"properties":{"port":{"type":"integer"}}}}}`), 0o644))
+
+	tests := []struct {
+		name     string
+		config   string
+		wantErr  string
+		wantRuns []types.Command
+	}{
+		{name: "valid config", config: `{"host":"db","port":5432}`, wantRuns: []types.Command{types.Spec, types.Sync}},
+		{name: "invalid config", config: `{"port":"5432"}`, wantErr: "source.json config is invalid: host: required; port: expected integer", wantRuns: []types.Command{types.Spec}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the spec run answers with the spec file, read from the config directory
+			exec := &fakeExecutor{result: &types.ExecutorResponse{Response: specFile}}
+			req := syncRequest()
+			req.Args = []string{"sync", "--config", "/mnt/config/source.json"}
+			req.Configs = []types.JobConfig{{Name: "source.json"}}
+
+			_, err := runSyncActivity(t, syncActivity(exec, tt.config), req)
+			if tt.wantErr != "" {
+				var appErr *temporal.ApplicationError
+				require.ErrorAs(t, err, &appErr)
+				require.Equal(t, "ConfigValidationFailed", appErr.Type())
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var runs []types.Command
+			for _, run := range exec.runs {
+				runs = append(runs, run.Command)
+			}
+			require.Equal(t, tt.wantRuns, runs)
+		})
+	}
+}
+
+func TestExecuteConnectionTest(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvActivityTestTimeout, nil) })
+	checkRequest := func(timeout time.Duration) *types.ExecutionRequest {
+		return &types.ExecutionRequest{JobID: 7, WorkflowID: "check-7-abc", Command: types.Check, ConnectorType: "postgres", Timeout: timeout}
+	}
+
+	t.Run("unreachable source", func(t *testing.T) {
+		viper.Set(constants.EnvActivityTestTimeout, 20*time.Millisecond)
+		exec := &fakeExecutor{hang: true}
+
+		_, err := (&Activity{executor: exec}).executeConnectionTest(context.Background(), checkRequest(2*time.Hour))
+		var appErr *temporal.ApplicationError
+		require.ErrorAs(t, err, &appErr)
+		require.Equal(t, "ConnectionTimeout", appErr.Type())
+		require.True(t, appErr.NonRetryable())
+		require.ErrorContains(t, err, "connection timed out after 20ms")
+		// the executor waits no longer than the test timeout either
+		require.Equal(t, 20*time.Millisecond, exec.runs[0].Timeout)
+	})
+
+	t.Run("shorter request timeout", func(t *testing.T) {
+		viper.Set(constants.EnvActivityTestTimeout, time.Minute)
+		exec := &fakeExecutor{result: &types.ExecutorResponse{Response: "SUCCEEDED"}}
+
+		result, err := (&Activity{executor: exec}).executeConnectionTest(context.Background(), checkRequest(10*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, "SUCCEEDED", result.Response)
+		require.Equal(t, 10*time.Second, exec.runs[0].Timeout)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		viper.Set(constants.EnvActivityTestTimeout, 0)
+		exec := &fakeExecutor{result: &types.ExecutorResponse{Response: "SUCCEEDED"}}
+
+		_, err := (&Activity{executor: exec}).executeConnectionTest(context.Background(), checkRequest(2*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 2*time.Hour, exec.runs[0].Timeout)
+	})
+
+	t.Run("activity cancelled", func(t *testing.T) {
+		viper.Set(constants.EnvActivityTestTimeout, time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		// the activity's own deadline is not reported as a connection timeout
+		_, err := (&Activity{executor: &fakeExecutor{hang: true}}).executeConnectionTest(ctx, checkRequest(2*time.Hour))
+		var appErr *temporal.ApplicationError
+		require.False(t, errors.As(err, &appErr))
+		require.ErrorContains(t, err, "context deadline exceeded")
+	})
+}
+
+func TestPreparePostSyncHook(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvPostSyncHookURL, nil) })
+	completed := func() *types.ExecutionRequest {
+		return &types.ExecutionRequest{JobID: 7, ProjectID: "project-a", WorkflowID: fmt.Sprintf("sync-7-%d", time.Now().UnixNano()), Command: types.Sync, Status: types.SyncStatusCompleted}
+	}
+
+	tests := []struct {
+		name        string
+		req         *types.ExecutionRequest
+		projectHook string
+		defaultHook string
+		wantURL     string
+	}{
+		{name: "project hook", req: completed(), projectHook: "https://hooks.example.com/project", defaultHook: "https://hooks.example.com/default", wantURL: "https://hooks.example.com/project"},
+		{name: "default hook", req: completed(), defaultHook: "https://hooks.example.com/default", wantURL: "https://hooks.example.com/default"},
+		{name: "no hook", req: completed()},
+		{name: "failed sync", req: &types.ExecutionRequest{JobID: 7, Command: types.Sync, Status: types.SyncStatusFailed}, defaultHook: "https://hooks.example.com/default"},
+		{name: "discover", req: &types.ExecutionRequest{JobID: 7, Command: types.Discover}, defaultHook: "https://hooks.example.com/default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvPostSyncHookURL, tt.defaultHook)
+			a := &Activity{db: &fakeActivityDB{hookURL: tt.projectHook}}
+
+			hookURL, payload := a.preparePostSyncHook(context.Background(), tt.req, "orders")
+			require.Equal(t, tt.wantURL, hookURL)
+			if tt.wantURL == "" {
+				return
+			}
+			require.Equal(t, 7, payload.JobID)
+			require.Equal(t, "orders", payload.JobName)
+			require.Equal(t, tt.req.WorkflowID, payload.WorkflowID)
+			require.Equal(t, types.SyncStatusCompleted, payload.Status)
+			// the workflow directory is gone, so the summary has no streams
+			require.NotNil(t, payload.Streams)
+			require.Empty(t, payload.Streams)
+		})
+	}
+}
+
+func TestExecuteDiscoverCache(t *testing.T) {
+	viper.Set(constants.EnvDiscoverCacheTTL, time.Hour)
+	t.Cleanup(func() { viper.Set(constants.EnvDiscoverCacheTTL, nil) })
+
+	// a connector type of its own keeps the cache entry apart from other tests
+	connector := fmt.Sprintf("postgres-%d", time.Now().UnixNano())
+	discover := func(workflowID string) *types.ExecutionRequest {
+		req := &types.ExecutionRequest{WorkflowID: workflowID, Command: types.Discover, ConnectorType: connector, Version: "v0.2.0",
+			Configs: []types.JobConfig{{Name: "config.json", Data: `{"host":"db.internal"}`}}}
+		_, workdir := utils.GetWorkflowDirAndSubDir(workflowID, types.Discover)
+		t.Cleanup(func() { os.RemoveAll(workdir) })
+		return req
+	}
+	readOutput := func(t *testing.T, req *types.ExecutionRequest) string {
+		_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+		data, err := os.ReadFile(filepath.Join(workdir, constants.OutputFileName))
+		require.NoError(t, err)
+		return string(data)
+	}
+	exec := &fakeExecutor{result: &types.ExecutorResponse{}}
+	a := &Activity{executor: exec}
+	catalog := `{"streams":[{"name":"orders"}]}`
+
+	// the first discover runs the connector, which writes the catalog
+	first := discover(fmt.Sprintf("discover-%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		os.Remove(filepath.Join(utils.GetConfigDir(), constants.CatalogCacheDir, utils.CatalogCacheKey(first)+".json"))
+	})
+	_, workdir := utils.GetWorkflowDirAndSubDir(first.WorkflowID, first.Command)
+	require.NoError(t, utils.WriteFile(filepath.Join(workdir, constants.OutputFileName), []byte(catalog)))
+	_, err := a.executeDiscover(context.Background(), first)
+	require.NoError(t, err)
+	require.Len(t, exec.runs, 1)
+
+	// an identical discover is answered from the cache
+	second := discover(fmt.Sprintf("discover-%d", time.Now().UnixNano()))
+	result, err := a.executeDiscover(context.Background(), second)
+	require.NoError(t, err)
+	require.Len(t, exec.runs, 1)
+	require.Equal(t, filepath.Join(utils.GetWorkflowDirectory(types.Discover, second.WorkflowID), constants.OutputFileName), result.Response)
+	require.Equal(t, catalog, readOutput(t, second))
+
+	// a forced refresh runs the connector again
+	refresh := discover(fmt.Sprintf("discover-%d", time.Now().UnixNano()))
+	refresh.ForceRefresh = true
+	_, err = a.executeDiscover(context.Background(), refresh)
+	require.NoError(t, err)
+	require.Len(t, exec.runs, 2)
+
+	// as does any discover with the cache disabled
+	viper.Set(constants.EnvDiscoverCacheTTL, 0)
+	_, err = a.executeDiscover(context.Background(), discover(fmt.Sprintf("discover-%d", time.Now().UnixNano())))
+	require.NoError(t, err)
+	require.Len(t, exec.runs, 3)
+}
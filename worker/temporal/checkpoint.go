@@ -0,0 +1,120 @@
+package temporal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+var (
+	checkpointSlots     chan struct{}
+	checkpointSlotsOnce sync.Once
+)
+
+// acquireCheckpointSlot takes one of the SYNC_STATE_CHECKPOINT_MAX_CONCURRENT slots without
+// blocking, so concurrent syncs checkpointing at once can't exhaust the connection pool that
+// job reads and final state writes depend on. A non-positive limit disables the budget.
+func acquireCheckpointSlot() (release func(), ok bool) {
+	checkpointSlotsOnce.Do(func() {
+		if limit := viper.GetInt(constants.EnvStateCheckpointMaxConcurrent); limit > 0 {
+			checkpointSlots = make(chan struct{}, limit)
+		}
+	})
+	if checkpointSlots == nil {
+		return func() {}, true
+	}
+
+	select {
+	case checkpointSlots <- struct{}{}:
+		return func() { <-checkpointSlots }, true
+	default:
+		return nil, false
+	}
+}
+
+// startStateCheckpointer periodically copies the connector's state.json into the job row while a
+// sync runs, so a sync that is evicted or crashes after many hours resumes from its last checkpoint
+// instead of from the state saved by the previous run. The returned function stops the
+// checkpointer and waits for an in-flight write to finish.
+func (a *Activity) startStateCheckpointer(ctx context.Context, req *types.ExecutionRequest) func() {
+	interval := viper.GetDuration(constants.EnvStateCheckpointInterval)
+	if interval <= 0 {
+		return func() {}
+	}
+
+	log := logger.Log(ctx)
+	// the saved state must survive a full refresh or past-state run that doesn't complete
+	if req.Options.OverridesState() {
+		log.Info("sync does not start from the saved state, skipping state checkpoints", "jobID", req.JobID)
+		return func() {}
+	}
+	log.Info("starting state checkpointer", "jobID", req.JobID, "interval", interval)
+
+	checkpointCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastState := ""
+		for {
+			select {
+			case <-checkpointCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			state, err := utils.GetStateFileFromWorkdir(req.WorkflowID, req.Command)
+			if err != nil {
+				log.Debug("state file not available for checkpoint", "jobID", req.JobID, "error", err)
+				continue
+			}
+			if !isCheckpointableState(state) || state == lastState {
+				continue
+			}
+
+			// the next tick retries; the final state is always written at cleanup
+			release, ok := acquireCheckpointSlot()
+			if !ok {
+				log.Debug("checkpoint budget exhausted, skipping state checkpoint", "jobID", req.JobID)
+				continue
+			}
+			err = a.db.CheckpointJobState(checkpointCtx, req.JobID, state, req.RunStartedAt)
+			release()
+			// the job was updated after this run started, so none of its states will be accepted
+			if errors.Is(err, constants.ErrStaleState) {
+				log.Warn("stopping state checkpoints, the job was updated after the run started", "jobID", req.JobID, "error", err)
+				return
+			}
+			if err != nil {
+				log.Warn("failed to checkpoint job state", "jobID", req.JobID, "error", err)
+				continue
+			}
+			lastState = state
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// isCheckpointableState rejects empty, null and partially written state files, which would
+// otherwise overwrite the last good state in the database
+func isCheckpointableState(state string) bool {
+	state = strings.TrimSpace(state)
+	return !utils.IsStateEmpty(state) && state != "null" && json.Valid([]byte(state))
+}
@@ -0,0 +1,46 @@
+package temporal
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// resetCheckpointSlots sizes the checkpoint budget from the given limit on the next acquire
+func resetCheckpointSlots(t *testing.T, limit int) {
+	viper.Set(constants.EnvStateCheckpointMaxConcurrent, limit)
+	checkpointSlots, checkpointSlotsOnce = nil, sync.Once{}
+	t.Cleanup(func() {
+		viper.Set(constants.EnvStateCheckpointMaxConcurrent, nil)
+		checkpointSlots, checkpointSlotsOnce = nil, sync.Once{}
+	})
+}
+
+func TestAcquireCheckpointSlot(t *testing.T) {
+	resetCheckpointSlots(t, 2)
+
+	releaseFirst, ok := acquireCheckpointSlot()
+	require.True(t, ok)
+	_, ok = acquireCheckpointSlot()
+	require.True(t, ok)
+
+	// a third concurrent checkpoint is skipped rather than waiting for a connection
+	_, ok = acquireCheckpointSlot()
+	require.False(t, ok)
+
+	releaseFirst()
+	_, ok = acquireCheckpointSlot()
+	require.True(t, ok)
+}
+
+func TestAcquireCheckpointSlotUnlimited(t *testing.T) {
+	resetCheckpointSlots(t, 0)
+
+	for range 10 {
+		_, ok := acquireCheckpointSlot()
+		require.True(t, ok)
+	}
+}
@@ -3,16 +3,20 @@ package temporal
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/datazip-inc/olake-helm/worker/utils/tracing"
 	"github.com/spf13/viper"
 	namespacepb "go.temporal.io/api/namespace/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -28,21 +32,29 @@ func NewClient() (*Temporal, error) {
 
 	namespace := utils.GetTemporalNamespace()
 
-	err := utils.RetryWithBackoff(func() error {
+	tlsConfig, err := buildTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Temporal TLS: %s", err)
+	}
+
+	err = utils.RetryWithBackoffJittered(func() error {
 		opts := client.Options{
 			HostPort:  viper.GetString(constants.EnvTemporalAddress),
 			Logger:    logger.Log(context.Background()),
 			Namespace: namespace,
 		}
 
-		if viper.GetBool(constants.EnvTemporalEnableTLS) {
+		if tlsConfig != nil {
 			opts.ConnectionOptions = client.ConnectionOptions{
-				TLS: &tls.Config{
-					MinVersion: tls.VersionTLS12,
-				},
+				TLS: tlsConfig,
 			}
 		}
 
+		// set on the client, the tracing interceptor also applies to the worker
+		if tracing.Enabled() {
+			opts.Interceptors = []interceptor.ClientInterceptor{tracing.NewTemporalInterceptor()}
+		}
+
 		if apiKey := viper.GetString(constants.EnvTemporalAPIKey); apiKey != "" {
 			opts.Credentials = client.NewAPIKeyStaticCredentials(apiKey)
 		}
@@ -55,7 +67,7 @@ func NewClient() (*Temporal, error) {
 			client: client,
 		}
 		return nil
-	}, 3, time.Second)
+	}, connectBackoff())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Temporal client: %s", err)
 	}
@@ -72,6 +84,71 @@ func NewClient() (*Temporal, error) {
 	return temporalClient, nil
 }
 
+// connectBackoff returns the retry budget for dialing Temporal. On a cold cluster start
+// Temporal can come up well after the worker, which keeps retrying (reporting not ready)
+// until TEMPORAL_CONNECT_MAX_RETRIES attempts or TEMPORAL_CONNECT_TIMEOUT run out.
+func connectBackoff() utils.BackoffOptions {
+	maxRetries := viper.GetInt(constants.EnvTemporalConnectMaxRetries)
+	if maxRetries < 1 {
+		logger.Warnf("invalid %s %d, dialing Temporal once", constants.EnvTemporalConnectMaxRetries, maxRetries)
+		maxRetries = 1
+	}
+	return utils.BackoffOptions{
+		MaxRetries:   maxRetries,
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		Timeout:      viper.GetDuration(constants.EnvTemporalConnectTimeout),
+		Jitter:       true,
+	}
+}
+
+// buildTLSConfig returns the TLS config for dialing Temporal, or nil for plaintext. TLS is used
+// when TEMPORAL_ENABLE_TLS is set or any certificate is configured; a client certificate and
+// key enable mTLS and a CA bundle replaces the system roots for verifying the server.
+func buildTLSConfig() (*tls.Config, error) {
+	certFile := viper.GetString(constants.EnvTemporalTLSCert)
+	keyFile := viper.GetString(constants.EnvTemporalTLSKey)
+	caFile := viper.GetString(constants.EnvTemporalTLSCA)
+
+	if !viper.GetBool(constants.EnvTemporalEnableTLS) && certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         viper.GetString(constants.EnvTemporalTLSServerName),
+		InsecureSkipVerify: viper.GetBool(constants.EnvTemporalTLSInsecureSkipVerify),
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both %s and %s must be set for mTLS", constants.EnvTemporalTLSCert, constants.EnvTemporalTLSKey)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if tlsConfig.InsecureSkipVerify {
+		logger.Warnf("%s is set, the Temporal server certificate will not be verified", constants.EnvTemporalTLSInsecureSkipVerify)
+	}
+	return tlsConfig, nil
+}
+
 // Close closes the Temporal client and, if initialised, the cloud management client.
 func (t *Temporal) Close() {
 	if t.cloudClient != nil {
@@ -0,0 +1,129 @@
+package temporal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files in dir
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "olake-worker"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	settings := []string{
+		constants.EnvTemporalEnableTLS, constants.EnvTemporalTLSCert, constants.EnvTemporalTLSKey,
+		constants.EnvTemporalTLSCA, constants.EnvTemporalTLSServerName, constants.EnvTemporalTLSInsecureSkipVerify,
+	}
+	t.Cleanup(func() {
+		for _, key := range settings {
+			viper.Set(key, nil)
+		}
+	})
+
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		wantNil  bool
+		wantErr  string
+		check    func(t *testing.T, config *tls.Config)
+	}{
+		{name: "plaintext", wantNil: true},
+		{
+			name:     "tls with system roots",
+			settings: map[string]any{constants.EnvTemporalEnableTLS: true, constants.EnvTemporalTLSServerName: "temporal.internal"},
+			check: func(t *testing.T, config *tls.Config) {
+				require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+				require.Equal(t, "temporal.internal", config.ServerName)
+				require.Nil(t, config.RootCAs)
+				require.Empty(t, config.Certificates)
+			},
+		},
+		{
+			name:     "mtls with custom ca",
+			settings: map[string]any{constants.EnvTemporalTLSCert: certFile, constants.EnvTemporalTLSKey: keyFile, constants.EnvTemporalTLSCA: certFile},
+			check: func(t *testing.T, config *tls.Config) {
+				require.Len(t, config.Certificates, 1)
+				require.NotNil(t, config.RootCAs)
+				require.False(t, config.InsecureSkipVerify)
+			},
+		},
+		{name: "cert without key", settings: map[string]any{constants.EnvTemporalTLSCert: certFile}, wantErr: "must be set for mTLS"},
+		{name: "unreadable key pair", settings: map[string]any{constants.EnvTemporalTLSCert: certFile, constants.EnvTemporalTLSKey: notPEM}, wantErr: "failed to load client certificate"},
+		{name: "missing ca", settings: map[string]any{constants.EnvTemporalTLSCA: filepath.Join(dir, "missing.pem")}, wantErr: "failed to read CA certificate"},
+		{name: "ca without certificates", settings: map[string]any{constants.EnvTemporalTLSCA: notPEM}, wantErr: "no valid certificates found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range settings {
+				viper.Set(key, tt.settings[key])
+			}
+
+			config, err := buildTLSConfig()
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				require.Nil(t, config)
+				return
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestConnectBackoff(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set(constants.EnvTemporalConnectMaxRetries, nil)
+		viper.Set(constants.EnvTemporalConnectTimeout, nil)
+	})
+
+	viper.Set(constants.EnvTemporalConnectMaxRetries, 60)
+	viper.Set(constants.EnvTemporalConnectTimeout, "5m")
+	require.Equal(t, utils.BackoffOptions{MaxRetries: 60, InitialDelay: time.Second, MaxDelay: 10 * time.Second, Timeout: 5 * time.Minute, Jitter: true}, connectBackoff())
+
+	// a non-positive retry count still dials once
+	viper.Set(constants.EnvTemporalConnectMaxRetries, 0)
+	require.Equal(t, 1, connectBackoff().MaxRetries)
+}
@@ -1,23 +1,40 @@
 package temporal
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/database"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+	"go.temporal.io/api/workflowservice/v1"
 )
 
-const healthPort = 8090
+const (
+	defaultHealthPort = 8090
+
+	// adminTokenHeader carries WORKER_ADMIN_TOKEN on admin endpoint requests
+	adminTokenHeader = "X-Admin-Token"
+
+	// temporalCheckTimeout bounds the describe-namespace call made by an idle worker's liveness probe;
+	// kept below the chart's 2s probe timeout so a slow Temporal shows up as a failed check
+	temporalCheckTimeout = 1500 * time.Millisecond
+)
 
 type Server struct {
 	server    *http.Server
-	worker    *Worker
+	worker    atomic.Pointer[Worker] // nil until Temporal is connected
 	startTime time.Time
 	db        *database.DB
+	logDir    string // cleaned by the admin log cleanup endpoint
 }
 
 type HealthResponse struct {
@@ -26,15 +43,17 @@ type HealthResponse struct {
 	Checks    map[string]string `json:"checks,omitempty"`
 }
 
-func NewHealthServer(worker *Worker, db *database.DB) *Server {
+// NewHealthServer creates the health server. It can be started before Temporal is connected,
+// reporting not ready until SetWorker is called.
+func NewHealthServer(db *database.DB) *Server {
 	mux := http.NewServeMux()
 
 	hs := &Server{
-		worker:    worker,
 		startTime: time.Now(),
 		db:        db,
+		logDir:    utils.GetConfigDir(),
 		server: &http.Server{
-			Addr:    fmt.Sprintf(":%d", healthPort),
+			Addr:    fmt.Sprintf(":%d", getHealthPort()),
 			Handler: mux,
 		},
 	}
@@ -44,12 +63,36 @@ func NewHealthServer(worker *Worker, db *database.DB) *Server {
 	mux.HandleFunc("/ready", hs.readinessHandler)
 	mux.HandleFunc("/metrics", hs.metricsHandler)
 
+	// admin endpoints are only served when a token protects them
+	if viper.GetString(constants.EnvWorkerAdminToken) != "" {
+		mux.HandleFunc("/admin/cleanup-logs", hs.cleanupLogsHandler)
+	}
+
 	return hs
 }
 
+// SetWorker marks Temporal as connected once the worker is created
+func (hs *Server) SetWorker(worker *Worker) {
+	hs.worker.Store(worker)
+}
+
 func (hs *Server) Start() error {
-	logger.Infof("starting health check server on port %d", healthPort)
-	return hs.server.ListenAndServe()
+	listener, err := net.Listen("tcp", hs.server.Addr)
+	if err != nil {
+		return err
+	}
+	logger.Infof("starting health check server on port %d", listener.Addr().(*net.TCPAddr).Port)
+	return hs.server.Serve(listener)
+}
+
+// getHealthPort returns the configured health server port, falling back to the default when out of range
+func getHealthPort() int {
+	port := viper.GetInt(constants.EnvHealthPort)
+	if port <= 0 || port > 65535 {
+		logger.Warnf("invalid %s %d, using default port %d", constants.EnvHealthPort, port, defaultHealthPort)
+		return defaultHealthPort
+	}
+	return port
 }
 
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
@@ -60,17 +103,33 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-// Liveness: fail if Temporal client/worker are not present
-func (hs *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
+// Liveness: fail if Temporal client/worker are not present, or if the worker has been idle
+// longer than HEALTH_LIVENESS_WINDOW and Temporal can no longer be reached
+func (hs *Server) healthHandler(w http.ResponseWriter, req *http.Request) {
 	response := HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
 		Checks:    map[string]string{"worker": "running"},
 	}
 
-	if hs.worker.worker == nil || hs.worker.temporal.client == nil {
+	// still retrying the Temporal connection; restarting the pod wouldn't help
+	worker := hs.worker.Load()
+	if worker == nil {
+		response.Checks["worker"] = "connecting"
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if worker.worker == nil || worker.temporal.client == nil {
+		response.Status = "unhealthy"
+		response.Checks["worker"] = utils.Ternary(worker.temporal.client == nil, "temporal_client_disconnected", "temporal_worker_failed").(string)
+		writeJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	if !hs.isWorkerLive(req.Context(), worker) {
 		response.Status = "unhealthy"
-		response.Checks["worker"] = utils.Ternary(hs.worker.temporal.client == nil, "temporal_client_disconnected", "temporal_worker_failed").(string)
+		response.Checks["worker"] = "temporal_poller_stalled"
 		writeJSON(w, http.StatusServiceUnavailable, response)
 		return
 	}
@@ -78,6 +137,38 @@ func (hs *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, response)
 }
 
+// isWorkerLive reports whether the worker ran an activity within the liveness window. An idle
+// worker is still live as long as Temporal answers a describe-namespace call, which then
+// restarts the window; a worker that can neither run activities nor reach Temporal is wedged.
+func (hs *Server) isWorkerLive(ctx context.Context, worker *Worker) bool {
+	window := viper.GetDuration(constants.EnvHealthLivenessWindow)
+	if window <= 0 {
+		return true
+	}
+
+	lastActivity := hs.startTime
+	if ts := lastActivityAt.Load(); ts > 0 {
+		lastActivity = time.Unix(0, ts)
+	}
+	if time.Since(lastActivity) < window {
+		return true
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, temporalCheckTimeout)
+	defer cancel()
+
+	_, err := worker.temporal.client.WorkflowService().DescribeNamespace(checkCtx, &workflowservice.DescribeNamespaceRequest{
+		Namespace: utils.GetTemporalNamespace(),
+	})
+	if err != nil {
+		logger.Warnf("liveness check failed - no activity for %s and temporal unreachable: %s", time.Since(lastActivity).Round(time.Second), err)
+		return false
+	}
+
+	recordWorkerActivity()
+	return true
+}
+
 // Readiness: require Temporal client + worker + database initialised
 func (hs *Server) readinessHandler(w http.ResponseWriter, req *http.Request) {
 	response := HealthResponse{
@@ -94,10 +185,11 @@ func (hs *Server) readinessHandler(w http.ResponseWriter, req *http.Request) {
 	// - worker: Must be non-nil (initialization completed)
 	// - temporalClient: Must be connected (can communicate with Temporal server)
 	// This prevents routing requests to pods that can't process workflows/activities.
-	if hs.worker == nil || hs.worker.temporal.client == nil {
+	worker := hs.worker.Load()
+	if worker == nil || worker.temporal.client == nil {
 		response.Status = "not_ready"
-		response.Checks["temporal"] = "disconnected"
-		logger.Debugf("Readiness check failed - Temporal not connected (worker: %v, client: %v)", hs.worker != nil, hs.worker != nil && hs.worker.temporal.client != nil)
+		response.Checks["temporal"] = utils.Ternary(worker == nil, "connecting", "disconnected").(string)
+		logger.Debugf("Readiness check failed - Temporal not connected (worker: %v, client: %v)", worker != nil, worker != nil && worker.temporal.client != nil)
 		writeJSON(w, http.StatusServiceUnavailable, response)
 		return
 	}
@@ -131,6 +223,29 @@ func (hs *Server) metricsHandler(w http.ResponseWriter, _ *http.Request) {
 		"worker_status":  "running",
 		"uptime_seconds": time.Since(hs.startTime).Seconds(),
 		"timestamp":      time.Now(),
+		"db_pool":        hs.db.PoolStats(),
 	}
 	writeJSON(w, http.StatusOK, metrics)
 }
+
+// cleanupLogsHandler runs the log cleaner now instead of waiting for midnight. Requests must
+// carry WORKER_ADMIN_TOKEN in the X-Admin-Token header.
+func (hs *Server) cleanupLogsHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	token := viper.GetString(constants.EnvWorkerAdminToken)
+	provided := req.Header.Get(adminTokenHeader)
+	if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		logger.Warnf("rejected log cleanup request from %s: invalid admin token", req.RemoteAddr)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	logger.Infof("log cleanup triggered from %s", req.RemoteAddr)
+	result := utils.RunLogCleanup(hs.logDir, viper.GetInt(constants.EnvLogRetentionPeriod))
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,100 @@
+package temporal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupLogsHandler(t *testing.T) {
+	viper.Set(constants.EnvWorkerAdminToken, "s3cret")
+	viper.Set(constants.EnvLogRetentionPeriod, 30)
+	t.Cleanup(func() {
+		viper.Set(constants.EnvWorkerAdminToken, nil)
+		viper.Set(constants.EnvLogRetentionPeriod, nil)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		wantStatus int
+		wantResult utils.LogCleanupResult
+	}{
+		{name: "cleanup", method: http.MethodPost, token: "s3cret", wantStatus: http.StatusOK, wantResult: utils.LogCleanupResult{Directories: 1, BytesFreed: 9}},
+		{name: "wrong token", method: http.MethodPost, token: "guess", wantStatus: http.StatusUnauthorized},
+		{name: "no token", method: http.MethodPost, wantStatus: http.StatusUnauthorized},
+		{name: "get", method: http.MethodGet, token: "s3cret", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := NewHealthServer(nil)
+			hs.logDir = t.TempDir()
+			expired := filepath.Join(hs.logDir, "sync-expired", "logs", "worker.log")
+			require.NoError(t, os.MkdirAll(filepath.Dir(expired), 0o755))
+			require.NoError(t, os.WriteFile(expired, []byte("long gone"), 0o644))
+			modTime := time.Now().AddDate(0, 0, -40)
+			require.NoError(t, os.Chtimes(expired, modTime, modTime))
+
+			req := httptest.NewRequest(tt.method, "/admin/cleanup-logs", nil)
+			if tt.token != "" {
+				req.Header.Set(adminTokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			hs.server.Handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				// rejected requests leave the logs alone
+				require.FileExists(t, expired)
+				return
+			}
+			var result utils.LogCleanupResult
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+			require.Equal(t, tt.wantResult, result)
+			require.NoDirExists(t, filepath.Join(hs.logDir, "sync-expired"))
+		})
+	}
+
+	// without a token the endpoint isn't served
+	viper.Set(constants.EnvWorkerAdminToken, "")
+	rec := httptest.NewRecorder()
+	NewHealthServer(nil).server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/cleanup-logs", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHealthHandlersWhileConnecting(t *testing.T) {
+	hs := NewHealthServer(nil)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		// the pod isn't restarted while the Temporal dial is still retrying
+		{path: "/health", wantStatus: http.StatusOK, wantChecks: map[string]string{"worker": "connecting"}},
+		{path: "/ready", wantStatus: http.StatusServiceUnavailable, wantChecks: map[string]string{"temporal": "connecting", "database": "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			hs.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.Equal(t, tt.wantStatus, rec.Code)
+
+			var response HealthResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.Equal(t, tt.wantChecks, response.Checks)
+		})
+	}
+}
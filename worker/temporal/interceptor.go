@@ -2,6 +2,8 @@ package temporal
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
@@ -9,6 +11,17 @@ import (
 	"go.temporal.io/sdk/interceptor"
 )
 
+// lastActivityAt holds the unix nano timestamp of the last activity started or finished by this
+// worker (or of the last successful Temporal reachability check); it backs the liveness probe.
+var lastActivityAt atomic.Int64
+
+func recordWorkerActivity() {
+	lastActivityAt.Store(time.Now().UnixNano())
+}
+
+// runningActivities counts the activities executing on this worker, reported when it shuts down
+var runningActivities atomic.Int64
+
 // LoggingInterceptor automatically sets up workflow file logging for activities.
 type LoggingInterceptor struct {
 	interceptor.WorkerInterceptorBase
@@ -35,19 +48,41 @@ func (a *loggingActivityInterceptor) ExecuteActivity(
 	ctx context.Context,
 	in *interceptor.ExecuteActivityInput,
 ) (interface{}, error) {
+	recordWorkerActivity()
+	defer recordWorkerActivity()
+	runningActivities.Add(1)
+	defer runningActivities.Add(-1)
+
 	req := extractExecutionRequest(in.Args)
-	if req == nil || req.WorkflowID == "" {
+	if req == nil {
 		return a.Next.ExecuteActivity(ctx, in)
 	}
+	if req.WorkflowID == "" {
+		return a.Next.ExecuteActivity(withRequestLogFields(ctx, req), in)
+	}
 
 	ctxWithLogger, logFile, err := utils.PrepareWorkflowLogger(ctx, req.WorkflowID, req.Command)
 	if err != nil {
 		logger.Warnf("failed to prepare workflow logger for workflowID=%s: %s", req.WorkflowID, err)
-		return a.Next.ExecuteActivity(ctx, in)
+		return a.Next.ExecuteActivity(withRequestLogFields(ctx, req), in)
 	}
 	defer logFile.Close()
 
-	return a.Next.ExecuteActivity(ctxWithLogger, in)
+	return a.Next.ExecuteActivity(withRequestLogFields(ctxWithLogger, req), in)
+}
+
+// withRequestLogFields tags every logger.Log(ctx) line of the activity with the request's IDs,
+// so worker logs can be filtered by job or workflow in Loki/ELK
+func withRequestLogFields(ctx context.Context, req *types.ExecutionRequest) context.Context {
+	fields := []interface{}{
+		"workflow_id", req.WorkflowID,
+		"connector", req.ConnectorType,
+		"command", string(req.Command),
+	}
+	if req.JobID > 0 {
+		fields = append(fields, "job_id", req.JobID)
+	}
+	return logger.CtxWithFields(ctx, fields...)
 }
 
 func extractExecutionRequest(args []interface{}) *types.ExecutionRequest {
@@ -58,4 +93,3 @@ func extractExecutionRequest(args []interface{}) *types.ExecutionRequest {
 	}
 	return nil
 }
-
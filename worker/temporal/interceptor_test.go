@@ -0,0 +1,91 @@
+package temporal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+)
+
+func TestLoggingInterceptorWritesWorkflowLog(t *testing.T) {
+	req := &types.ExecutionRequest{JobID: 7, WorkflowID: fmt.Sprintf("sync-%d", time.Now().UnixNano()), Command: types.Sync, ConnectorType: "postgres"}
+	_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	t.Cleanup(func() { os.RemoveAll(workdir) })
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{NewLoggingInterceptor()}})
+	var running int64
+	env.RegisterActivityWithOptions(func(ctx context.Context, _ *types.ExecutionRequest) error {
+		running = runningActivities.Load()
+		logger.Log(ctx).Info("connector started")
+		return nil
+	}, activity.RegisterOptions{Name: SyncActivity})
+
+	_, err := env.ExecuteActivity(SyncActivity, req)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, running)
+	require.Zero(t, runningActivities.Load())
+
+	// the activity's lines land in the workflow's worker.log, tagged with the request
+	data, err := os.ReadFile(filepath.Join(workdir, "logs", "worker.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "connector started")
+
+	// and carry its IDs as structured fields
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(data))), &line))
+	require.Equal(t, req.WorkflowID, line["workflow_id"])
+	require.EqualValues(t, 7, line["job_id"])
+	require.Equal(t, "postgres", line["connector"])
+	require.Equal(t, "sync", line["command"])
+}
+
+func TestWithRequestLogFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *types.ExecutionRequest
+		wantFields map[string]interface{}
+	}{
+		{
+			name:       "sync",
+			req:        &types.ExecutionRequest{JobID: 7, WorkflowID: "sync-7-abc", Command: types.Sync, ConnectorType: "postgres"},
+			wantFields: map[string]interface{}{"workflow_id": "sync-7-abc", "job_id": float64(7), "connector": "postgres", "command": "sync"},
+		},
+		{
+			// a connection test has no job and is run without a workflow directory
+			name:       "check",
+			req:        &types.ExecutionRequest{Command: types.Check, ConnectorType: "mysql"},
+			wantFields: map[string]interface{}{"connector": "mysql", "command": "check"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			ctx := withRequestLogFields(logger.CtxWithLogger(context.Background(), zerolog.New(&logs)), tt.req)
+			logger.Log(ctx).Info("connector started")
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+			delete(line, "level")
+			delete(line, "message")
+			require.Equal(t, tt.wantFields, line)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package temporal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+const bytesPerMiB = 1 << 20
+
+// startResourceSampler logs the connector container's CPU and memory every
+// CONNECTOR_METRICS_INTERVAL while a sync runs, for right-sizing job profiles. Samples that
+// can't be read, e.g. before the container starts, are skipped. The returned function stops
+// the sampler and waits for an in-flight sample.
+func (a *Activity) startResourceSampler(ctx context.Context, req *types.ExecutionRequest) func() {
+	interval := viper.GetDuration(constants.EnvConnectorMetricsInterval)
+	if interval <= 0 {
+		return func() {}
+	}
+
+	log := logger.Log(ctx)
+	log.Info("starting connector resource sampler", "jobID", req.JobID, "interval", interval)
+
+	samplerCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-samplerCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			sample, err := a.executor.SampleResources(samplerCtx, req)
+			if err != nil {
+				log.Debug("connector resource sample not available", "jobID", req.JobID, "error", err)
+				continue
+			}
+			log.Info("connector resource usage",
+				"jobID", req.JobID,
+				"cpuCores", sample.CPUCores,
+				"memoryMiB", sample.MemoryBytes/bytesPerMiB,
+				"memoryLimitMiB", sample.MemoryLimitBytes/bytesPerMiB,
+			)
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,82 @@
+package temporal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a log sink safe to read while the sampler writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStartResourceSampler(t *testing.T) {
+	viper.Set(constants.EnvConnectorMetricsInterval, 10*time.Millisecond)
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorMetricsInterval, nil) })
+
+	var logs syncBuffer
+	ctx := logger.CtxWithLogger(context.Background(), zerolog.New(&logs).Level(zerolog.DebugLevel))
+	exec := &fakeExecutor{samples: []*types.ResourceSample{
+		{CPUCores: 1.5, MemoryBytes: 512 << 20, MemoryLimitBytes: 1 << 30},
+		{CPUCores: 0.25, MemoryBytes: 300 << 20},
+	}}
+
+	stop := syncActivity(exec, "").startResourceSampler(ctx, syncRequest())
+	// once both samples are read the fake has no more, which the sampler skips
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "connector resource sample not available")
+	}, 5*time.Second, 10*time.Millisecond)
+	stop()
+
+	var usage []string
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"message":"connector resource usage"`) {
+			usage = append(usage, line)
+		}
+	}
+	require.Len(t, usage, 2)
+	require.Contains(t, usage[0], `"jobID":7,"cpuCores":1.5,"memoryMiB":512,"memoryLimitMiB":1024`)
+	require.Contains(t, usage[1], `"jobID":7,"cpuCores":0.25,"memoryMiB":300,"memoryLimitMiB":0`)
+
+	// nothing is logged once the sampler is stopped
+	written := logs.String()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, written, logs.String())
+}
+
+func TestStartResourceSamplerDisabled(t *testing.T) {
+	var logs syncBuffer
+	ctx := logger.CtxWithLogger(context.Background(), zerolog.New(&logs))
+	exec := &fakeExecutor{samples: []*types.ResourceSample{{CPUCores: 1}}}
+
+	stop := syncActivity(exec, "").startResourceSampler(ctx, syncRequest())
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	require.Len(t, exec.samples, 1)
+	require.Empty(t, logs.String())
+}
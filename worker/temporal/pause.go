@@ -0,0 +1,109 @@
+package temporal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// syncPauseGate tracks the pause-sync and resume-sync signals of a sync workflow. The connector
+// runs outside the worker, so a pause lets a running attempt finish and holds off the next one.
+type syncPauseGate struct {
+	paused bool
+}
+
+// newSyncPauseGate registers the signal handlers and the sync-paused query
+func newSyncPauseGate(ctx workflow.Context, jobID int) *syncPauseGate {
+	gate := &syncPauseGate{}
+	logger := workflow.GetLogger(ctx)
+
+	if err := workflow.SetQueryHandler(ctx, constants.SyncPausedQuery, func() (bool, error) {
+		return gate.paused, nil
+	}); err != nil {
+		logger.Error("failed to register sync paused query", "error", err)
+	}
+
+	pauseCh := workflow.GetSignalChannel(ctx, constants.PauseSyncSignal)
+	resumeCh := workflow.GetSignalChannel(ctx, constants.ResumeSyncSignal)
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		for {
+			selector := workflow.NewSelector(ctx)
+			selector.AddReceive(pauseCh, func(c workflow.ReceiveChannel, _ bool) {
+				c.Receive(ctx, nil)
+				if !gate.paused {
+					logger.Info("sync paused", "jobID", jobID)
+				}
+				gate.paused = true
+			})
+			selector.AddReceive(resumeCh, func(c workflow.ReceiveChannel, _ bool) {
+				c.Receive(ctx, nil)
+				if gate.paused {
+					logger.Info("sync resumed", "jobID", jobID)
+				}
+				gate.paused = false
+			})
+			selector.Select(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	})
+
+	return gate
+}
+
+// wait blocks while the sync is paused
+func (g *syncPauseGate) wait(ctx workflow.Context) error {
+	return workflow.Await(ctx, func() bool { return !g.paused })
+}
+
+// runPausableAttempts runs the sync activity one attempt at a time with SyncRetryPolicy's
+// backoff, so a paused sync starts no new attempt until it is resumed
+func runPausableAttempts(ctx workflow.Context, activity string, req *types.ExecutionRequest, options workflow.ActivityOptions) (*types.ExecutorResponse, error) {
+	gate := newSyncPauseGate(ctx, req.JobID)
+
+	attemptOptions := options
+	attemptOptions.RetryPolicy = &temporal.RetryPolicy{MaximumAttempts: 1}
+	attemptCtx := workflow.WithActivityOptions(ctx, attemptOptions)
+
+	delay := SyncRetryPolicy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		var result *types.ExecutorResponse
+		req.Attempt = attempt
+		future := workflow.ExecuteActivity(attemptCtx, activity, req)
+		if attempt == 1 && req.Command == types.Sync {
+			waitForTimeoutWarning(ctx, req, future, options.StartToCloseTimeout)
+		}
+		err := future.Get(ctx, &result)
+		if err == nil || !isRetryableAttemptError(err) {
+			return result, err
+		}
+
+		workflow.GetLogger(ctx).Warn("sync attempt failed, retrying", "jobID", req.JobID, "attempt", attempt, "retryIn", delay, "error", err)
+		if err := workflow.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		delay = min(time.Duration(float64(delay)*SyncRetryPolicy.BackoffCoefficient), SyncRetryPolicy.MaximumInterval)
+	}
+}
+
+// isRetryableAttemptError reports whether the server would have retried the failed attempt
+// under SyncRetryPolicy
+func isRetryableAttemptError(err error) bool {
+	if temporal.IsCanceledError(err) {
+		return false
+	}
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.NonRetryable() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,222 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// specTimeout bounds the spec run used to validate a config before discover or sync
+const specTimeout = 5 * time.Minute
+
+// validateConnectorConfig runs the connector's spec and checks the --config file against it
+// before an operation listed in VALIDATE_CONFIG_BEFORE, failing fast with field-level errors.
+// Problems with the validation itself (spec failing, no schema) are logged and never block the run.
+func (a *Activity) validateConnectorConfig(ctx context.Context, req *types.ExecutionRequest) error {
+	if !slices.Contains(configValidationOperations(), req.Command) {
+		return nil
+	}
+
+	log := logger.Log(ctx)
+	config, found := utils.GetConfigFileArg(req)
+	if !found {
+		log.Debug("no --config file found, skipping config validation", "command", req.Command)
+		return nil
+	}
+
+	specReq := &types.ExecutionRequest{
+		Command:       types.Spec,
+		ConnectorType: req.ConnectorType,
+		Version:       req.Version,
+		Args:          []string{string(types.Spec)},
+		WorkflowID:    fmt.Sprintf("%s-spec", req.WorkflowID),
+		JobID:         req.JobID,
+		ProjectID:     req.ProjectID,
+		Timeout:       specTimeout,
+		HeartbeatFunc: req.HeartbeatFunc,
+	}
+
+	result, err := a.executor.Execute(ctx, specReq)
+	if err != nil {
+		log.Warn("failed to run connector spec, skipping config validation", "connector", req.ConnectorType, "error", err)
+		return nil
+	}
+
+	specOutput, err := utils.ReadFile(filepath.Join(utils.GetConfigDir(), result.Response))
+	if err != nil {
+		log.Warn("failed to read connector spec, skipping config validation", "connector", req.ConnectorType, "error", err)
+		return nil
+	}
+
+	schema, err := utils.ExtractSpecSchema([]byte(specOutput))
+	if err != nil {
+		log.Warn("failed to extract config schema from spec, skipping config validation", "connector", req.ConnectorType, "error", err)
+		return nil
+	}
+
+	fieldErrs, err := utils.ValidateConfigAgainstSchema(config.Data, schema)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "ConfigValidationFailed", err)
+	}
+	if len(fieldErrs) > 0 {
+		errMsg := fmt.Sprintf("%s config is invalid: %s", config.Name, strings.Join(fieldErrs, "; "))
+		log.Error("config validation failed", "connector", req.ConnectorType, "errors", fieldErrs)
+		return temporal.NewNonRetryableApplicationError(errMsg, "ConfigValidationFailed", nil)
+	}
+
+	log.Info("config validated against connector spec", "connector", req.ConnectorType, "command", req.Command)
+	return nil
+}
+
+// configValidationOperations returns the operations configured in VALIDATE_CONFIG_BEFORE
+func configValidationOperations() []types.Command {
+	var operations []types.Command
+	for _, op := range strings.Split(viper.GetString(constants.EnvValidateConfigBefore), ",") {
+		if op = strings.ToLower(strings.TrimSpace(op)); op != "" {
+			operations = append(operations, types.Command(op))
+		}
+	}
+	return operations
+}
+
+// validateStreamsConfig canonicalizes streams.json before a sync according to STREAMS_VALIDATION.
+// In warn mode the problems found are logged and the cleaned-up catalog is used; in fail mode
+// any problem fails the sync before the connector starts.
+func validateStreamsConfig(ctx context.Context, req *types.ExecutionRequest) error {
+	mode := strings.ToLower(strings.TrimSpace(viper.GetString(constants.EnvStreamsValidation)))
+	if mode == "" || mode == utils.StreamsValidationOff {
+		return nil
+	}
+
+	log := logger.Log(ctx)
+	idx := slices.IndexFunc(req.Configs, func(config types.JobConfig) bool { return config.Name == "streams.json" })
+	if idx < 0 || strings.TrimSpace(req.Configs[idx].Data) == "" {
+		return nil
+	}
+
+	canonical, problems, err := utils.CanonicalizeStreams(req.Configs[idx].Data)
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "StreamsValidationFailed", err)
+	}
+	if len(problems) > 0 && mode == utils.StreamsValidationFail {
+		errMsg := fmt.Sprintf("streams config is invalid: %s", strings.Join(problems, "; "))
+		log.Error("streams validation failed", "jobID", req.JobID, "problems", problems)
+		return temporal.NewNonRetryableApplicationError(errMsg, "StreamsValidationFailed", nil)
+	}
+	if len(problems) > 0 {
+		log.Warn("streams config had problems, using canonicalized streams", "jobID", req.JobID, "problems", problems)
+	}
+
+	req.Configs[idx].Data = canonical
+	return nil
+}
+
+// ValidateJobActivity is a pre-flight for a job: it runs the connector's check against the
+// job's source and destination configs and parses its streams config, without syncing.
+// Failed steps are reported in the result, only failing to load the job is an error.
+func (a *Activity) ValidateJobActivity(ctx context.Context, req *types.ExecutionRequest) (*types.JobValidationResult, error) {
+	log := logger.Log(ctx)
+	log.Info("validating job", "jobID", req.JobID, "workflowID", req.WorkflowID)
+
+	activity.RecordHeartbeat(ctx, "validating job %d", req.JobID)
+	req.HeartbeatFunc = activity.RecordHeartbeat
+
+	jobDetails, err := a.db.GetJobData(ctx, req.JobID)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get job data: %s", err)
+		return nil, temporal.NewNonRetryableApplicationError(errMsg, "DatabaseError", err)
+	}
+
+	steps := []types.ValidationStep{
+		a.runJobCheck(ctx, req, jobDetails, types.CheckSource, jobDetails.Source),
+		a.runJobCheck(ctx, req, jobDetails, types.CheckDestination, jobDetails.Destination),
+		validateJobStreams(jobDetails.Streams),
+	}
+
+	result := &types.JobValidationResult{JobID: req.JobID, Valid: true, Steps: steps}
+	for _, step := range steps {
+		result.Valid = result.Valid && step.Passed
+	}
+
+	log.Info("job validation finished", "jobID", req.JobID, "valid", result.Valid)
+	return result, nil
+}
+
+// runJobCheck runs the connector's check command for one of the job's configs
+func (a *Activity) runJobCheck(ctx context.Context, req *types.ExecutionRequest, jobDetails types.JobData, target types.CheckTarget, config string) types.ValidationStep {
+	log := logger.Log(ctx)
+	step := string(target)
+	fileName, err := utils.CheckTargetFile(target)
+	if err != nil {
+		return types.ValidationStep{Name: step, Message: err.Error()}
+	}
+	if strings.TrimSpace(config) == "" {
+		return types.ValidationStep{Name: step, Message: fmt.Sprintf("%s is empty", fileName)}
+	}
+	args, err := utils.BuildCheckArgs(target)
+	if err != nil {
+		return types.ValidationStep{Name: step, Message: err.Error()}
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = specTimeout
+	}
+	checkReq := &types.ExecutionRequest{
+		Command:       types.Check,
+		ConnectorType: jobDetails.Driver,
+		Version:       jobDetails.Version,
+		Args:          args,
+		Configs:       []types.JobConfig{{Name: fileName, Data: config}},
+		WorkflowID:    fmt.Sprintf("%s-%s-check", req.WorkflowID, step),
+		JobID:         req.JobID,
+		ProjectID:     jobDetails.ProjectID,
+		Timeout:       timeout,
+		HeartbeatFunc: req.HeartbeatFunc,
+	}
+
+	result, err := a.executor.Execute(ctx, checkReq)
+	if err != nil {
+		log.Warn("job validation check failed to run", "jobID", req.JobID, "step", step, "error", err)
+		return types.ValidationStep{Name: step, Message: fmt.Sprintf("check failed to run: %s", err)}
+	}
+
+	output, err := utils.ReadFile(filepath.Join(utils.GetConfigDir(), result.Response))
+	if err != nil {
+		return types.ValidationStep{Name: step, Message: fmt.Sprintf("failed to read check output: %s", err)}
+	}
+
+	passed, message, err := utils.ParseConnectionStatus([]byte(output))
+	if err != nil {
+		return types.ValidationStep{Name: step, Message: err.Error()}
+	}
+	return types.ValidationStep{Name: step, Passed: passed, Message: message}
+}
+
+// validateJobStreams checks that the job's streams config parses and is free of the problems
+// STREAMS_VALIDATION would clean up
+func validateJobStreams(streams string) types.ValidationStep {
+	if strings.TrimSpace(streams) == "" {
+		return types.ValidationStep{Name: "streams", Message: "streams.json is empty"}
+	}
+
+	_, problems, err := utils.CanonicalizeStreams(streams)
+	if err != nil {
+		return types.ValidationStep{Name: "streams", Message: err.Error()}
+	}
+	if len(problems) > 0 {
+		return types.ValidationStep{Name: "streams", Message: strings.Join(problems, "; ")}
+	}
+	return types.ValidationStep{Name: "streams", Passed: true}
+}
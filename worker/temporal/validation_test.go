@@ -0,0 +1,62 @@
+package temporal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+func TestValidateStreamsConfig(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvStreamsValidation, nil) })
+
+	const (
+		duplicated = `{"streams":[{"stream":{"name":"orders"}},{"stream":{"name":"orders"}}]}`
+		deduped    = `{"streams":[{"stream":{"name":"orders"}}]}`
+	)
+	tests := []struct {
+		name    string
+		mode    string
+		streams string
+		want    string
+		wantErr string
+	}{
+		{name: "off", mode: utils.StreamsValidationOff, streams: duplicated, want: duplicated},
+		{name: "unset", streams: `{"streams":`, want: `{"streams":`},
+		{name: "warn", mode: " WARN ", streams: duplicated, want: deduped},
+		{name: "fail", mode: utils.StreamsValidationFail, streams: duplicated, wantErr: "streams config is invalid: duplicate stream .orders, dropped"},
+		{name: "fail on a clean catalog", mode: utils.StreamsValidationFail, streams: deduped, want: deduped},
+		{name: "unreadable catalog", mode: utils.StreamsValidationWarn, streams: `{"streams":`, wantErr: "failed to parse streams config"},
+		{name: "empty catalog", mode: utils.StreamsValidationFail, streams: " ", want: " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvStreamsValidation, tt.mode)
+			req := &types.ExecutionRequest{JobID: 7, Configs: []types.JobConfig{{Name: "source.json", Data: "{}"}, {Name: "streams.json", Data: tt.streams}}}
+
+			err := validateStreamsConfig(context.Background(), req)
+			if tt.wantErr != "" {
+				var appErr *temporal.ApplicationError
+				require.ErrorAs(t, err, &appErr)
+				require.Equal(t, "StreamsValidationFailed", appErr.Type())
+				require.True(t, appErr.NonRetryable())
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, req.Configs[1].Data)
+			require.Equal(t, "{}", req.Configs[0].Data)
+		})
+	}
+
+	// syncs of jobs without a streams config are left alone
+	viper.Set(constants.EnvStreamsValidation, utils.StreamsValidationFail)
+	require.NoError(t, validateStreamsConfig(context.Background(), &types.ExecutionRequest{JobID: 7}))
+}
@@ -3,11 +3,13 @@ package temporal
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/database"
 	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
 
 	"github.com/datazip-inc/olake-helm/worker/executor"
 	enums "go.temporal.io/api/enums/v1"
@@ -31,12 +33,20 @@ func NewWorker(ctx context.Context, t *Temporal, e *executor.AbstractExecutor, d
 		Interceptors: []interceptor.WorkerInterceptor{
 			NewLoggingInterceptor(),
 		},
+		MaxConcurrentActivityExecutionSize:     concurrencyLimit(constants.EnvMaxConcurrentActivities),
+		MaxConcurrentWorkflowTaskExecutionSize: concurrencyLimit(constants.EnvMaxConcurrentWorkflows),
+		WorkerStopTimeout:                      viper.GetDuration(constants.EnvWorkerDrainTimeout),
 	}
-	w := worker.New(t.GetClient(), utils.GetTemporalTaskQueue(), workerOptions)
+	logger.Infof("worker concurrency limits: activities=%s, workflow tasks=%s",
+		describeLimit(workerOptions.MaxConcurrentActivityExecutionSize), describeLimit(workerOptions.MaxConcurrentWorkflowTaskExecutionSize))
+	taskQueue := utils.GetTemporalTaskQueue()
+	logger.Infof("polling task queue %s in namespace %s", taskQueue, utils.GetTemporalNamespace())
+	w := worker.New(t.GetClient(), taskQueue, workerOptions)
 
 	// regsiter workflows
 	w.RegisterWorkflow(RunSyncWorkflow)
 	w.RegisterWorkflow(ExecuteWorkflow)
+	w.RegisterWorkflow(ValidateJobWorkflow)
 	// w.RegisterWorkflow(ExecuteClearWorkflow)
 
 	// regsiter activities
@@ -46,6 +56,8 @@ func NewWorker(ctx context.Context, t *Temporal, e *executor.AbstractExecutor, d
 	w.RegisterActivity(activitiesInstance.PostSyncActivity)
 	w.RegisterActivity(activitiesInstance.PostClearActivity)
 	w.RegisterActivity(activitiesInstance.SendWebhookNotificationActivity)
+	w.RegisterActivity(activitiesInstance.SendTimeoutWarningActivity)
+	w.RegisterActivity(activitiesInstance.ValidateJobActivity)
 
 	searchAttributes := map[string]enums.IndexedValueType{constants.OperationTypeKey: enums.INDEXED_VALUE_TYPE_KEYWORD}
 
@@ -81,7 +93,37 @@ func (w *Worker) Start() error {
 	return w.worker.Start()
 }
 
-// Stop stops the worker and closes the client
+// Stop stops polling for new tasks, gives running activities up to WORKER_DRAIN_TIMEOUT to
+// finish and then cancels the ones still running. Cancelled sync activities are retried by
+// Temporal on another worker once their heartbeat times out.
 func (w *Worker) Stop() {
+	drainTimeout := viper.GetDuration(constants.EnvWorkerDrainTimeout)
+	logger.Infof("draining worker: %d activities running, waiting up to %s", runningActivities.Load(), drainTimeout)
+
 	w.worker.Stop()
+
+	if remaining := runningActivities.Load(); remaining > 0 {
+		logger.Warnf("worker stopped with %d activities still running, they were cancelled", remaining)
+	}
+}
+
+// concurrencyLimit reads a worker concurrency limit; unset or invalid values return 0 so the
+// Temporal SDK default applies
+func concurrencyLimit(key string) int {
+	if !viper.IsSet(key) {
+		return 0
+	}
+	limit := viper.GetInt(key)
+	if limit <= 0 {
+		logger.Warnf("invalid %s %d, must be a positive integer. using Temporal default", key, limit)
+		return 0
+	}
+	return limit
+}
+
+func describeLimit(limit int) string {
+	if limit == 0 {
+		return "temporal default"
+	}
+	return strconv.Itoa(limit)
 }
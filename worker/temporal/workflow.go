@@ -1,12 +1,16 @@
 package temporal
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/spf13/viper"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
@@ -17,6 +21,8 @@ const (
 	PostSyncActivity                = "PostSyncActivity"
 	PostClearActivity               = "PostClearActivity"
 	SendWebhookNotificationActivity = "SendWebhookNotificationActivity"
+	SendTimeoutWarningActivity      = "SendTimeoutWarningActivity"
+	ValidateJobActivity             = "ValidateJobActivity"
 )
 
 // Retry policy for non-sync activities (discover, test, spec, cleanup)
@@ -43,6 +49,7 @@ func ExecuteWorkflow(ctx workflow.Context, req *types.ExecutionRequest) (*types.
 	}
 
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	req.Memo = workflowMemo(ctx)
 
 	var result *types.ExecutorResponse
 	if err := workflow.ExecuteActivity(ctx, ExecuteActivity, req).Get(ctx, &result); err != nil {
@@ -51,25 +58,47 @@ func ExecuteWorkflow(ctx workflow.Context, req *types.ExecutionRequest) (*types.
 	return result, nil
 }
 
+// ValidateJobWorkflow checks a job's source and destination connections and its streams
+// config without starting a sync. Only req.JobID is required; the configs are loaded from
+// the job, and req.Timeout bounds each connector check.
+func ValidateJobWorkflow(ctx workflow.Context, req *types.ExecutionRequest) (*types.JobValidationResult, error) {
+	activityOptions := workflow.ActivityOptions{
+		// two connector checks run back to back
+		StartToCloseTimeout: 2*max(req.Timeout, specTimeout) + time.Minute,
+		RetryPolicy:         DefaultRetryPolicy,
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	req.WorkflowID = workflow.GetInfo(ctx).WorkflowExecution.ID
+
+	var result *types.JobValidationResult
+	if err := workflow.ExecuteActivity(ctx, ValidateJobActivity, req).Get(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // RunSyncWorkflow is a Temporal workflow that orchestrates long-running data operations:
 //
 // Supported Commands:
 //   - Sync: Performs data replication from source to destination
-//   - ClearDestination: Clears data from the destination
+//   - ClearDestination: Clears data from the destination, or with DryRun returns the
+//     connector's plan of what would be deleted as the result
 //
 // Features:
 //   - Infinite retries (MaximumAttempts: 0) with exponential backoff for transient errors
-//   - Heartbeat monitoring (30s) to detect worker failures
+//   - Heartbeat monitoring (SYNC_HEARTBEAT_TIMEOUT) to detect worker failures
 //   - Graceful cleanup via deferred activity (runs even on cancellation)
+//   - pause-sync/resume-sync signals holding off the next activity attempt while paused
 //
-// HeartbeatTimeout: 30 seconds
+// HeartbeatTimeout: SYNC_HEARTBEAT_TIMEOUT (default 30 seconds)
 // Heartbeats are throttled at timeout * 0.8 = 24s intervals.
 // Faster heartbeats enable quicker cancellation detection and worker failure recovery.
 func RunSyncWorkflow(ctx workflow.Context, args interface{}) (result *types.ExecutorResponse, err error) {
 	workflowLogger := workflow.GetLogger(ctx)
 	activityOptions := workflow.ActivityOptions{
 		StartToCloseTimeout: constants.DefaultSyncTimeout,
-		HeartbeatTimeout:    30 * time.Second,
+		HeartbeatTimeout:    syncHeartbeatTimeout(ctx),
 		WaitForCancellation: true,
 		RetryPolicy:         SyncRetryPolicy,
 	}
@@ -79,8 +108,16 @@ func RunSyncWorkflow(ctx workflow.Context, args interface{}) (result *types.Exec
 		return nil, err
 	}
 
+	activityOptions.StartToCloseTimeout = syncTimeout(ctx, req.ConnectorType)
+	req.Timeout = activityOptions.StartToCloseTimeout
+	workflowLogger.Info("sync activity timeout", "jobID", req.JobID, "connector", req.ConnectorType, "timeout", activityOptions.StartToCloseTimeout)
+
 	ctx = workflow.WithActivityOptions(ctx, activityOptions)
 	req.WorkflowID = workflow.GetInfo(ctx).WorkflowExecution.ID
+	req.RunStartedAt = workflow.GetInfo(ctx).WorkflowStartTime
+	req.Memo = workflowMemo(ctx)
+	req.Trigger = workflowTrigger(ctx)
+	registerLogTailQuery(ctx, req)
 
 	var activity, cleanupActivity string
 	switch req.Command {
@@ -94,6 +131,11 @@ func RunSyncWorkflow(ctx workflow.Context, args interface{}) (result *types.Exec
 
 	// Defer cleanup - runs on both normal completion and cancellation
 	defer func() {
+		req.Status = resolveSyncStatus(result, err)
+		// the workflow result tells olake-ui a skipped run from a completed one
+		if result != nil {
+			result.Status = req.Status
+		}
 		newCtx, _ := workflow.NewDisconnectedContext(ctx)
 		cleanupOtions := workflow.ActivityOptions{
 			StartToCloseTimeout: time.Minute * 15,
@@ -116,7 +158,25 @@ func RunSyncWorkflow(ctx workflow.Context, args interface{}) (result *types.Exec
 		workflowLogger.Error("failed to upsert search attributes", "error", err)
 	}
 
-	err = workflow.ExecuteActivity(ctx, activity, req).Get(ctx, &result)
+	// stagger sync starts so schedules firing together don't hit the source at once
+	if req.Command == types.Sync {
+		if jitter := syncStartJitter(ctx); jitter > 0 {
+			workflowLogger.Info("delaying sync start", "jobID", req.JobID, "jitter", jitter)
+			if err := workflow.Sleep(ctx, jitter); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if workflow.GetVersion(ctx, "sync-pause", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		syncFuture := workflow.ExecuteActivity(ctx, activity, req)
+		if req.Command == types.Sync {
+			waitForTimeoutWarning(ctx, req, syncFuture, activityOptions.StartToCloseTimeout)
+		}
+		err = syncFuture.Get(ctx, &result)
+	} else {
+		result, err = runPausableAttempts(ctx, activity, req, activityOptions)
+	}
 	if err != nil {
 		// Skip webhook for cancellations
 		if temporal.IsCanceledError(err) {
@@ -139,9 +199,203 @@ func RunSyncWorkflow(ctx workflow.Context, args interface{}) (result *types.Exec
 			LastRunTime:  lastRunTime,
 			ErrorMessage: err.Error(),
 		}
+		if exit, ok := connectorExit(err); ok {
+			webhookArgs.ExitReason, webhookArgs.ExitCode = exit.ExitReason, exit.ExitCode
+		}
 		workflow.ExecuteActivity(webhookCtx, SendWebhookNotificationActivity, webhookArgs)
 		return nil, err
 
 	}
 	return result, err
 }
+
+// registerLogTailQuery answers the sync-log-tail query, taking an optional line count, with the
+// end of the run's worker.log. The log is read from the shared job volume, so any worker can
+// answer it; a run whose activity hasn't started yet has no log to return.
+func registerLogTailQuery(ctx workflow.Context, req *types.ExecutionRequest) {
+	if err := workflow.SetQueryHandler(ctx, constants.SyncLogTailQuery, func(lines int) (string, error) {
+		return utils.TailWorkflowLog(req.WorkflowID, req.Command, lines)
+	}); err != nil {
+		workflow.GetLogger(ctx).Error("failed to register sync log tail query", "error", err)
+	}
+}
+
+// connectorExit returns how the connector container ended when the sync failed on its own
+func connectorExit(err error) (types.ConnectorExit, bool) {
+	var exit types.ConnectorExit
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || !appErr.HasDetails() {
+		return exit, false
+	}
+	if appErr.Details(&exit) != nil || exit.ExitReason == "" {
+		return exit, false
+	}
+	return exit, true
+}
+
+// resolveSyncStatus maps the sync activity outcome to the status reported during cleanup
+func resolveSyncStatus(result *types.ExecutorResponse, err error) types.SyncStatus {
+	switch {
+	case err != nil && temporal.IsCanceledError(err):
+		return types.SyncStatusCancelled
+	case err != nil:
+		return types.SyncStatusFailed
+	case result != nil && result.Status != "":
+		return result.Status
+	default:
+		return types.SyncStatusCompleted
+	}
+}
+
+// syncStartJitter returns a random delay within [0, SYNC_START_JITTER).
+// The value is recorded as a side effect so replays stay deterministic, and
+// versioned so histories from before the jitter existed still replay.
+func syncStartJitter(ctx workflow.Context) time.Duration {
+	if workflow.GetVersion(ctx, "sync-start-jitter", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return 0
+	}
+
+	var jitter time.Duration
+	encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		maxJitter := viper.GetDuration(constants.EnvSyncStartJitter)
+		if maxJitter <= 0 {
+			return time.Duration(0)
+		}
+		return time.Duration(rand.Int63n(int64(maxJitter)))
+	})
+	if err := encoded.Get(&jitter); err != nil {
+		workflow.GetLogger(ctx).Warn("failed to decode sync start jitter", "error", err)
+		return 0
+	}
+	return jitter
+}
+
+// syncHeartbeatTimeout returns SYNC_HEARTBEAT_TIMEOUT for the sync activity, recorded as a
+// side effect so a replay on a worker with different settings schedules the same activity.
+// Histories from before it was configurable keep the original 30 seconds. The executors poll
+// within the timeout the activity got (see utils.GetHeartbeatInterval), not the current setting.
+func syncHeartbeatTimeout(ctx workflow.Context) time.Duration {
+	if workflow.GetVersion(ctx, "sync-heartbeat-timeout", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return constants.DefaultHeartbeatTimeout
+	}
+
+	var timeout time.Duration
+	encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return viper.GetDuration(constants.EnvSyncHeartbeatTimeout)
+	})
+	if err := encoded.Get(&timeout); err != nil || timeout <= 0 {
+		return constants.DefaultHeartbeatTimeout
+	}
+	return timeout
+}
+
+// syncTimeout returns the connector's sync timeout from SYNC_TIMEOUT_OVERRIDES, recorded as a
+// side effect so replays keep the timeout the run started with. Legacy requests, which only
+// learn their connector type in the activity, use the default.
+func syncTimeout(ctx workflow.Context, connectorType string) time.Duration {
+	if workflow.GetVersion(ctx, "sync-timeout-overrides", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return constants.DefaultSyncTimeout
+	}
+
+	var timeout time.Duration
+	encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return utils.GetSyncTimeout(connectorType)
+	})
+	if err := encoded.Get(&timeout); err != nil || timeout <= 0 {
+		return constants.DefaultSyncTimeout
+	}
+	return timeout
+}
+
+// waitForTimeoutWarning blocks until the sync activity finishes or has run for
+// SYNC_TIMEOUT_WARNING_THRESHOLD of its timeout, whichever comes first. In the latter
+// case a warning is sent without waiting for it; the sync itself is left untouched.
+func waitForTimeoutWarning(ctx workflow.Context, req *types.ExecutionRequest, syncFuture workflow.Future, timeout time.Duration) {
+	warnAfter := syncTimeoutWarningAfter(ctx, timeout)
+	if warnAfter <= 0 {
+		return
+	}
+
+	startedAt := workflow.Now(ctx)
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddFuture(syncFuture, func(workflow.Future) {})
+	selector.AddFuture(workflow.NewTimer(timerCtx, warnAfter), func(f workflow.Future) {
+		if err := f.Get(timerCtx, nil); err != nil {
+			return
+		}
+		workflow.GetLogger(ctx).Warn("sync crossed timeout warning threshold", "jobID", req.JobID, "elapsed", warnAfter, "timeout", timeout)
+
+		disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
+		warningCtx := workflow.WithActivityOptions(disconnectedCtx, workflow.ActivityOptions{
+			StartToCloseTimeout: time.Minute * 1,
+			RetryPolicy:         DefaultRetryPolicy,
+		})
+		workflow.ExecuteActivity(warningCtx, SendTimeoutWarningActivity, types.TimeoutWarningArgs{
+			JobID:     req.JobID,
+			ProjectID: req.ProjectID,
+			StartedAt: startedAt,
+			Elapsed:   warnAfter,
+			Timeout:   timeout,
+		})
+	})
+	selector.Select(ctx)
+}
+
+// syncTimeoutWarningAfter returns how long a sync may run before the timeout warning fires,
+// or 0 when SYNC_TIMEOUT_WARNING_THRESHOLD is not a fraction in (0, 1). Like the start jitter
+// it is recorded as a side effect and versioned so replays stay deterministic.
+func syncTimeoutWarningAfter(ctx workflow.Context, timeout time.Duration) time.Duration {
+	if workflow.GetVersion(ctx, "sync-timeout-warning", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return 0
+	}
+
+	var threshold float64
+	encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return viper.GetFloat64(constants.EnvSyncTimeoutWarningThreshold)
+	})
+	if err := encoded.Get(&threshold); err != nil {
+		workflow.GetLogger(ctx).Warn("failed to decode sync timeout warning threshold", "error", err)
+		return 0
+	}
+	if threshold <= 0 || threshold >= 1 {
+		return 0
+	}
+	return time.Duration(float64(timeout) * threshold)
+}
+
+// workflowMemo returns the string-valued fields of the workflow memo; other values are skipped
+func workflowMemo(ctx workflow.Context) map[string]string {
+	memo := workflow.GetInfo(ctx).Memo
+	if memo == nil || len(memo.GetFields()) == 0 {
+		return nil
+	}
+
+	dataConverter := converter.GetDefaultDataConverter()
+	fields := make(map[string]string, len(memo.GetFields()))
+	for key, payload := range memo.GetFields() {
+		var value string
+		if err := dataConverter.FromPayload(payload, &value); err == nil {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// workflowTrigger tells scheduled runs from manual ones using the search attributes Temporal
+// sets on workflows started by a schedule
+func workflowTrigger(ctx workflow.Context) *types.TriggerContext {
+	attributes := workflow.GetTypedSearchAttributes(ctx)
+	scheduleID, scheduled := attributes.GetKeyword(temporal.NewSearchAttributeKeyKeyword("TemporalScheduledById"))
+	if !scheduled || scheduleID == "" {
+		return &types.TriggerContext{Type: types.TriggerManual}
+	}
+
+	trigger := &types.TriggerContext{Type: types.TriggerScheduled, ScheduleID: scheduleID}
+	if scheduledTime, ok := attributes.GetTime(temporal.NewSearchAttributeKeyTime("TemporalScheduledStartTime")); ok {
+		trigger.ScheduledTime = scheduledTime
+	}
+	return trigger
+}
@@ -0,0 +1,325 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestResolveSyncStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *types.ExecutorResponse
+		err    error
+		want   types.SyncStatus
+	}{
+		{name: "completed", result: &types.ExecutorResponse{Response: "done"}, want: types.SyncStatusCompleted},
+		{name: "adopted completed run", result: &types.ExecutorResponse{Status: types.SyncStatusCompleted}, want: types.SyncStatusCompleted},
+		{name: "skipped", result: &types.ExecutorResponse{Status: types.SyncStatusSkipped}, want: types.SyncStatusSkipped},
+		{name: "failed", err: errors.New("connector exited with code 1"), want: types.SyncStatusFailed},
+		{name: "cancelled", err: temporal.NewCanceledError(), want: types.SyncStatusCancelled},
+		{name: "no result", want: types.SyncStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, resolveSyncStatus(tt.result, tt.err))
+		})
+	}
+}
+
+// pausableSyncWorkflow runs the sync attempts of RunSyncWorkflow without its setup and cleanup
+func pausableSyncWorkflow(ctx workflow.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+	return runPausableAttempts(ctx, SyncActivity, req, workflow.ActivityOptions{StartToCloseTimeout: time.Hour})
+}
+
+func TestPausedSyncStartsNoNewAttempt(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(pausableSyncWorkflow)
+
+	// the first attempt fails, so the next one waits for the retry backoff
+	var attempts atomic.Int32
+	env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+		if attempts.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return &types.ExecutorResponse{Response: "done"}, nil
+	}, activity.RegisterOptions{Name: SyncActivity})
+
+	isPaused := func() bool {
+		value, err := env.QueryWorkflow(constants.SyncPausedQuery)
+		require.NoError(t, err)
+		var paused bool
+		require.NoError(t, value.Get(&paused))
+		return paused
+	}
+
+	env.RegisterDelayedCallback(func() {
+		require.False(t, isPaused())
+		env.SignalWorkflow(constants.PauseSyncSignal, nil)
+	}, time.Second)
+
+	// long after the backoff, the paused sync still hasn't started its second attempt
+	env.RegisterDelayedCallback(func() {
+		require.True(t, isPaused())
+		require.EqualValues(t, 1, attempts.Load())
+		env.SignalWorkflow(constants.ResumeSyncSignal, nil)
+	}, time.Hour)
+
+	env.ExecuteWorkflow(pausableSyncWorkflow, &types.ExecutionRequest{JobID: 1, Command: types.Sync})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result *types.ExecutorResponse
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "done", result.Response)
+	require.EqualValues(t, 2, attempts.Load())
+}
+
+func TestRunSyncWorkflowReportsStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *types.ExecutorResponse
+		want     types.SyncStatus
+	}{
+		{name: "completed", response: &types.ExecutorResponse{Response: "done"}, want: types.SyncStatusCompleted},
+		{name: "skipped", response: &types.ExecutorResponse{Response: constants.SyncStatusSkippedMessage, Status: types.SyncStatusSkipped}, want: types.SyncStatusSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(RunSyncWorkflow)
+			env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+				return tt.response, nil
+			}, activity.RegisterOptions{Name: SyncActivity})
+
+			// the cleanup activity gets the same status
+			var cleanupStatus types.SyncStatus
+			env.RegisterActivityWithOptions(func(_ context.Context, req *types.ExecutionRequest) error {
+				cleanupStatus = req.Status
+				return nil
+			}, activity.RegisterOptions{Name: PostSyncActivity})
+
+			env.ExecuteWorkflow(RunSyncWorkflow, map[string]interface{}{"command": "sync", "job_id": 7})
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result *types.ExecutorResponse
+			require.NoError(t, env.GetWorkflowResult(&result))
+			require.Equal(t, tt.want, result.Status)
+			require.Equal(t, tt.want, cleanupStatus)
+		})
+	}
+}
+
+// timeoutWarningWorkflow runs a sync activity alongside its timeout warning
+func timeoutWarningWorkflow(ctx workflow.Context, timeout time.Duration) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: timeout})
+	req := &types.ExecutionRequest{JobID: 7, ProjectID: "project-a", Command: types.Sync}
+	syncFuture := workflow.ExecuteActivity(ctx, SyncActivity, req)
+	waitForTimeoutWarning(ctx, req, syncFuture, timeout)
+	return syncFuture.Get(ctx, nil)
+}
+
+func TestSyncTimeoutWarning(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvSyncTimeoutWarningThreshold, nil) })
+
+	tests := []struct {
+		name      string
+		threshold float64
+		syncTakes time.Duration
+		want      []types.TimeoutWarningArgs
+	}{
+		{
+			name:      "crosses threshold",
+			threshold: 0.8,
+			syncTakes: 55 * time.Minute,
+			want:      []types.TimeoutWarningArgs{{JobID: 7, ProjectID: "project-a", Elapsed: 48 * time.Minute, Timeout: time.Hour}},
+		},
+		{name: "finishes first", threshold: 0.8, syncTakes: 30 * time.Minute},
+		{name: "disabled", syncTakes: 55 * time.Minute},
+		{name: "threshold past the timeout", threshold: 1, syncTakes: 55 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvSyncTimeoutWarningThreshold, tt.threshold)
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(timeoutWarningWorkflow)
+			env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+				return nil, nil
+			}, activity.RegisterOptions{Name: SyncActivity})
+			var warnings []types.TimeoutWarningArgs
+			env.RegisterActivityWithOptions(func(_ context.Context, args types.TimeoutWarningArgs) error {
+				args.StartedAt = time.Time{}
+				warnings = append(warnings, args)
+				return nil
+			}, activity.RegisterOptions{Name: SendTimeoutWarningActivity})
+			env.OnActivity(SyncActivity, mock.Anything, mock.Anything).After(tt.syncTakes).Return(&types.ExecutorResponse{Response: "done"}, nil)
+
+			env.ExecuteWorkflow(timeoutWarningWorkflow, time.Hour)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			require.Equal(t, tt.want, warnings)
+		})
+	}
+}
+
+func TestSyncStartJitter(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvSyncStartJitter, nil) })
+
+	tests := []struct {
+		name   string
+		jitter time.Duration
+	}{
+		{name: "disabled"},
+		{name: "jittered", jitter: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvSyncStartJitter, tt.jitter)
+
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(RunSyncWorkflow)
+			var syncStarted time.Time
+			env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+				syncStarted = env.Now()
+				return &types.ExecutorResponse{Response: "done"}, nil
+			}, activity.RegisterOptions{Name: SyncActivity})
+			env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) error {
+				return nil
+			}, activity.RegisterOptions{Name: PostSyncActivity})
+
+			started := env.Now()
+			env.ExecuteWorkflow(RunSyncWorkflow, map[string]interface{}{"command": "sync", "job_id": 7})
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			delay := syncStarted.Sub(started)
+			if tt.jitter == 0 {
+				require.Zero(t, delay)
+				return
+			}
+			require.Positive(t, delay)
+			require.Less(t, delay, tt.jitter)
+		})
+	}
+}
+
+func TestRunSyncWorkflowPassesMemo(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(RunSyncWorkflow)
+	var memo map[string]string
+	env.RegisterActivityWithOptions(func(_ context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+		memo = req.Memo
+		return &types.ExecutorResponse{Response: "done"}, nil
+	}, activity.RegisterOptions{Name: SyncActivity})
+	env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) error {
+		return nil
+	}, activity.RegisterOptions{Name: PostSyncActivity})
+	// only string fields are copied
+	env.SetMemoOnStart(map[string]interface{}{"team": "growth", "priority": 3})
+
+	env.ExecuteWorkflow(RunSyncWorkflow, map[string]interface{}{"command": "sync", "job_id": 7})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	require.Equal(t, map[string]string{"team": "growth"}, memo)
+}
+
+func TestRunSyncWorkflowTrigger(t *testing.T) {
+	scheduledTime := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		attributes temporal.SearchAttributes
+		want       *types.TriggerContext
+	}{
+		{name: "manual", want: &types.TriggerContext{Type: types.TriggerManual}},
+		{
+			name: "scheduled",
+			attributes: temporal.NewSearchAttributes(
+				temporal.NewSearchAttributeKeyKeyword("TemporalScheduledById").ValueSet("schedule-sync-7"),
+				temporal.NewSearchAttributeKeyTime("TemporalScheduledStartTime").ValueSet(scheduledTime),
+			),
+			want: &types.TriggerContext{Type: types.TriggerScheduled, ScheduleID: "schedule-sync-7", ScheduledTime: scheduledTime},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(RunSyncWorkflow)
+			var trigger *types.TriggerContext
+			env.RegisterActivityWithOptions(func(_ context.Context, req *types.ExecutionRequest) (*types.ExecutorResponse, error) {
+				trigger = req.Trigger
+				return &types.ExecutorResponse{Response: "done"}, nil
+			}, activity.RegisterOptions{Name: SyncActivity})
+			env.RegisterActivityWithOptions(func(context.Context, *types.ExecutionRequest) error {
+				return nil
+			}, activity.RegisterOptions{Name: PostSyncActivity})
+			env.SetTypedSearchAttributesOnStart(tt.attributes)
+
+			env.ExecuteWorkflow(RunSyncWorkflow, map[string]interface{}{"command": "sync", "job_id": 7})
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			require.Equal(t, tt.want.Type, trigger.Type)
+			require.Equal(t, tt.want.ScheduleID, trigger.ScheduleID)
+			require.True(t, tt.want.ScheduledTime.Equal(trigger.ScheduledTime))
+		})
+	}
+}
+
+func TestSyncLogTailQuery(t *testing.T) {
+	req := &types.ExecutionRequest{JobID: 7, WorkflowID: fmt.Sprintf("sync-7-%d", time.Now().UnixNano()), Command: types.Sync}
+	_, workdir := utils.GetWorkflowDirAndSubDir(req.WorkflowID, req.Command)
+	t.Cleanup(func() { os.RemoveAll(workdir) })
+	require.NoError(t, utils.WriteFile(filepath.Join(workdir, "logs", "worker.log"), []byte("starting sync\nsynced 1200 records\n")))
+
+	// a sync that stays running while it is queried
+	syncWorkflow := func(ctx workflow.Context) error {
+		registerLogTailQuery(ctx, req)
+		return workflow.Sleep(ctx, time.Hour)
+	}
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(syncWorkflow)
+
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow(constants.SyncLogTailQuery, 1)
+		require.NoError(t, err)
+		var tail string
+		require.NoError(t, value.Get(&tail))
+		require.Equal(t, "synced 1200 records", tail)
+	}, time.Minute)
+
+	env.ExecuteWorkflow(syncWorkflow)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
@@ -26,10 +26,101 @@ type ExecutionRequest struct {
 	OutputFile    string        `json:"output_file"`
 	TempPath      string        `json:"temp_path"`
 
+	// flags appended to Args when the connector is started (e.g. --threads 8)
+	ExtraArgs []string `json:"extra_args,omitempty"`
+
+	// connector environment variables for this run, overriding the env propagated from the worker
+	Env map[string]string `json:"env,omitempty"`
+
+	// clear-destination only: report what would be deleted without deleting it
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// check only: which config the connection test runs against; the args are built by the
+	// worker when set (empty = args as sent)
+	CheckTarget CheckTarget `json:"check_target,omitempty"`
+
+	// gzip the result file (output_file, or the extracted output.json) and respond with the .gz
+	// path; empty = uncompressed
+	OutputCompression OutputCompression `json:"output_compression,omitempty"`
+
+	// discover only: run the connector even when DISCOVER_CACHE_TTL holds a cached catalog
+	ForceRefresh bool `json:"force_refresh,omitempty"`
+
+	// sync only: which state the run starts from
+	Options ExecutionOptions `json:"options"`
+
+	// set by the sync workflow before cleanup so the outcome can be reported
+	Status SyncStatus `json:"status,omitempty"`
+
+	// string fields of the workflow memo, copied by the workflow for pod labels/annotations
+	Memo map[string]string `json:"memo,omitempty"`
+
+	// what started this run, set by the sync workflow for connectors adapting to the trigger
+	Trigger *TriggerContext `json:"trigger,omitempty"`
+
+	// start time of the sync workflow, ordering the state writes of different runs of a job
+	RunStartedAt time.Time `json:"run_started_at,omitempty"`
+
+	// sync only: attempt number of a sync whose attempts are driven by the workflow, each of
+	// which is a single activity attempt
+	Attempt int `json:"attempt,omitempty"`
+
 	// k8s specific fields
 	HeartbeatFunc func(context.Context, ...interface{}) `json:"-"`
 }
 
+// CheckTarget is the side of a job a connection check validates
+type CheckTarget string
+
+const (
+	CheckSource      CheckTarget = "source"
+	CheckDestination CheckTarget = "destination"
+)
+
+// OutputCompression is how the result file of a command is written
+type OutputCompression string
+
+const (
+	OutputCompressionNone OutputCompression = ""
+	OutputCompressionGzip OutputCompression = "gzip"
+)
+
+// ExecutionOptions changes the state a sync starts from for a single run. The job's saved
+// state is only replaced by the state the run ends with.
+type ExecutionOptions struct {
+	// start as if the job had never synced (full refresh)
+	UseEmptyState bool `json:"use_empty_state,omitempty"`
+	// start from a past state: a state history id or an RFC 3339 timestamp
+	FromStateRef string `json:"from_state_ref,omitempty"`
+}
+
+// OverridesState reports whether the run does not start from the job's saved state
+func (o ExecutionOptions) OverridesState() bool {
+	return o.UseEmptyState || o.FromStateRef != ""
+}
+
+// Trigger types of a sync run
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// TriggerContext describes what started a sync run
+type TriggerContext struct {
+	Type          string    `json:"type"`
+	ScheduleID    string    `json:"schedule_id,omitempty"`
+	ScheduledTime time.Time `json:"scheduled_time,omitempty"`
+	Cron          string    `json:"cron,omitempty"` // schedule cron expressions, resolved by the activity
+}
+
 type ExecutorResponse struct {
-	Response string `json:"response"`
+	Response string     `json:"response"`
+	Status   SyncStatus `json:"status,omitempty"`
+}
+
+// ResourceSample is a point-in-time reading of the connector container's resource usage
+type ResourceSample struct {
+	CPUCores         float64
+	MemoryBytes      int64
+	MemoryLimitBytes int64 // 0 when the container has no limit or the source doesn't report it
 }
@@ -45,6 +45,69 @@ type WebhookNotificationArgs struct {
 	ProjectID    string
 	LastRunTime  time.Time
 	ErrorMessage string
+	// how the connector container ended, when it failed on its own (e.g. OOMKilled)
+	ExitReason string
+	ExitCode   int
+}
+
+// ConnectorExit is attached to a failed sync's error so the workflow and the UI can see how
+// the connector container ended
+type ConnectorExit struct {
+	ExitCode   int    `json:"exit_code"`
+	ExitReason string `json:"exit_reason"`
+}
+
+// TimeoutWarningArgs describes a sync that has run past the warning threshold of its timeout
+type TimeoutWarningArgs struct {
+	JobID     int
+	ProjectID string
+	StartedAt time.Time
+	Elapsed   time.Duration
+	Timeout   time.Duration
+}
+
+// ValidationStep is the outcome of one pre-flight check of a job
+type ValidationStep struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// JobValidationResult is the combined outcome of validating a job without syncing it
+type JobValidationResult struct {
+	JobID int              `json:"job_id"`
+	Valid bool             `json:"valid"`
+	Steps []ValidationStep `json:"steps"`
+}
+
+// SyncStatus is the outcome of a sync run reported back to olake-ui
+type SyncStatus string
+
+const (
+	SyncStatusCompleted SyncStatus = "completed"
+	SyncStatusSkipped   SyncStatus = "skipped" // adopted or already-handled run, no new sync performed
+	SyncStatusFailed    SyncStatus = "failed"
+	SyncStatusCancelled SyncStatus = "cancelled"
+)
+
+// PostSyncHookPayload is posted to a project's post-sync hook once a sync completes, so
+// downstream runs (dbt, data quality checks) can be triggered with what was synced
+type PostSyncHookPayload struct {
+	JobID           int                  `json:"job_id"`
+	JobName         string               `json:"job_name"`
+	ProjectID       string               `json:"project_id"`
+	WorkflowID      string               `json:"workflow_id"`
+	Status          SyncStatus           `json:"status"`
+	FinishedAt      time.Time            `json:"finished_at"`
+	DurationSeconds float64              `json:"duration_seconds,omitempty"`
+	SyncedRecords   *int64               `json:"synced_records,omitempty"`
+	Streams         []PostSyncHookStream `json:"streams"`
+}
+
+// PostSyncHookStream is a stream in the final state of a completed sync
+type PostSyncHookStream struct {
+	Namespace string `json:"namespace,omitempty"`
+	Stream    string `json:"stream"`
 }
 
 type Result struct {
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+)
+
+// reservedConnectorFlags point the connector at the files the worker writes and reads back, or
+// change what a run does; extra args can't set them
+var reservedConnectorFlags = []string{
+	"--config",
+	"--destination",
+	"--catalog",
+	"--streams",
+	constants.StateFlag,
+	constants.DryRunFlag,
+}
+
+// ValidateExtraArgs checks that extra connector args don't set a flag the worker controls
+func ValidateExtraArgs(args []string) error {
+	for _, arg := range args {
+		flag, _, _ := strings.Cut(arg, "=")
+		if slices.Contains(reservedConnectorFlags, flag) {
+			return fmt.Errorf("flag %s is set by the worker and can't be passed as an extra arg", flag)
+		}
+	}
+	return nil
+}
+
+// AppendExtraArgs returns the connector args followed by each set of extra args, in order,
+// so later sets win for connectors taking the last value of a repeated flag. A set containing
+// a reserved flag is dropped whole, as its values would no longer line up with their flags.
+func AppendExtraArgs(args []string, extras ...[]string) []string {
+	result := slices.Clone(args)
+	for _, extra := range extras {
+		if len(extra) == 0 {
+			continue
+		}
+		if err := ValidateExtraArgs(extra); err != nil {
+			logger.Warnf("ignoring extra connector args %v: %s", extra, err)
+			continue
+		}
+		result = append(result, extra...)
+	}
+	return result
+}
+
+// checkTargetFiles are the connector flag and config file a check of each target runs against
+var checkTargetFiles = map[types.CheckTarget][2]string{
+	types.CheckSource:      {"--config", "source.json"},
+	types.CheckDestination: {"--destination", "destination.json"},
+}
+
+// CheckTargetFile returns the config file name a check of the target reads
+func CheckTargetFile(target types.CheckTarget) (string, error) {
+	files, found := checkTargetFiles[target]
+	if !found {
+		return "", fmt.Errorf("unknown check target %q", target)
+	}
+	return files[1], nil
+}
+
+// BuildCheckArgs returns the connector args of a connection check against the target's config
+// file, e.g. `check --destination /mnt/config/destination.json` for a writer config
+func BuildCheckArgs(target types.CheckTarget) ([]string, error) {
+	files, found := checkTargetFiles[target]
+	if !found {
+		return nil, fmt.Errorf("unknown check target %q", target)
+	}
+	return []string{string(types.Check), files[0], filepath.Join(constants.ContainerMountDir, files[1])}, nil
+}
+
+// ApplyCheckTarget sets the args of a check request with a CheckTarget, after making sure the
+// request carries the target's config file
+func ApplyCheckTarget(req *types.ExecutionRequest) error {
+	if req.Command != types.Check || req.CheckTarget == "" {
+		return nil
+	}
+
+	args, err := BuildCheckArgs(req.CheckTarget)
+	if err != nil {
+		return err
+	}
+	fileName, _ := CheckTargetFile(req.CheckTarget)
+	if !slices.ContainsFunc(req.Configs, func(config types.JobConfig) bool { return config.Name == fileName }) {
+		return fmt.Errorf("%s check requires a %s config", req.CheckTarget, fileName)
+	}
+	req.Args = args
+	return nil
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExtraArgs(t *testing.T) {
+	require.NoError(t, ValidateExtraArgs(nil))
+	require.NoError(t, ValidateExtraArgs([]string{"--threads", "8", "--batch-size=1000"}))
+	require.EqualError(t, ValidateExtraArgs([]string{"--threads", "8", "--state", "/tmp/state.json"}), "flag --state is set by the worker and can't be passed as an extra arg")
+	require.EqualError(t, ValidateExtraArgs([]string{"--config=/tmp/source.json"}), "flag --config is set by the worker and can't be passed as an extra arg")
+	require.Error(t, ValidateExtraArgs([]string{"--dry-run"}))
+}
+
+func TestAppendExtraArgs(t *testing.T) {
+	args := []string{"sync", "--config", "/mnt/config/source.json"}
+
+	got := AppendExtraArgs(args, []string{"--threads", "4"}, nil, []string{"--threads", "8"})
+	require.Equal(t, []string{"sync", "--config", "/mnt/config/source.json", "--threads", "4", "--threads", "8"}, got)
+	// the request's args are left as they were
+	require.Len(t, args, 3)
+
+	// a set with a reserved flag is dropped whole
+	got = AppendExtraArgs(args, []string{"--catalog", "/tmp/streams.json"}, []string{"--threads", "8"})
+	require.Equal(t, []string{"sync", "--config", "/mnt/config/source.json", "--threads", "8"}, got)
+}
+
+func TestBuildCheckArgs(t *testing.T) {
+	args, err := BuildCheckArgs(types.CheckSource)
+	require.NoError(t, err)
+	require.Equal(t, []string{"check", "--config", "/mnt/config/source.json"}, args)
+
+	args, err = BuildCheckArgs(types.CheckDestination)
+	require.NoError(t, err)
+	require.Equal(t, []string{"check", "--destination", "/mnt/config/destination.json"}, args)
+
+	_, err = BuildCheckArgs("catalog")
+	require.EqualError(t, err, `unknown check target "catalog"`)
+}
+
+func TestApplyCheckTarget(t *testing.T) {
+	destination := []types.JobConfig{{Name: "destination.json", Data: `{"type":"ICEBERG"}`}}
+	tests := []struct {
+		name     string
+		req      *types.ExecutionRequest
+		wantArgs []string
+		wantErr  string
+	}{
+		{
+			name:     "destination",
+			req:      &types.ExecutionRequest{Command: types.Check, CheckTarget: types.CheckDestination, Args: []string{"check", "--config", "/mnt/config/source.json"}, Configs: destination},
+			wantArgs: []string{"check", "--destination", "/mnt/config/destination.json"},
+		},
+		{
+			name:    "missing config",
+			req:     &types.ExecutionRequest{Command: types.Check, CheckTarget: types.CheckSource, Configs: destination},
+			wantErr: "source check requires a source.json config",
+		},
+		{
+			name:    "unknown target",
+			req:     &types.ExecutionRequest{Command: types.Check, CheckTarget: "catalog", Configs: destination},
+			wantErr: `unknown check target "catalog"`,
+		},
+		{
+			// without a target the args are used as sent
+			name:     "no target",
+			req:      &types.ExecutionRequest{Command: types.Check, Args: []string{"check", "--config", "/mnt/config/source.json"}},
+			wantArgs: []string{"check", "--config", "/mnt/config/source.json"},
+		},
+		{
+			name:     "not a check",
+			req:      &types.ExecutionRequest{Command: types.Discover, CheckTarget: types.CheckDestination, Args: []string{"discover"}},
+			wantArgs: []string{"discover"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ApplyCheckTarget(tt.req)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantArgs, tt.req.Args)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+)
+
+// CatalogCacheKey identifies a discover by connector, version, arguments and config files, so
+// any change to the source config or connector version misses the cache
+func CatalogCacheKey(req *types.ExecutionRequest) string {
+	configs := slices.Clone(req.Configs)
+	slices.SortFunc(configs, func(a, b types.JobConfig) int { return strings.Compare(a.Name, b.Name) })
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", req.ConnectorType, req.Version, strings.Join(req.Args, "\x00"))
+	for _, config := range configs {
+		fmt.Fprintf(hash, "%s\x00%s\x00", config.Name, config.Data)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func catalogCachePath(key string) string {
+	return filepath.Join(GetConfigDir(), constants.CatalogCacheDir, key+".json")
+}
+
+// ReadCachedCatalog returns the catalog cached under key when it was stored less than ttl ago.
+// Expired entries are removed.
+func ReadCachedCatalog(key string, ttl time.Duration) ([]byte, bool) {
+	path := catalogCachePath(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) >= ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	catalog, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warnf("failed to read cached catalog %s: %s", path, err)
+		return nil, false
+	}
+	return catalog, true
+}
+
+// WriteCachedCatalog stores a discovered catalog under key and removes entries older than ttl,
+// which would otherwise stay on the volume for configs that are never discovered again
+func WriteCachedCatalog(key string, catalog []byte, ttl time.Duration) error {
+	path := catalogCachePath(key)
+	if err := WriteFile(path, catalog); err != nil {
+		return fmt.Errorf("failed to cache catalog: %s", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || time.Since(info.ModTime()) < ttl {
+			continue
+		}
+		_ = os.Remove(filepath.Join(filepath.Dir(path), entry.Name()))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogCacheKey(t *testing.T) {
+	discover := func(version string, configs ...types.JobConfig) *types.ExecutionRequest {
+		return &types.ExecutionRequest{Command: types.Discover, ConnectorType: "postgres", Version: version, Args: []string{"discover", "--config", "/mnt/config/config.json"}, Configs: configs}
+	}
+	config := types.JobConfig{Name: "config.json", Data: `{"host":"db.internal"}`}
+	streams := types.JobConfig{Name: "streams.json", Data: `{}`}
+
+	key := CatalogCacheKey(discover("v0.2.0", config, streams))
+	// config order doesn't matter
+	require.Equal(t, key, CatalogCacheKey(discover("v0.2.0", streams, config)))
+	require.NotEqual(t, key, CatalogCacheKey(discover("v0.3.0", config, streams)))
+	require.NotEqual(t, key, CatalogCacheKey(discover("v0.2.0", types.JobConfig{Name: "config.json", Data: `{"host":"replica.internal"}`}, streams)))
+}
+
+func TestCachedCatalog(t *testing.T) {
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	stale := key + "-stale"
+	t.Cleanup(func() {
+		os.Remove(catalogCachePath(key))
+		os.Remove(catalogCachePath(stale))
+	})
+
+	_, ok := ReadCachedCatalog(key, time.Hour)
+	require.False(t, ok)
+
+	// storing a catalog prunes expired entries
+	require.NoError(t, WriteFile(catalogCachePath(stale), []byte(`{"streams":[]}`)))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(catalogCachePath(stale), old, old))
+	require.NoError(t, WriteCachedCatalog(key, []byte(`{"streams":[{"name":"orders"}]}`), time.Hour))
+	require.NoFileExists(t, catalogCachePath(stale))
+
+	catalog, ok := ReadCachedCatalog(key, time.Hour)
+	require.True(t, ok)
+	require.JSONEq(t, `{"streams":[{"name":"orders"}]}`, string(catalog))
+
+	// an expired catalog is a miss and is removed
+	_, ok = ReadCachedCatalog(key, time.Nanosecond)
+	require.False(t, ok)
+	require.NoFileExists(t, catalogCachePath(key))
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// ExtractSpecSchema returns the JSON schema of a connector's config from its spec output.
+// The schema may be the spec itself or nested under "jsonschema", as an object or a JSON string.
+func ExtractSpecSchema(specOutput []byte) (map[string]any, error) {
+	var output map[string]any
+	if err := json.Unmarshal(specOutput, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse spec output: %s", err)
+	}
+
+	schema := output
+	if spec, ok := output["spec"]; ok {
+		schema = asObject(spec)
+	}
+	if nested, ok := schema["jsonschema"]; ok {
+		schema = asObject(nested)
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("spec output contains no config schema")
+	}
+	return schema, nil
+}
+
+// ValidateConfigAgainstSchema checks a connector config against the common subset of JSON
+// schema used by connector specs (required, type, enum and nested properties) and returns
+// one error per offending field. Keywords outside this subset are not checked.
+func ValidateConfigAgainstSchema(config string, schema map[string]any) ([]string, error) {
+	var value any
+	if err := json.Unmarshal([]byte(config), &value); err != nil {
+		return nil, fmt.Errorf("config is not valid JSON: %s", err)
+	}
+
+	var errs []string
+	validateValue("", value, schema, &errs)
+	sort.Strings(errs)
+	return errs, nil
+}
+
+// GetConfigFileArg returns the config file passed to the connector with --config, as named in req.Configs
+func GetConfigFileArg(req *types.ExecutionRequest) (types.JobConfig, bool) {
+	idx := slices.Index(req.Args, "--config")
+	if idx == -1 || idx+1 >= len(req.Args) {
+		return types.JobConfig{}, false
+	}
+
+	name := filepath.Base(req.Args[idx+1])
+	for _, config := range req.Configs {
+		if config.Name == name {
+			return config, true
+		}
+	}
+	return types.JobConfig{}, false
+}
+
+func validateValue(path string, value any, schema map[string]any, errs *[]string) {
+	if expected, ok := schema["type"].(string); ok && !matchesType(value, expected) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s", fieldName(path), expected))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		if !slices.ContainsFunc(enum, func(allowed any) bool { return fmt.Sprint(allowed) == fmt.Sprint(value) }) {
+			*errs = append(*errs, fmt.Sprintf("%s: must be one of %v", fieldName(path), enum))
+		}
+	}
+
+	object, isObject := value.(map[string]any)
+	if !isObject {
+		return
+	}
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, field := range required {
+			name, _ := field.(string)
+			if v, exists := object[name]; name != "" && (!exists || v == nil) {
+				*errs = append(*errs, fmt.Sprintf("%s: required", joinPath(path, name)))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	for name, propertySchema := range properties {
+		if v, exists := object[name]; exists && v != nil {
+			validateValue(joinPath(path, name), v, asObject(propertySchema), errs)
+		}
+	}
+}
+
+func matchesType(value any, expected string) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func asObject(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case string:
+		var object map[string]any
+		if err := json.Unmarshal([]byte(v), &object); err == nil {
+			return object
+		}
+	}
+	return nil
+}
+
+func joinPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+func fieldName(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return strings.TrimPrefix(path, ".")
+}
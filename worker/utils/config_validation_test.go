@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpecSchema = `{
+	"type": "object",
+	"required": ["host", "port"],
+	"properties": {
+		"host": {"type": "string"},
+		"port": {"type": "integer"},
+		"ssl": {"type": "object", "required": ["mode"], "properties": {"mode": {"enum": ["disable", "require"]}}}
+	}
+}`
+
+func TestExtractSpecSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr string
+	}{
+		{name: "bare schema", output: testSpecSchema},
+		{name: "nested spec", output: `{"spec":{"jsonschema":` + testSpecSchema + `}}`},
+		{name: "schema as a string", output: `{"spec":{"jsonschema":` + strconv.Quote(testSpecSchema) + `}}`},
+		{name: "empty spec", output: `{"spec":{}}`, wantErr: "spec output contains no config schema"},
+		{name: "not json", output: "spec failed", wantErr: "failed to parse spec output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ExtractSpecSchema([]byte(tt.output))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []any{"host", "port"}, schema["required"])
+		})
+	}
+}
+
+func TestValidateConfigAgainstSchema(t *testing.T) {
+	schema, err := ExtractSpecSchema([]byte(testSpecSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr string
+	}{
+		{name: "valid", config: `{"host":"db","port":5432,"ssl":{"mode":"require"},"extra":true}`},
+		{name: "missing field", config: `{"host":"db","port":null}`, want: []string{"port: required"}},
+		{name: "wrong types", config: `{"host":5432,"port":54.32}`, want: []string{"host: expected string", "port: expected integer"}},
+		{name: "nested field", config: `{"host":"db","port":5432,"ssl":{"mode":"verify"}}`, want: []string{"ssl.mode: must be one of [disable require]"}},
+		{name: "not an object", config: `[]`, want: []string{"config: expected object"}},
+		{name: "not json", config: `{"host":`, wantErr: "config is not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := ValidateConfigAgainstSchema(tt.config, schema)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, errs)
+		})
+	}
+}
+
+func TestGetConfigFileArg(t *testing.T) {
+	source := types.JobConfig{Name: "source.json", Data: `{"host":"db"}`}
+	req := &types.ExecutionRequest{
+		Args:    []string{"sync", "--config", "/mnt/config/source.json", "--destination", "/mnt/config/destination.json"},
+		Configs: []types.JobConfig{{Name: "destination.json"}, source},
+	}
+
+	config, found := GetConfigFileArg(req)
+	require.True(t, found)
+	require.Equal(t, source, config)
+
+	req.Args = []string{"sync", "--config"}
+	_, found = GetConfigFileArg(req)
+	require.False(t, found)
+}
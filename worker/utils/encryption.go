@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
@@ -17,13 +18,56 @@ import (
 	"github.com/spf13/viper"
 )
 
+// SecretKeys are the key configs are encrypted with and the keys they were encrypted with
+// before a rotation, which are still tried so older configs keep decrypting
+type SecretKeys struct {
+	Current  string
+	Previous []string
+}
+
+var (
+	runtimeKeysMu sync.RWMutex
+	runtimeKeys   *SecretKeys
+)
+
+// SetSecretKeys replaces the keys read from OLAKE_SECRET_KEY and OLAKE_SECRET_KEY_PREVIOUS,
+// e.g. with the ones of a watched Secret after a rotation
+func SetSecretKeys(keys SecretKeys) {
+	runtimeKeysMu.Lock()
+	defer runtimeKeysMu.Unlock()
+	runtimeKeys = &keys
+}
+
+// GetSecretKeys returns the keys set at runtime, or else the ones from the environment
+func GetSecretKeys() SecretKeys {
+	runtimeKeysMu.RLock()
+	defer runtimeKeysMu.RUnlock()
+	if runtimeKeys != nil {
+		return *runtimeKeys
+	}
+	return SecretKeys{
+		Current:  viper.GetString(constants.EnvSecretKey),
+		Previous: ParseSecretKeyList(viper.GetString(constants.EnvSecretKeyPrevious)),
+	}
+}
+
+// ParseSecretKeyList splits a comma or newline separated list of keys
+func ParseSecretKeyList(raw string) []string {
+	var keys []string
+	for _, key := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 // getSecretKey returns the AES key bytes and optionally a KMS client.
 //
-//   - If OLAKE_SECRET_KEY is empty          → encryption disabled (nil key, nil client)
-//   - If OLAKE_SECRET_KEY starts with "arn:aws:kms:" → AWS KMS mode
-//   - Otherwise                             → local AES-256-GCM (key is SHA-256 of the env value)
-func getSecretKey() ([]byte, *kms.Client, error) {
-	envKey := viper.GetString(constants.EnvSecretKey)
+//   - If the key is empty                   → encryption disabled (nil key, nil client)
+//   - If the key starts with "arn:aws:kms:" → AWS KMS mode
+//   - Otherwise                             → local AES-256-GCM (key is SHA-256 of the value)
+func getSecretKey(envKey string) ([]byte, *kms.Client, error) {
 	if strings.TrimSpace(envKey) == "" {
 		return []byte{}, nil, nil // Encryption is disabled
 	}
@@ -43,15 +87,16 @@ func getSecretKey() ([]byte, *kms.Client, error) {
 
 // Decrypt decrypts a value that was encrypted by the server's Encrypt function.
 // The stored format is a JSON-quoted, base64-encoded ciphertext (nonce prepended for AES-GCM).
-// If OLAKE_SECRET_KEY is not set, the raw encryptedText is returned unchanged.
+// The current key is tried first, then the previous ones. If no key is set, the raw
+// encryptedText is returned unchanged.
 func Decrypt(encryptedText string) (string, error) {
 	if strings.TrimSpace(encryptedText) == "" {
 		return "", fmt.Errorf("cannot decrypt empty or whitespace-only input")
 	}
 
-	key, kmsClient, err := getSecretKey()
-	if err != nil || key == nil || len(key) == 0 {
-		return encryptedText, err
+	keys := GetSecretKeys()
+	if strings.TrimSpace(keys.Current) == "" {
+		return encryptedText, nil
 	}
 
 	// The server stores the ciphertext as a JSON-quoted string (via fmt.Sprintf("%q", ...))
@@ -65,6 +110,28 @@ func Decrypt(encryptedText string) (string, error) {
 		return "", fmt.Errorf("failed to decode base64 data: %s", err)
 	}
 
+	plaintext, err := decryptWithKey(keys.Current, encryptedData)
+	if err == nil {
+		return plaintext, nil
+	}
+	for _, previous := range keys.Previous {
+		if plaintext, prevErr := decryptWithKey(previous, encryptedData); prevErr == nil {
+			return plaintext, nil
+		}
+	}
+	if len(keys.Previous) > 0 {
+		return "", fmt.Errorf("%s (previous keys tried: %d)", err, len(keys.Previous))
+	}
+	return "", err
+}
+
+// decryptWithKey decrypts the decoded ciphertext with one key
+func decryptWithKey(secretKey string, encryptedData []byte) (string, error) {
+	key, kmsClient, err := getSecretKey(secretKey)
+	if err != nil {
+		return "", err
+	}
+
 	// AWS KMS path
 	if kmsClient != nil {
 		result, err := kmsClient.Decrypt(context.Background(), &kms.DecryptInput{
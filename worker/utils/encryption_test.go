@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encryptWithKey encrypts plaintext the way the server's Encrypt does with a local key
+func encryptWithKey(t *testing.T, secretKey, plaintext string) string {
+	key := sha256.Sum256([]byte(secretKey))
+	block, err := aes.NewCipher(key[:])
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+	return fmt.Sprintf("%q", base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)))
+}
+
+func useSecretKeys(t *testing.T, keys SecretKeys) {
+	SetSecretKeys(keys)
+	t.Cleanup(func() {
+		runtimeKeysMu.Lock()
+		defer runtimeKeysMu.Unlock()
+		runtimeKeys = nil
+	})
+}
+
+func TestDecryptWithPreviousKey(t *testing.T) {
+	config := `{"host":"db.internal","password":"secret"}`
+	beforeRotation := encryptWithKey(t, "old-key", config)
+	afterRotation := encryptWithKey(t, "new-key", config)
+
+	useSecretKeys(t, SecretKeys{Current: "new-key", Previous: []string{"older-key", "old-key"}})
+
+	plaintext, err := Decrypt(afterRotation)
+	require.NoError(t, err)
+	require.Equal(t, config, plaintext)
+
+	plaintext, err = Decrypt(beforeRotation)
+	require.NoError(t, err)
+	require.Equal(t, config, plaintext)
+}
+
+func TestDecryptWithUnknownKey(t *testing.T) {
+	encrypted := encryptWithKey(t, "other-key", "{}")
+
+	useSecretKeys(t, SecretKeys{Current: "new-key", Previous: []string{"old-key"}})
+	_, err := Decrypt(encrypted)
+	require.ErrorContains(t, err, "previous keys tried: 1")
+
+	useSecretKeys(t, SecretKeys{Current: "new-key"})
+	_, err = Decrypt(encrypted)
+	require.ErrorContains(t, err, "failed to decrypt")
+}
+
+func TestDecryptWithoutKey(t *testing.T) {
+	useSecretKeys(t, SecretKeys{})
+
+	plaintext, err := Decrypt(`{"host":"db.internal"}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"host":"db.internal"}`, plaintext)
+}
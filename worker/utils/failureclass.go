@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+// failureScanBytes is how much of the end of a failed connector's output is matched against
+// the failure patterns
+const failureScanBytes = 64 << 10
+
+// defaultTransientPatterns are connector errors of a source or destination that is briefly
+// unreachable or overloaded, used when CONNECTOR_TRANSIENT_PATTERNS is unset
+var defaultTransientPatterns = []string{
+	`connection refused`,
+	`connection reset by peer`,
+	`broken pipe`,
+	`i/o timeout`,
+	`tls handshake timeout`,
+	`too many (connections|clients)`,
+	`temporarily unavailable`,
+	`server closed the connection unexpectedly`,
+	`the database system is (starting up|shutting down|in recovery mode)`,
+}
+
+// defaultFatalPatterns are config and credential errors that fail every attempt the same way,
+// used when CONNECTOR_FATAL_PATTERNS is unset
+var defaultFatalPatterns = []string{
+	`authentication failed`,
+	`access denied`,
+	`permission denied`,
+	`invalid (config|configuration|credentials)`,
+	`unknown database`,
+	`no pg_hba.conf entry`,
+}
+
+// IsTransientConnectorFailure reports whether a connector that exited with a failure is worth
+// retrying: its exit code is listed in CONNECTOR_TRANSIENT_EXIT_CODES or the end of its output
+// matches a CONNECTOR_TRANSIENT_PATTERNS pattern, and no CONNECTOR_FATAL_PATTERNS pattern
+// matches. Patterns are case-insensitive regular expressions; out-of-memory kills are never
+// transient, as the next attempt runs with the same memory.
+func IsTransientConnectorFailure(exitCode int, reason, output string) bool {
+	if reason == constants.ExitReasonOOMKilled {
+		return false
+	}
+	if len(output) > failureScanBytes {
+		output = output[len(output)-failureScanBytes:]
+	}
+
+	if matchesAnyPattern(output, failurePatterns(constants.EnvConnectorFatalPatterns, defaultFatalPatterns)) {
+		return false
+	}
+	if slices.Contains(transientExitCodes(), exitCode) {
+		return true
+	}
+	return matchesAnyPattern(output, failurePatterns(constants.EnvConnectorTransientPatterns, defaultTransientPatterns))
+}
+
+// failurePatterns compiles the JSON array of patterns in env, or the defaults when it is unset.
+// Invalid patterns are logged and ignored; an empty array disables the match.
+func failurePatterns(env string, defaults []string) []*regexp.Regexp {
+	patterns := defaults
+	if raw := strings.TrimSpace(viper.GetString(env)); raw != "" {
+		// decoded into a fresh slice, never into the shared defaults
+		var configured []string
+		if err := json.Unmarshal([]byte(raw), &configured); err != nil {
+			logger.Warnf("ignoring invalid %s, expected a JSON array of regular expressions: %s", env, err)
+		} else {
+			patterns = configured
+		}
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			logger.Warnf("ignoring invalid %s pattern %q: %s", env, pattern, err)
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled
+}
+
+// transientExitCodes returns the comma separated exit codes of CONNECTOR_TRANSIENT_EXIT_CODES
+func transientExitCodes() []int {
+	var codes []int
+	for _, value := range strings.Split(viper.GetString(constants.EnvConnectorTransientExitCodes), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			logger.Warnf("ignoring invalid %s value %q", constants.EnvConnectorTransientExitCodes, value)
+			continue
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func matchesAnyPattern(output string, patterns []*regexp.Regexp) bool {
+	return slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool {
+		return re.MatchString(output)
+	})
+}
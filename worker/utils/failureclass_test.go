@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientConnectorFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		exitCode int
+		reason   string
+		output   string
+		want     bool
+	}{
+		{
+			name:     "default transient pattern",
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "reading chunk\ndial tcp 10.0.0.5:5432: connect: Connection refused",
+			want:     true,
+		},
+		{
+			name:     "unclassified failure stays fatal",
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "panic: nil map",
+			want:     false,
+		},
+		{
+			name:     "oom kill is never transient",
+			exitCode: 137,
+			reason:   constants.ExitReasonOOMKilled,
+			output:   "connection reset by peer",
+			want:     false,
+		},
+		{
+			name:     "fatal pattern wins over transient pattern",
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "connection refused, retrying\npassword authentication failed for user \"olake\"",
+			want:     false,
+		},
+		{
+			name:     "fatal pattern wins over transient exit code",
+			env:      map[string]string{constants.EnvConnectorTransientExitCodes: "75"},
+			exitCode: 75,
+			reason:   constants.ExitReasonError,
+			output:   "ERROR: access denied for user",
+			want:     false,
+		},
+		{
+			name:     "transient exit code",
+			env:      map[string]string{constants.EnvConnectorTransientExitCodes: "2, 75"},
+			exitCode: 75,
+			reason:   constants.ExitReasonError,
+			output:   "source busy",
+			want:     true,
+		},
+		{
+			name:     "configured patterns replace the defaults",
+			env:      map[string]string{constants.EnvConnectorTransientPatterns: `["rate limit(ed)?"]`},
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "API RATE LIMITED",
+			want:     true,
+		},
+		{
+			name:     "configured patterns drop the defaults",
+			env:      map[string]string{constants.EnvConnectorTransientPatterns: `["rate limit"]`},
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "connection refused",
+			want:     false,
+		},
+		{
+			name:     "empty array disables transient patterns",
+			env:      map[string]string{constants.EnvConnectorTransientPatterns: `[]`},
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "connection refused",
+			want:     false,
+		},
+		{
+			name:     "invalid configuration falls back to the defaults",
+			env:      map[string]string{constants.EnvConnectorTransientPatterns: `["x", 1]`},
+			exitCode: 1,
+			reason:   constants.ExitReasonError,
+			output:   "i/o timeout",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				viper.Set(key, value)
+				t.Cleanup(func() { viper.Set(key, "") })
+			}
+			require.Equal(t, tt.want, IsTransientConnectorFailure(tt.exitCode, tt.reason, tt.output))
+		})
+	}
+}
+
+func TestFailurePatternsKeepDefaults(t *testing.T) {
+	defaults := slices.Clone(defaultTransientPatterns)
+	for _, value := range []string{`["a", "b"]`, `["x", 1]`, `not json`} {
+		viper.Set(constants.EnvConnectorTransientPatterns, value)
+		failurePatterns(constants.EnvConnectorTransientPatterns, defaultTransientPatterns)
+	}
+	viper.Set(constants.EnvConnectorTransientPatterns, "")
+
+	require.Equal(t, defaults, defaultTransientPatterns)
+}
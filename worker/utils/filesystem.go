@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -55,6 +56,53 @@ func WriteFile(filePath string, data []byte) error {
 	return nil
 }
 
+// WriteOutputFile writes a command's result to filePath, or gzipped to filePath.gz, returning
+// the path written. Compressed data is streamed to the file as it is deflated.
+func WriteOutputFile(filePath string, data []byte, compression types.OutputCompression) (string, error) {
+	switch compression {
+	case types.OutputCompressionNone:
+		return filePath, WriteFile(filePath, data)
+	case types.OutputCompressionGzip:
+	default:
+		return "", fmt.Errorf("unsupported output compression: %s", compression)
+	}
+
+	if err := CreateDirectory(filepath.Dir(filePath)); err != nil {
+		return "", err
+	}
+	gzPath := filePath + ".gz"
+	file, err := os.OpenFile(gzPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, constants.DefaultFilePermissions)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file %s: %s", gzPath, err)
+	}
+
+	zw := gzip.NewWriter(file)
+	_, err = zw.Write(data)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write to file %s: %s", gzPath, err)
+	}
+	return gzPath, nil
+}
+
+// CompressFile replaces a file a connector generated with its gzipped copy at filePath.gz,
+// keeping its modification time, and returns the new path
+func CompressFile(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file %s: %s", filePath, err)
+	}
+	if err := gzipFile(filePath, info.ModTime()); err != nil {
+		return "", fmt.Errorf("failed to compress file %s: %s", filePath, err)
+	}
+	return filePath + ".gz", nil
+}
+
 func DeleteDirectory(dirPath string) error {
 	if err := os.RemoveAll(dirPath); err != nil {
 		return fmt.Errorf("failed to delete directory %s: %s", dirPath, err)
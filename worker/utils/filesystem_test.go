@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+// readGzip returns the decompressed contents of a gzipped file
+func readGzip(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	const catalog = `{"type":"CATALOG"}`
+	dir := filepath.Join(t.TempDir(), "discover-abc")
+
+	path, err := WriteOutputFile(filepath.Join(dir, "output.json"), []byte(catalog), types.OutputCompressionNone)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "output.json"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, catalog, string(data))
+
+	path, err = WriteOutputFile(filepath.Join(dir, "output.json"), []byte(catalog), types.OutputCompressionGzip)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "output.json.gz"), path)
+	require.Equal(t, catalog, readGzip(t, path))
+
+	_, err = WriteOutputFile(filepath.Join(dir, "output.json"), []byte(catalog), "zstd")
+	require.EqualError(t, err, "unsupported output compression: zstd")
+}
+
+func TestCompressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "streams.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"streams":[]}`), 0o644))
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	gzPath, err := CompressFile(path)
+	require.NoError(t, err)
+	require.Equal(t, path+".gz", gzPath)
+	require.NoFileExists(t, path)
+	require.Equal(t, `{"streams":[]}`, readGzip(t, gzPath))
+	info, err := os.Stat(gzPath)
+	require.NoError(t, err)
+	require.True(t, modTime.Equal(info.ModTime()))
+
+	_, err = CompressFile(path)
+	require.ErrorContains(t, err, "failed to stat file")
+}
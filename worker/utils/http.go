@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+const httpRetryDelay = time.Second
+
+// outboundTransport sends outbound calls through HTTP_PROXY/HTTPS_PROXY unless the host
+// matches NO_PROXY. Set explicitly rather than relying on http.DefaultTransport, which
+// libraries may replace.
+var outboundTransport = func() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}()
+
+// HTTPResult is the outcome of an outbound HTTP call whose body has already been read
+type HTTPResult struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+// PostJSON posts payload to url with the outbound HTTP_CLIENT_TIMEOUT, retrying network
+// errors and 5xx responses up to HTTP_RETRY_ATTEMPTS times in total. Other responses,
+// including 4xx, are returned as they are for the caller to judge; only a call that never
+// got a response returns an error.
+func PostJSON(ctx context.Context, url string, payload []byte) (*HTTPResult, error) {
+	return PostJSONWithHeaders(ctx, url, payload, nil)
+}
+
+// PostJSONWithHeaders is PostJSON sending extra request headers
+func PostJSONWithHeaders(ctx context.Context, url string, payload []byte, headers map[string]string) (*HTTPResult, error) {
+	client := &http.Client{Timeout: viper.GetDuration(constants.EnvHTTPClientTimeout), Transport: outboundTransport}
+	attempts := max(1, viper.GetInt(constants.EnvHTTPRetryAttempts))
+
+	var result *HTTPResult
+	err := RetryWithBackoff(func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil // cancelled, stop retrying
+			}
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		result = &HTTPResult{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("server returned %s", resp.Status)
+		}
+		return nil
+	}, attempts, httpRetryDelay)
+
+	switch {
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case result != nil:
+		// a 5xx that outlived the retries is still a response
+		return result, nil
+	default:
+		return nil, err
+	}
+}
+
+// CallbackHeaders returns the headers of calls to the olake-ui callback URL: a bearer token
+// when OLAKE_CALLBACK_TOKEN is set. Only callback calls send it, never webhooks.
+func CallbackHeaders() map[string]string {
+	token := strings.TrimSpace(viper.GetString(constants.EnvCallbackToken))
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// LogProxySettings logs the proxy outbound calls use, without credentials, and warns when the
+// olake-ui callback would go through it: behind a corporate proxy the in-cluster or local
+// callback host usually has to be listed in NO_PROXY.
+func LogProxySettings() {
+	var configured []string
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"} {
+		value := os.Getenv(name)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if value == "" {
+			continue
+		}
+		if name != "NO_PROXY" {
+			if proxyURL, err := url.Parse(value); err == nil {
+				value = proxyURL.Redacted()
+			}
+		}
+		configured = append(configured, fmt.Sprintf("%s=%s", name, value))
+	}
+	if len(configured) == 0 {
+		return
+	}
+	logger.Infof("outbound proxy: %s", strings.Join(configured, " "))
+
+	callbackURL := viper.GetString(constants.EnvCallbackURL)
+	req, err := http.NewRequest(http.MethodPost, callbackURL, nil)
+	if err != nil {
+		return
+	}
+	if proxyURL, err := outboundTransport.Proxy(req); err == nil && proxyURL != nil {
+		logger.Warnf("callback %s goes through proxy %s, add its host to NO_PROXY if it is internal", req.URL.Host, proxyURL.Redacted())
+	}
+}
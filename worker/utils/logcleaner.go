@@ -1,32 +1,78 @@
 package utils
 
 import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 	"github.com/robfig/cron"
+	"github.com/spf13/viper"
 )
 
-// starts a log cleaner that removes old logs from the specified directory based on the retention period
-func InitLogCleaner(logDir string, retentionPeriod int) {
+// starts a log cleaner that removes old logs from the specified directory based on the retention period,
+// stopping it once ctx is cancelled. With LOG_COMPRESS_AFTER_DAYS set, worker logs are gzipped first;
+// with LOG_MAX_USAGE set, the least recently used workflow directories are removed when the volume fills up.
+func InitLogCleaner(ctx context.Context, logDir string, retentionPeriod int) {
 	c := cron.New()
 
 	err := c.AddFunc("@midnight", func() {
-		cleanOldLogs(logDir, retentionPeriod)
+		RunLogCleanup(logDir, retentionPeriod)
 	})
 	if err != nil {
 		logger.Errorf("failed to start log cleaner: %s", err)
 		return
 	}
 
+	// bursts of jobs can fill the volume well before midnight, so usage is checked more often
+	if maxUsage := viper.GetString(constants.EnvLogMaxUsage); maxUsage != "" {
+		interval := viper.GetDuration(constants.EnvLogUsageCheckInterval)
+		err := c.AddFunc(fmt.Sprintf("@every %s", interval), func() {
+			enforceLogUsageLimit(logDir, maxUsage)
+		})
+		if err != nil {
+			logger.Errorf("failed to start log usage check: %s", err)
+		}
+	}
+
 	c.Start()
+	go func() {
+		<-ctx.Done()
+		c.Stop()
+	}()
+}
+
+// LogCleanupResult reports what a log cleanup run removed
+type LogCleanupResult struct {
+	Directories int   `json:"directories_removed"`
+	BytesFreed  int64 `json:"bytes_freed"`
+}
+
+// logCleanupMu keeps the scheduled cleanup and on-demand runs from walking the volume at once
+var logCleanupMu sync.Mutex
+
+// RunLogCleanup compresses and deletes old logs as the nightly cleaner does, for runs
+// triggered on demand
+func RunLogCleanup(logDir string, retentionPeriod int) LogCleanupResult {
+	logCleanupMu.Lock()
+	defer logCleanupMu.Unlock()
+
+	if compressAfter := viper.GetInt(constants.EnvLogCompressAfterDays); compressAfter > 0 && compressAfter < retentionPeriod {
+		compressOldLogs(logDir, compressAfter)
+	}
+	return cleanOldLogs(logDir, retentionPeriod)
 }
 
-func cleanOldLogs(logDir string, retentionPeriod int) {
+func cleanOldLogs(logDir string, retentionPeriod int) LogCleanupResult {
 	logger.Info("running log cleaner...")
+	var result LogCleanupResult
 	cutoff := time.Now().AddDate(0, 0, -retentionPeriod)
 
 	// check if old logs are present
@@ -59,7 +105,7 @@ func cleanOldLogs(logDir string, retentionPeriod int) {
 	entries, err := os.ReadDir(logDir)
 	if err != nil {
 		logger.Errorf("failed to read log dir: %s", err)
-		return
+		return result
 	}
 	// delete dir if old logs are found or is empty
 	for _, entry := range entries {
@@ -69,7 +115,82 @@ func cleanOldLogs(logDir string, retentionPeriod int) {
 		dirPath := filepath.Join(logDir, entry.Name())
 		if toDelete := shouldDelete(dirPath, cutoff); toDelete {
 			logger.Infof("deleting folder: %s", dirPath)
-			_ = os.RemoveAll(dirPath)
+			size := scanWorkflowDir(dirPath).size
+			if err := os.RemoveAll(dirPath); err != nil {
+				logger.Warnf("failed to delete folder %s: %s", dirPath, err)
+				continue
+			}
+			result.Directories++
+			result.BytesFreed += size
+		}
+	}
+	logger.Infof("log cleaner removed %d folders, %d bytes", result.Directories, result.BytesFreed)
+	return result
+}
+
+// compressOldLogs gzips worker.log files not written to for compressAfter days. A running
+// workflow keeps writing its log, so its file is never old enough to be picked up. The
+// compressed file keeps the log's modification time, so retention still counts from it.
+func compressOldLogs(logDir string, compressAfter int) {
+	cutoff := time.Now().AddDate(0, 0, -compressAfter)
+
+	_ = filepath.WalkDir(logDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if entry.Name() == "telemetry" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != "worker.log" {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
 		}
+		if err := gzipFile(path, info.ModTime()); err != nil {
+			logger.Warnf("failed to compress log %s: %s", path, err)
+		}
+		return nil
+	})
+}
+
+// gzipFile replaces path with path.gz, stamped with modTime
+func gzipFile(path string, modTime time.Time) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	gzPath := path + ".gz"
+	tmpPath := gzPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmpPath, modTime, modTime)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, gzPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
 	}
+	return os.Remove(path)
 }
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLog writes a log file under dir last modified age ago
+func writeLog(t *testing.T, dir, name, data string, age time.Duration) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	return path
+}
+
+func TestRunLogCleanupCompressesOldLogs(t *testing.T) {
+	viper.Set(constants.EnvLogCompressAfterDays, 7)
+	t.Cleanup(func() { viper.Set(constants.EnvLogCompressAfterDays, nil) })
+
+	const day = 24 * time.Hour
+	logDir := t.TempDir()
+	idle := writeLog(t, logDir, "sync-idle/logs/worker.log", "sync finished", 10*day)
+	running := writeLog(t, logDir, "sync-running/logs/worker.log", "still syncing", time.Hour)
+	connector := writeLog(t, logDir, "sync-idle/logs/sync_1/olake.log", "connector output", 10*day)
+	telemetry := writeLog(t, logDir, "telemetry/worker.log", "telemetry", 10*day)
+	writeLog(t, logDir, "sync-expired/logs/worker.log", "long gone", 40*day)
+
+	result := RunLogCleanup(logDir, 30)
+
+	// only idle worker logs are gzipped, keeping their modification time
+	require.NoFileExists(t, idle)
+	info, err := os.Stat(idle + ".gz")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(-10*day), info.ModTime(), time.Minute)
+
+	file, err := os.Open(idle + ".gz")
+	require.NoError(t, err)
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, "sync finished", string(data))
+
+	for _, path := range []string{running, connector, telemetry} {
+		require.FileExists(t, path)
+		require.NoFileExists(t, path+".gz")
+	}
+
+	// a compressed log past retention is still deleted
+	require.NoDirExists(t, filepath.Join(logDir, "sync-expired"))
+	require.Equal(t, 1, result.Directories)
+}
+
+func TestRunLogCleanupCompressionThreshold(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvLogCompressAfterDays, nil) })
+
+	// disabled, or not below the retention period
+	for _, compressAfter := range []int{0, 30, 45} {
+		viper.Set(constants.EnvLogCompressAfterDays, compressAfter)
+		logDir := t.TempDir()
+		path := writeLog(t, logDir, "sync-idle/logs/worker.log", "sync finished", 10*24*time.Hour)
+
+		RunLogCleanup(logDir, 30)
+		require.FileExists(t, path)
+		require.NoFileExists(t, path+".gz")
+	}
+}
@@ -3,11 +3,11 @@ package logger
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
 )
 
 // ctxKey is the key type for the logger in the context.
@@ -15,7 +15,7 @@ type ctxKey struct{}
 
 // WorkflowLogFile holds the file handle for a workflow's log file.
 type WorkflowLogFile struct {
-	file *os.File
+	file *rotatingFile
 }
 
 // Close must be called when the workflow finishes.
@@ -29,16 +29,21 @@ func (wf *WorkflowLogFile) Close() error {
 // InitWorkflowLogger creates a zerolog.Logger instance that writes to both stdout and <workflowDir>/worker.log.
 // Returns the logger instance and a file handle that must be closed when the workflow finishes.
 // Note: workflowDir must already exist before calling this function.
+// worker.log is rotated past WORKER_LOG_MAX_SIZE, and with LOG_SAMPLE_RATE set debug and info
+// lines beyond that many per second are dropped.
 func InitWorkflowLogger(ctx context.Context, workflowLogsDir string) (context.Context, *WorkflowLogFile, error) {
 	logFilePath := filepath.Join(workflowLogsDir, "worker.log")
-	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.DefaultFilePermissions)
+	file, err := openRotatingFile(logFilePath, int64(viper.GetSizeInBytes(constants.EnvWorkerLogMaxSize)))
 	if err != nil {
 		return ctx, nil, fmt.Errorf("failed to open worker.log: %w", err)
 	}
 
 	stdoutWriter := createStdoutWriter()
-	multiWriter := zerolog.MultiLevelWriter(stdoutWriter, file)
-	log := zerolog.New(multiWriter).With().Timestamp().Logger()
+	var writer zerolog.LevelWriter = zerolog.MultiLevelWriter(stdoutWriter, file)
+	if rate := viper.GetInt(constants.EnvLogSampleRate); rate > 0 {
+		writer = newSampledWriter(writer, rate)
+	}
+	log := zerolog.New(writer).With().Timestamp().Logger()
 	logFile := &WorkflowLogFile{file: file}
 
 	return CtxWithLogger(ctx, log), logFile, nil
@@ -49,6 +54,18 @@ func CtxWithLogger(ctx context.Context, log zerolog.Logger) context.Context {
 	return context.WithValue(ctx, ctxKey{}, log)
 }
 
+// CtxWithFields attaches the key/value pairs to every line logged through the context's logger,
+// as structured fields in JSON output and key=value pairs in console output. Empty values are skipped.
+func CtxWithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	fields := make([]interface{}, 0, len(keyvals))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if value := keyvals[i+1]; value != "" && value != nil {
+			fields = append(fields, keyvals[i], value)
+		}
+	}
+	return CtxWithLogger(ctx, FromContext(ctx).With().Fields(fields).Logger())
+}
+
 // FromContext retrieves the logger instance from context, or returns the global logger.
 func FromContext(ctx context.Context) zerolog.Logger {
 	if ctx == nil {
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+// FinalField marks a log line that must never be sampled out, such as the result of a run.
+// Usage: logger.Log(ctx).Info("executor output", "output", out, logger.FinalField, true)
+const FinalField = "final"
+
+var finalMarker = []byte(`"` + FinalField + `":true`)
+
+// sampledWriter passes at most rate debug and info lines per second and writes how many were
+// dropped once lines pass again. Warnings, errors and lines marked final are always written.
+type sampledWriter struct {
+	next zerolog.LevelWriter
+	rate int
+
+	mu          sync.Mutex
+	windowStart time.Time
+	written     int
+	dropped     int
+}
+
+func newSampledWriter(next zerolog.LevelWriter, rate int) *sampledWriter {
+	return &sampledWriter{next: next, rate: rate}
+}
+
+func (w *sampledWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *sampledWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level > zerolog.InfoLevel || bytes.Contains(p, finalMarker) {
+		return w.next.WriteLevel(level, p)
+	}
+
+	w.mu.Lock()
+	now := time.Now()
+	if now.Sub(w.windowStart) >= time.Second {
+		w.windowStart, w.written = now, 0
+	}
+	if w.written >= w.rate {
+		w.dropped++
+		w.mu.Unlock()
+		return len(p), nil
+	}
+	w.written++
+	dropped := w.dropped
+	w.dropped = 0
+	w.mu.Unlock()
+
+	if dropped > 0 {
+		summary := fmt.Sprintf(`{"level":"warn","time":%q,"message":"sampled out %d log lines"}`+"\n", now.UTC().Format(time.RFC3339), dropped)
+		_, _ = w.next.WriteLevel(zerolog.WarnLevel, []byte(summary))
+	}
+	return w.next.WriteLevel(level, p)
+}
+
+// rotatingFile is a log file that is moved to <path>.1, replacing an earlier one, and reopened
+// once it grows past maxBytes, so a workflow's log takes at most twice maxBytes on the volume
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxBytes int64) (*rotatingFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.DefaultFilePermissions)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &rotatingFile{path: path, maxBytes: maxBytes, file: file, size: info.Size()}, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s for rotation: %w", f.path, err)
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", f.path, err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, constants.DefaultFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to reopen %s after rotation: %w", f.path, err)
+	}
+	f.file, f.size = file, 0
+	return nil
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+var _ io.WriteCloser = (*rotatingFile)(nil)
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampledWriter(t *testing.T) {
+	var out bytes.Buffer
+	log := zerolog.New(newSampledWriter(zerolog.MultiLevelWriter(&out), 2))
+
+	for range 5 {
+		log.Info().Msg("reading chunk")
+	}
+	log.Warn().Msg("slow chunk")
+	log.Info().Bool(FinalField, true).Msg("executor output")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[0], "reading chunk")
+	require.Contains(t, lines[1], "reading chunk")
+	// warnings and final lines pass the sampler
+	require.Contains(t, lines[2], "slow chunk")
+	require.Contains(t, lines[3], "executor output")
+
+	// the dropped lines are reported once the next window lets lines through
+	sampler := newSampledWriter(zerolog.MultiLevelWriter(&out), 1)
+	log = zerolog.New(sampler)
+	log.Info().Msg("first")
+	log.Info().Msg("dropped")
+	log.Info().Msg("dropped")
+	out.Reset()
+	sampler.windowStart = sampler.windowStart.Add(-time.Second)
+	log.Info().Msg("next window")
+
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "sampled out 2 log lines")
+	require.Contains(t, lines[1], "next window")
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.log")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789\n"), 0o644))
+
+	// the existing file counts towards the limit
+	file, err := openRotatingFile(path, 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+
+	_, err = file.Write([]byte("abcdefgh\n"))
+	require.NoError(t, err)
+	_, err = file.Write([]byte("rotated\n"))
+	require.NoError(t, err)
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "0123456789\nabcdefgh\n", string(rotated))
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "rotated\n", string(current))
+
+	// without a limit the file only grows
+	unlimited, err := openRotatingFile(filepath.Join(t.TempDir(), "worker.log"), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { unlimited.Close() })
+	for range 3 {
+		_, err = unlimited.Write(bytes.Repeat([]byte("x"), 100))
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 300, unlimited.size)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// TailWorkflowLog returns up to the last lines lines of the workflow's worker.log, read from at
+// most SyncLogTailMaxBytes at the end of the file. Only the current file is read once it has
+// been rotated.
+func TailWorkflowLog(workflowID string, command types.Command, lines int) (string, error) {
+	if lines <= 0 {
+		lines = constants.SyncLogTailDefaultLines
+	}
+	lines = min(lines, constants.SyncLogTailMaxLines)
+
+	_, workdir := GetWorkflowDirAndSubDir(workflowID, command)
+	logPath := filepath.Join(workdir, "logs", "worker.log")
+	file, err := os.Open(logPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open worker log %s: %s", logPath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat worker log %s: %s", logPath, err)
+	}
+	offset := max(0, info.Size()-constants.SyncLogTailMaxBytes)
+	data := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(data, offset); err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read worker log %s: %s", logPath, err)
+	}
+
+	tail := strings.TrimRight(string(data), "\n")
+	// a read starting mid-file begins with a partial line
+	if offset > 0 {
+		if _, rest, found := strings.Cut(tail, "\n"); found {
+			tail = rest
+		}
+	}
+	for i, end := 0, len(tail); i < lines; i++ {
+		start := strings.LastIndexByte(tail[:end], '\n')
+		if start < 0 {
+			return tail, nil
+		}
+		if i == lines-1 {
+			return tail[start+1:], nil
+		}
+		end = start
+	}
+	return tail, nil
+}
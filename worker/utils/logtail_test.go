@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+// writeWorkerLog writes the sync workflow's worker.log and returns the workflow ID
+func writeWorkerLog(t *testing.T, data string) string {
+	workflowID := fmt.Sprintf("sync-7-%d", time.Now().UnixNano())
+	_, workdir := GetWorkflowDirAndSubDir(workflowID, types.Sync)
+	t.Cleanup(func() { os.RemoveAll(workdir) })
+	require.NoError(t, WriteFile(filepath.Join(workdir, "logs", "worker.log"), []byte(data)))
+	return workflowID
+}
+
+func TestTailWorkflowLog(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 1500; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	workflowID := writeWorkerLog(t, strings.Join(lines, "\n")+"\n")
+
+	tail, err := TailWorkflowLog(workflowID, types.Sync, 2)
+	require.NoError(t, err)
+	require.Equal(t, "line 1499\nline 1500", tail)
+
+	tests := []struct {
+		name      string
+		lines     int
+		wantLines int
+	}{
+		{name: "default", lines: 0, wantLines: constants.SyncLogTailDefaultLines},
+		{name: "capped", lines: 5000, wantLines: constants.SyncLogTailMaxLines},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail, err := TailWorkflowLog(workflowID, types.Sync, tt.lines)
+			require.NoError(t, err)
+			require.Equal(t, strings.Join(lines[len(lines)-tt.wantLines:], "\n"), tail)
+		})
+	}
+
+	t.Run("short log", func(t *testing.T) {
+		tail, err := TailWorkflowLog(writeWorkerLog(t, "only line\n"), types.Sync, 10)
+		require.NoError(t, err)
+		require.Equal(t, "only line", tail)
+	})
+
+	t.Run("byte limit", func(t *testing.T) {
+		long := strings.Repeat("x", constants.SyncLogTailMaxBytes)
+		tail, err := TailWorkflowLog(writeWorkerLog(t, long+"\nlast line\n"), types.Sync, 10)
+		require.NoError(t, err)
+		// the partial line the read starts in is dropped
+		require.Equal(t, "last line", tail)
+	})
+
+	t.Run("not started", func(t *testing.T) {
+		_, err := TailWorkflowLog(fmt.Sprintf("sync-7-%d", time.Now().UnixNano()), types.Sync, 10)
+		require.ErrorContains(t, err, "failed to open worker log")
+	})
+}
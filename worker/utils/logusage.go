@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// activeWorkflowWindow protects directories written to this recently: running workflows log
+// continuously, so a directory this fresh may belong to one and is never removed for space
+const activeWorkflowWindow = time.Hour
+
+// usageLimit is a LOG_MAX_USAGE threshold, either a share of the volume or a size in bytes
+type usageLimit struct {
+	percent float64
+	bytes   int64
+}
+
+// parseUsageLimit reads "85%" as a share of the volume and anything else as a quantity
+// such as "50Gi" or "2000000000"
+func parseUsageLimit(value string) (usageLimit, error) {
+	value = strings.TrimSpace(value)
+	if percent, found := strings.CutSuffix(value, "%"); found {
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return usageLimit{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return usageLimit{percent: p}, nil
+	}
+
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil || quantity.Value() <= 0 {
+		return usageLimit{}, fmt.Errorf("invalid size %q", value)
+	}
+	return usageLimit{bytes: quantity.Value()}, nil
+}
+
+type workflowDir struct {
+	path     string
+	size     int64
+	lastUsed time.Time
+}
+
+// enforceLogUsageLimit deletes the least recently used workflow directories under logDir
+// until usage is back under the limit, whatever their age. Directories written to within
+// activeWorkflowWindow and the telemetry directory are kept.
+func enforceLogUsageLimit(logDir, limitValue string) {
+	limit, err := parseUsageLimit(limitValue)
+	if err != nil {
+		logger.Errorf("failed to parse log usage limit: %s", err)
+		return
+	}
+
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		logger.Errorf("failed to read log dir: %s", err)
+		return
+	}
+
+	var dirs []workflowDir
+	var total int64
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "telemetry" {
+			continue
+		}
+		dir := scanWorkflowDir(filepath.Join(logDir, entry.Name()))
+		total += dir.size
+		dirs = append(dirs, dir)
+	}
+
+	overLimit := func() (bool, error) {
+		if limit.percent > 0 {
+			used, err := volumeUsagePercent(logDir)
+			return used > limit.percent, err
+		}
+		return total > limit.bytes, nil
+	}
+
+	over, err := overLimit()
+	if err != nil {
+		logger.Errorf("failed to read log volume usage: %s", err)
+		return
+	}
+	if !over {
+		return
+	}
+
+	logger.Warnf("log volume over %s, deleting least recently used workflow directories", limitValue)
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].lastUsed.Before(dirs[j].lastUsed) })
+
+	activeSince := time.Now().Add(-activeWorkflowWindow)
+	for _, dir := range dirs {
+		if !dir.lastUsed.Before(activeSince) {
+			// sorted by last use, every remaining directory is active
+			break
+		}
+
+		logger.Infof("deleting folder to free space: %s", dir.path)
+		if err := os.RemoveAll(dir.path); err != nil {
+			logger.Warnf("failed to delete folder %s: %s", dir.path, err)
+			continue
+		}
+		total -= dir.size
+
+		if over, err = overLimit(); err != nil || !over {
+			return
+		}
+	}
+	logger.Warnf("log volume still over %s, the remaining workflow directories are in use", limitValue)
+}
+
+// scanWorkflowDir returns the size of a workflow directory and when a file in it was last written
+func scanWorkflowDir(path string) workflowDir {
+	dir := workflowDir{path: path}
+	if info, err := os.Stat(path); err == nil {
+		dir.lastUsed = info.ModTime()
+	}
+
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		dir.size += info.Size()
+		if info.ModTime().After(dir.lastUsed) {
+			dir.lastUsed = info.ModTime()
+		}
+		return nil
+	})
+	return dir
+}
+
+// volumeUsagePercent returns how full the filesystem holding path is
+func volumeUsagePercent(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	total := uint64(stat.Blocks) * uint64(stat.Bsize)
+	if total == 0 {
+		return 0, fmt.Errorf("filesystem reports no size")
+	}
+	free := uint64(stat.Bavail) * uint64(stat.Bsize)
+	return float64(total-free) / float64(total) * 100, nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUsageLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    usageLimit
+		wantErr string
+	}{
+		{value: "85%", want: usageLimit{percent: 85}},
+		{value: " 92.5 % ", want: usageLimit{percent: 92.5}},
+		{value: "50Gi", want: usageLimit{bytes: 50 << 30}},
+		{value: "2000000000", want: usageLimit{bytes: 2000000000}},
+		{value: "100%", wantErr: `invalid percentage "100%"`},
+		{value: "0%", wantErr: `invalid percentage "0%"`},
+		{value: "full%", wantErr: `invalid percentage "full%"`},
+		{value: "0", wantErr: `invalid size "0"`},
+		{value: "lots", wantErr: `invalid size "lots"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseUsageLimit(tt.value)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnforceLogUsageLimit(t *testing.T) {
+	const day = 24 * time.Hour
+	// four workflow directories of 100 bytes each, from least to most recently used
+	ages := map[string]time.Duration{"sync-a": 3 * day, "sync-b": 2 * day, "sync-c": day, "sync-active": 10 * time.Minute}
+
+	tests := []struct {
+		limit string
+		want  []string
+	}{
+		{limit: "500", want: []string{"sync-a", "sync-active", "sync-b", "sync-c", "telemetry"}},
+		{limit: "250", want: []string{"sync-active", "sync-c", "telemetry"}},
+		// running workflows and telemetry are kept even when that leaves the volume over the limit
+		{limit: "50", want: []string{"sync-active", "telemetry"}},
+		{limit: "lots", want: []string{"sync-a", "sync-active", "sync-b", "sync-c", "telemetry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			logDir := t.TempDir()
+			for name, age := range ages {
+				writeLog(t, logDir, name+"/logs/worker.log", strings.Repeat("x", 100), age)
+				modTime := time.Now().Add(-age)
+				require.NoError(t, os.Chtimes(filepath.Join(logDir, name), modTime, modTime))
+			}
+			writeLog(t, logDir, "telemetry/user_id", strings.Repeat("x", 100), 10*day)
+
+			enforceLogUsageLimit(logDir, tt.limit)
+
+			entries, err := os.ReadDir(logDir)
+			require.NoError(t, err)
+			var remaining []string
+			for _, entry := range entries {
+				remaining = append(remaining, entry.Name())
+			}
+			require.Equal(t, tt.want, remaining)
+		})
+	}
+}
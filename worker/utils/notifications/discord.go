@@ -0,0 +1,119 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+// Discord message limits, see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	discordContentLimit    = 2000
+	discordEmbedLimit      = 6000
+	discordFieldValueLimit = 1024
+)
+
+// discordColors maps notification severities to embed colors
+var discordColors = map[string]int{
+	"critical": 0x992D22, // dark red
+	"error":    0xE74C3C, // red
+	"warning":  0xF1C40F, // yellow
+	"info":     0x3498DB, // blue
+}
+
+type discordMessage struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title     string         `json:"title"`
+	Color     int            `json:"color"`
+	Fields    []discordField `json:"fields"`
+	Timestamp string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// IsDiscordWebhook reports whether the webhook URL points at a Discord webhook
+func IsDiscordWebhook(webhookURL string) bool {
+	return strings.Contains(webhookURL, "discord.com/api/webhooks") || strings.Contains(webhookURL, "discordapp.com/api/webhooks")
+}
+
+// SendDiscordNotification posts a sync failure embed, colored by severity, to a Discord webhook.
+func SendDiscordNotification(ctx context.Context, req types.WebhookNotificationArgs, jobName, webhookURL, severity string) error {
+	if strings.TrimSpace(webhookURL) == "" {
+		return fmt.Errorf("discord webhook url not configured")
+	}
+
+	payload, err := json.Marshal(buildDiscordMessage(req, jobName, severity))
+	if err != nil {
+		return fmt.Errorf("failed to marshal discord message: %s", err)
+	}
+
+	resp, err := utils.PostJSON(ctx, webhookURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send discord notification: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("discord returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
+
+func buildDiscordMessage(req types.WebhookNotificationArgs, jobName, severity string) discordMessage {
+	severity = PagerDutySeverity(severity)
+	title := fmt.Sprintf("🚨 Sync Failure Detected: %s", jobName)
+	lastRunTime := req.LastRunTime.Format("2006-01-02 15:04:05 MST")
+
+	// the error gets whatever the other fields leave of the embed budget, capped at a field's limit
+	fixedLen := utf8.RuneCountInString(title) + len("Job ID") + len(strconv.Itoa(req.JobID)) +
+		len("Job Name") + utf8.RuneCountInString(jobName) + len("Last Run Time") + len(lastRunTime) + len("Error")
+	errorBudget := min(discordFieldValueLimit, discordEmbedLimit-fixedLen) - len("``````")
+
+	return discordMessage{
+		Content: truncateRunes(fmt.Sprintf("OLake sync failed for job %d", req.JobID), discordContentLimit),
+		Embeds: []discordEmbed{{
+			Title: truncateRunes(title, 256),
+			Color: discordColors[severity],
+			Fields: []discordField{
+				{Name: "Job ID", Value: strconv.Itoa(req.JobID), Inline: true},
+				{Name: "Job Name", Value: truncateRunes(orDefault(jobName, "-"), discordFieldValueLimit), Inline: true},
+				{Name: "Last Run Time", Value: lastRunTime, Inline: true},
+				{Name: "Error", Value: "```" + truncateRunes(trimErrorLogs(req.ErrorMessage), errorBudget) + "```"},
+			},
+			Timestamp: req.LastRunTime.Format(time.RFC3339),
+		}},
+	}
+}
+
+// truncateRunes cuts s to at most limit characters, marking the cut with an ellipsis
+func truncateRunes(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+func orDefault(s, fallback string) string {
+	if strings.TrimSpace(s) == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+const emailDialTimeout = 30 * time.Second
+
+// SMTP connection security modes
+const (
+	EmailTLSStartTLS = "starttls"
+	EmailTLSImplicit = "tls"
+	EmailTLSNone     = "none"
+)
+
+// EmailConfig holds the SMTP server and recipients used for failure emails
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLSMode  string
+}
+
+// Enabled reports whether an SMTP host has been configured at all
+func (c EmailConfig) Enabled() bool {
+	return strings.TrimSpace(c.Host) != ""
+}
+
+// Validate checks that the config has everything needed to deliver an email
+func (c EmailConfig) Validate() error {
+	if !c.Enabled() {
+		return fmt.Errorf("smtp host not configured")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid smtp port: %d", c.Port)
+	}
+	if strings.TrimSpace(c.From) == "" {
+		return fmt.Errorf("smtp sender address not configured")
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("smtp recipients not configured")
+	}
+	switch c.TLSMode {
+	case EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone:
+	default:
+		return fmt.Errorf("invalid smtp tls mode %q: expected %s, %s or %s", c.TLSMode, EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone)
+	}
+	return nil
+}
+
+// ParseEmailRecipients splits a comma separated recipient list, dropping empty entries
+func ParseEmailRecipients(recipients string) []string {
+	var to []string
+	for _, r := range strings.Split(recipients, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			to = append(to, r)
+		}
+	}
+	return to
+}
+
+// SendEmailNotification sends a sync failure email through the configured SMTP server.
+func SendEmailNotification(ctx context.Context, cfg EmailConfig, req types.WebhookNotificationArgs, jobName string) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: emailDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %s", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+	if cfg.TLSMode == EmailTLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create smtp client: %s", err)
+	}
+	defer client.Close()
+
+	if cfg.TLSMode == EmailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %s", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with smtp server: %s", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return fmt.Errorf("failed to set sender: %s", err)
+	}
+	for _, to := range cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %s", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start email body: %s", err)
+	}
+	if _, err := w.Write(buildFailureEmail(cfg, req, jobName)); err != nil {
+		return fmt.Errorf("failed to write email body: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %s", err)
+	}
+	return client.Quit()
+}
+
+func buildFailureEmail(cfg EmailConfig, req types.WebhookNotificationArgs, jobName string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: OLake sync failed for job %d (%s)\r\n", req.JobID, jobName)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString("Sync Failure Detected!\r\n\r\n")
+	fmt.Fprintf(&b, "Job ID: %d\r\n", req.JobID)
+	fmt.Fprintf(&b, "Job Name: %s\r\n", jobName)
+	fmt.Fprintf(&b, "Last Run Time: %s\r\n\r\n", req.LastRunTime.Format("2006-01-02 15:04:05 MST"))
+	b.WriteString("Error:\r\n")
+	b.WriteString(strings.ReplaceAll(trimErrorLogs(req.ErrorMessage), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+var pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type PagerDutyAction string
+
+const (
+	PagerDutyTrigger PagerDutyAction = "trigger"
+	PagerDutyResolve PagerDutyAction = "resolve"
+)
+
+// pagerDutySeverities are the severities accepted by the Events API v2
+var pagerDutySeverities = []string{"critical", "error", "warning", "info"}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction PagerDutyAction   `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// PagerDutyDedupKey groups all failures of a job into a single incident until it is resolved
+func PagerDutyDedupKey(jobID int) string {
+	return fmt.Sprintf("olake-sync-job-%d", jobID)
+}
+
+// PagerDutySeverity maps the configured severity to one accepted by PagerDuty, defaulting to "error"
+func PagerDutySeverity(severity string) string {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	for _, s := range pagerDutySeverities {
+		if s == severity {
+			return s
+		}
+	}
+	return "error"
+}
+
+// SendPagerDutyEvent triggers or resolves the incident for a job's sync failures.
+// Resolve events only need the routing and dedup key, so the payload is sent for triggers only.
+func SendPagerDutyEvent(ctx context.Context, routingKey string, action PagerDutyAction, severity string, req types.WebhookNotificationArgs, jobName string) error {
+	if strings.TrimSpace(routingKey) == "" {
+		return fmt.Errorf("pagerduty routing key not configured")
+	}
+
+	event := pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: action,
+		DedupKey:    PagerDutyDedupKey(req.JobID),
+	}
+
+	if action == PagerDutyTrigger {
+		event.Payload = &pagerDutyPayload{
+			Summary:   fmt.Sprintf("OLake sync failed for job %d (%s)", req.JobID, jobName),
+			Source:    "olake-worker",
+			Severity:  PagerDutySeverity(severity),
+			Timestamp: req.LastRunTime.Format(time.RFC3339),
+			CustomDetails: map[string]any{
+				"job_id":     req.JobID,
+				"job_name":   jobName,
+				"project_id": req.ProjectID,
+				"error":      trimErrorLogs(req.ErrorMessage),
+			},
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pagerduty event: %s", err)
+	}
+
+	resp, err := utils.PostJSON(ctx, pagerDutyEventsURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send pagerduty event: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// mockPagerDuty points SendPagerDutyEvent at a server recording the events it receives
+func mockPagerDuty(t *testing.T, status int) *[]pagerDutyEvent {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var event pagerDutyEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+		events = append(events, event)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := pagerDutyEventsURL
+	pagerDutyEventsURL = server.URL
+	t.Cleanup(func() { pagerDutyEventsURL = previous })
+	return &events
+}
+
+func TestSendPagerDutyEventTrigger(t *testing.T) {
+	events := mockPagerDuty(t, http.StatusAccepted)
+	req := types.WebhookNotificationArgs{
+		JobID:        42,
+		ProjectID:    "project-a",
+		LastRunTime:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		ErrorMessage: "connection refused",
+	}
+
+	require.NoError(t, SendPagerDutyEvent(context.Background(), "project-key", PagerDutyTrigger, "Critical", req, "orders"))
+
+	require.Len(t, *events, 1)
+	event := (*events)[0]
+	require.Equal(t, "project-key", event.RoutingKey)
+	require.Equal(t, PagerDutyTrigger, event.EventAction)
+	require.Equal(t, "olake-sync-job-42", event.DedupKey)
+	require.NotNil(t, event.Payload)
+	require.Equal(t, "OLake sync failed for job 42 (orders)", event.Payload.Summary)
+	require.Equal(t, "critical", event.Payload.Severity)
+	require.Equal(t, "2026-03-01T12:00:00Z", event.Payload.Timestamp)
+	require.Equal(t, "project-a", event.Payload.CustomDetails["project_id"])
+}
+
+func TestSendPagerDutyEventResolve(t *testing.T) {
+	events := mockPagerDuty(t, http.StatusAccepted)
+
+	require.NoError(t, SendPagerDutyEvent(context.Background(), "project-key", PagerDutyResolve, "", types.WebhookNotificationArgs{JobID: 42}, "orders"))
+
+	require.Len(t, *events, 1)
+	require.Equal(t, PagerDutyResolve, (*events)[0].EventAction)
+	require.Equal(t, "olake-sync-job-42", (*events)[0].DedupKey)
+	require.Nil(t, (*events)[0].Payload)
+}
+
+func TestSendPagerDutyEventErrors(t *testing.T) {
+	events := mockPagerDuty(t, http.StatusBadRequest)
+
+	err := SendPagerDutyEvent(context.Background(), "project-key", PagerDutyTrigger, "", types.WebhookNotificationArgs{JobID: 42}, "orders")
+	require.ErrorContains(t, err, "non-2xx")
+
+	err = SendPagerDutyEvent(context.Background(), " ", PagerDutyTrigger, "", types.WebhookNotificationArgs{JobID: 42}, "orders")
+	require.ErrorContains(t, err, "routing key not configured")
+	require.Len(t, *events, 1)
+}
@@ -0,0 +1,29 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+// SendPostSyncHook posts the summary of a completed sync to the project's post-sync hook, as
+// JSON rather than a chat message so downstream jobs can act on it
+func SendPostSyncHook(ctx context.Context, payload types.PostSyncHookPayload, hookURL string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal post-sync hook payload: %s", err)
+	}
+
+	resp, err := utils.PostJSON(ctx, hookURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to send post-sync hook: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("post-sync hook returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+func TestSendPostSyncHook(t *testing.T) {
+	records := int64(1200)
+	payload := types.PostSyncHookPayload{
+		JobID:         42,
+		JobName:       "orders",
+		WorkflowID:    "sync-42-abc",
+		Status:        types.SyncStatusCompleted,
+		FinishedAt:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		SyncedRecords: &records,
+		Streams:       []types.PostSyncHookStream{{Namespace: "public", Stream: "orders"}},
+	}
+
+	var received types.PostSyncHookPayload
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	require.NoError(t, SendPostSyncHook(context.Background(), payload, server.URL))
+	require.Equal(t, payload, received)
+
+	status = http.StatusBadRequest
+	require.ErrorContains(t, SendPostSyncHook(context.Background(), payload, server.URL), "non-2xx status: 400 Bad Request")
+}
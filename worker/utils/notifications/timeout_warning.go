@@ -0,0 +1,75 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+// SendTimeoutWarningNotification warns the project webhook that a sync is still running and
+// is approaching its timeout. Discord webhooks get an embed, others the plain text message.
+func SendTimeoutWarningNotification(ctx context.Context, req types.TimeoutWarningArgs, jobName, webhookURL string) error {
+	if strings.TrimSpace(webhookURL) == "" {
+		return fmt.Errorf("webhook_alert_url not configured")
+	}
+
+	var message any = WebhookMessage{Text: buildTimeoutWarningText(req, jobName)}
+	if IsDiscordWebhook(webhookURL) {
+		message = buildTimeoutWarningEmbed(req, jobName)
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timeout warning: %s", err)
+	}
+
+	resp, err := utils.PostJSON(ctx, webhookURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send timeout warning: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned non-2xx status: %s", resp.Status)
+	}
+	return nil
+}
+
+func buildTimeoutWarningText(req types.TimeoutWarningArgs, jobName string) string {
+	return fmt.Sprintf(
+		"⚠️ *Sync Approaching Timeout* \n\n"+
+			"------------------------------------------- \n\n"+
+			"• *Job ID:* `%d` \n\n"+
+			"• *Job Name:* `%s` \n\n"+
+			"• *Running For:* %s of %s \n\n"+
+			"• *Started At:* %s \n\n"+
+			"------------------------------------------- \n\n",
+		req.JobID,
+		jobName,
+		req.Elapsed.Round(time.Second),
+		req.Timeout.Round(time.Second),
+		req.StartedAt.Format("2006-01-02 15:04:05 MST"),
+	)
+}
+
+func buildTimeoutWarningEmbed(req types.TimeoutWarningArgs, jobName string) discordMessage {
+	return discordMessage{
+		Content: fmt.Sprintf("OLake sync for job %d is approaching its timeout", req.JobID),
+		Embeds: []discordEmbed{{
+			Title: truncateRunes(fmt.Sprintf("⚠️ Sync Approaching Timeout: %s", jobName), 256),
+			Color: discordColors["warning"],
+			Fields: []discordField{
+				{Name: "Job ID", Value: strconv.Itoa(req.JobID), Inline: true},
+				{Name: "Job Name", Value: truncateRunes(orDefault(jobName, "-"), discordFieldValueLimit), Inline: true},
+				{Name: "Running For", Value: fmt.Sprintf("%s of %s", req.Elapsed.Round(time.Second), req.Timeout.Round(time.Second)), Inline: true},
+				{Name: "Started At", Value: req.StartedAt.Format("2006-01-02 15:04:05 MST"), Inline: true},
+			},
+			Timestamp: req.StartedAt.Add(req.Elapsed).Format(time.RFC3339),
+		}},
+	}
+}
@@ -1,14 +1,14 @@
 package notifications
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 
+	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils"
 )
 
 type WebhookMessage struct {
@@ -26,21 +26,22 @@ func SendWebhookNotification(ctx context.Context, req types.WebhookNotificationA
 			"------------------------------------------- \n\n"+
 			"• *Job ID:* `%d` \n\n"+
 			"• *Job Name:* `%s` \n\n"+
+			"%s"+
 			"• *Error:* ```%s``` \n\n"+
 			"• *Last Run Time:* %s \n\n"+
 			"------------------------------------------- \n\n",
 		req.JobID,
 		jobName,
+		formatExitReason(req),
 		trimErrorLogs(req.ErrorMessage),
 		req.LastRunTime.Format("2006-01-02 15:04:05 MST"),
 	)
 
 	payload, _ := json.Marshal(WebhookMessage{Text: message})
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(payload))
+	resp, err := utils.PostJSON(ctx, webhookURL, payload)
 	if err != nil {
 		return fmt.Errorf("failed to send webhook notification: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		return fmt.Errorf("webhook returned non-2xx status: %s", resp.Status)
@@ -48,6 +49,19 @@ func SendWebhookNotification(ctx context.Context, req types.WebhookNotificationA
 	return nil
 }
 
+// formatExitReason describes how the connector ended, pointing out-of-memory kills at the
+// memory limit since raising it is the fix
+func formatExitReason(req types.WebhookNotificationArgs) string {
+	if req.ExitReason == "" {
+		return ""
+	}
+	hint := ""
+	if req.ExitReason == constants.ExitReasonOOMKilled {
+		hint = " (connector ran out of memory, raise its memory limit)"
+	}
+	return fmt.Sprintf("• *Exit Reason:* `%s`, exit code `%d`%s \n\n", req.ExitReason, req.ExitCode, hint)
+}
+
 func trimErrorLogs(logs string) string {
 	lines := strings.Split(logs, "\n")
 	var filtered []string
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+	"golang.org/x/mod/semver"
+)
+
+// outputTypes are the typed message envelopes a command's result is read from
+var outputTypes = map[types.Command][]string{
+	types.Spec:             {"SPEC"},
+	types.Check:            {"CONNECTION_STATUS"},
+	types.Discover:         {"CATALOG"},
+	types.Sync:             {"STATE"},
+	types.ClearDestination: {"STATE"},
+}
+
+// dryRunOutputTypes are the envelopes a clear-destination dry run reports its plan in
+var dryRunOutputTypes = []string{"PLAN"}
+
+// ExtractConnectorOutput returns the result of a connector command from its output.
+// Connectors emitting typed envelopes are parsed by message type, taking the last matching
+// message (e.g. the last STATE of a sync); older connectors, and output without a matching
+// typed message, fall back to the last JSON line. A clear-destination dry run returns its PLAN.
+func ExtractConnectorOutput(output string, req *types.ExecutionRequest) ([]byte, error) {
+	if !SupportsTypedOutput(req.Version) {
+		return ExtractJSONAndMarshal(output)
+	}
+
+	messageTypes := outputTypes[req.Command]
+	if req.DryRun && req.Command == types.ClearDestination {
+		messageTypes = dryRunOutputTypes
+	}
+
+	result, err := ExtractTypedMessage(output, messageTypes...)
+	if err != nil {
+		logger.Debugf("no typed %s output found (%s), falling back to last JSON line", req.Command, err)
+		return ExtractJSONAndMarshal(output)
+	}
+	return result, nil
+}
+
+// SupportsTypedOutput reports whether the connector version emits typed message envelopes.
+// Non-semver versions such as "latest" or "dev" are treated as current.
+func SupportsTypedOutput(version string) bool {
+	minVersion := canonicalVersion(viper.GetString(constants.EnvConnectorTypedOutputMinVersion))
+	current := canonicalVersion(version)
+	if minVersion == "" || current == "" {
+		return true
+	}
+	return semver.Compare(current, minVersion) >= 0
+}
+
+// ExtractTypedMessage returns the last line of output that is a complete JSON object whose
+// "type" field is one of the given types. Lines merely containing braces, such as log
+// messages quoting JSON, are ignored.
+func ExtractTypedMessage(output string, messageTypes ...string) ([]byte, error) {
+	if len(messageTypes) == 0 {
+		return nil, fmt.Errorf("no message types to match")
+	}
+
+	outputStr := strings.TrimSpace(output)
+	if maxBytes := viper.GetInt(constants.EnvOutputScanMaxBytes); maxBytes > 0 && len(outputStr) > maxBytes {
+		outputStr = outputStr[len(outputStr)-maxBytes:]
+	}
+
+	for lineEnd := len(outputStr); lineEnd > 0; {
+		lineStart := strings.LastIndexByte(outputStr[:lineEnd], '\n') + 1
+		line := strings.TrimSpace(outputStr[lineStart:lineEnd])
+		lineEnd = lineStart - 1
+		if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+			continue
+		}
+
+		// only the type is decoded; the line itself is returned as is, so a large catalog isn't
+		// rebuilt and re-serialized
+		var message struct {
+			Type any `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			continue
+		}
+		messageType, _ := message.Type.(string)
+		for _, t := range messageTypes {
+			if strings.EqualFold(messageType, t) {
+				return []byte(line), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no %s message found in output", strings.Join(messageTypes, "/"))
+}
+
+// ParseConnectionStatus reads the outcome of a check from its extracted output, the
+// CONNECTION_STATUS message of newer connectors or the bare status object of older ones
+func ParseConnectionStatus(output []byte) (bool, string, error) {
+	var message struct {
+		ConnectionStatus *struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"connectionStatus"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(output, &message); err != nil {
+		return false, "", fmt.Errorf("failed to parse connection status: %s", err)
+	}
+
+	status, statusMessage := message.Status, message.Message
+	if message.ConnectionStatus != nil {
+		status, statusMessage = message.ConnectionStatus.Status, message.ConnectionStatus.Message
+	}
+	if status == "" {
+		return false, "", fmt.Errorf("no connection status found in output")
+	}
+	return strings.EqualFold(status, "SUCCEEDED"), statusMessage, nil
+}
+
+// canonicalVersion turns a connector image tag into a semver string, or "" if it isn't one
+func canonicalVersion(version string) string {
+	// a digest pinned tag compares as its tag
+	version, _, _ = strings.Cut(strings.TrimSpace(version), "@")
+	if version == "" {
+		return ""
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if !semver.IsValid(version) {
+		return ""
+	}
+	return semver.Canonical(version)
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+)
+
+// outputHeadShare is the part of the capped output kept from the start, for context on how
+// the connector started; the rest keeps the end, where the result JSON is
+const outputHeadShare = 10
+
+// OutputBuffer collects connector output up to a byte limit. Beyond the limit it keeps the
+// first bytes and the last bytes, dropping the middle, so a connector printing gigabytes of
+// logs can't exhaust the worker's memory while its final result line stays recoverable.
+type OutputBuffer struct {
+	head    []byte
+	tail    []byte // ring buffer once full
+	tailPos int
+	tailCap int
+	dropped int64
+	limited bool
+}
+
+// NewOutputBuffer returns a buffer keeping at most maxBytes, or everything when maxBytes <= 0
+func NewOutputBuffer(maxBytes int) *OutputBuffer {
+	if maxBytes <= 0 {
+		return &OutputBuffer{}
+	}
+	headCap := maxBytes / outputHeadShare
+	return &OutputBuffer{
+		head:    make([]byte, 0, headCap),
+		tailCap: maxBytes - headCap,
+		limited: true,
+	}
+}
+
+// Write implements io.Writer
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if !b.limited {
+		b.tail = append(b.tail, p...)
+		return n, nil
+	}
+
+	if room := cap(b.head) - len(b.head); room > 0 {
+		take := min(room, len(p))
+		b.head = append(b.head, p[:take]...)
+		p = p[take:]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	// fill the tail until it reaches capacity, then overwrite its oldest bytes
+	if len(b.tail) < b.tailCap {
+		take := min(b.tailCap-len(b.tail), len(p))
+		b.tail = append(b.tail, p[:take]...)
+		p = p[take:]
+	}
+	if len(p) > b.tailCap {
+		b.dropped += int64(len(p) - b.tailCap)
+		p = p[len(p)-b.tailCap:]
+	}
+	for len(p) > 0 {
+		written := copy(b.tail[b.tailPos:], p)
+		b.dropped += int64(written)
+		b.tailPos = (b.tailPos + written) % b.tailCap
+		p = p[written:]
+	}
+	return n, nil
+}
+
+// Truncated reports how many bytes were dropped from the middle of the output
+func (b *OutputBuffer) Truncated() int64 {
+	return b.dropped
+}
+
+// Len returns the number of bytes kept
+func (b *OutputBuffer) Len() int {
+	return len(b.head) + len(b.tail)
+}
+
+// Bytes returns the kept output, with a notice where bytes were dropped
+func (b *OutputBuffer) Bytes() []byte {
+	out := make([]byte, 0, b.Len()+64)
+	out = append(out, b.head...)
+	if b.dropped > 0 {
+		out = append(out, fmt.Sprintf("\n... [%d bytes of connector output truncated] ...\n", b.dropped)...)
+	}
+	out = append(out, b.tail[b.tailPos:]...)
+	return append(out, b.tail[:b.tailPos]...)
+}
+
+// String returns the kept output as a string
+func (b *OutputBuffer) String() string {
+	return string(b.Bytes())
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticOutput is a connector log of numbered lines ending in the result JSON
+func syntheticOutput(lines int, result string) string {
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "2026-01-01T00:00:00Z INFO reading chunk %06d\n", i)
+	}
+	b.WriteString(result + "\n")
+	return b.String()
+}
+
+// writeInChunks writes output in chunks of varying size, as a log stream arrives
+func writeInChunks(t *testing.T, buf *OutputBuffer, output string) {
+	sizes := []int{1, 7, 333, 4096, 50}
+	for i := 0; len(output) > 0; i++ {
+		chunk := output[:min(sizes[i%len(sizes)], len(output))]
+		n, err := buf.Write([]byte(chunk))
+		require.NoError(t, err)
+		require.Equal(t, len(chunk), n)
+		output = output[len(chunk):]
+	}
+}
+
+func TestOutputBufferKeepsHeadAndTail(t *testing.T) {
+	const maxBytes = 1000
+	result := `{"status":"completed","records":123456}`
+	output := syntheticOutput(100000, result)
+
+	buf := NewOutputBuffer(maxBytes)
+	writeInChunks(t, buf, output)
+
+	headCap := maxBytes / outputHeadShare
+	require.Equal(t, maxBytes, buf.Len())
+	require.Equal(t, int64(len(output)-maxBytes), buf.Truncated())
+
+	kept := buf.String()
+	notice := fmt.Sprintf("\n... [%d bytes of connector output truncated] ...\n", len(output)-maxBytes)
+	require.Equal(t, output[:headCap]+notice+output[len(output)-(maxBytes-headCap):], kept)
+
+	extracted, err := ExtractJSONAndMarshal(kept)
+	require.NoError(t, err)
+	require.JSONEq(t, result, string(extracted))
+}
+
+func TestOutputBufferSingleLargeWrite(t *testing.T) {
+	output := syntheticOutput(1000, `{"status":"completed"}`)
+
+	buf := NewOutputBuffer(100)
+	_, err := buf.Write([]byte(output))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(len(output)-100), buf.Truncated())
+	require.True(t, strings.HasPrefix(buf.String(), output[:10]))
+	require.True(t, strings.HasSuffix(buf.String(), output[len(output)-90:]))
+}
+
+func TestOutputBufferWithinLimit(t *testing.T) {
+	output := syntheticOutput(10, `{"status":"completed"}`)
+
+	for _, maxBytes := range []int{0, len(output)} {
+		buf := NewOutputBuffer(maxBytes)
+		writeInChunks(t, buf, output)
+		require.Zero(t, buf.Truncated())
+		require.Equal(t, output, buf.String())
+	}
+}
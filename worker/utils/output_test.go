@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTypedMessage(t *testing.T) {
+	// key order and escaping are kept as the connector wrote them
+	const catalog = `{"type":"CATALOG","catalog":{"streams":[{"name":"orders","note":"a < b"}]}}`
+	output := "INFO discovering\n" + catalog + "\nINFO received {\"type\":\"CATALOG\"} from source\n" + `{"type":"LOG","message":"done"}`
+
+	got, err := ExtractTypedMessage(output, "catalog")
+	require.NoError(t, err)
+	require.Equal(t, catalog, string(got))
+
+	_, err = ExtractTypedMessage(output, "SPEC")
+	require.EqualError(t, err, "no SPEC message found in output")
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+)
+
+// State regression check modes for STATE_REGRESSION_CHECK
+const (
+	StateRegressionOff  = "off"
+	StateRegressionWarn = "warn"
+	StateRegressionFail = "fail"
+)
+
+// GetStateMarkerPath returns the dot-separated path of the monotonic marker (e.g. "global.state.lsn")
+// configured for the connector type in STATE_REGRESSION_MARKERS, or "" if none is configured
+func GetStateMarkerPath(connectorType string) string {
+	markersJSON := strings.TrimSpace(viper.GetString(constants.EnvStateRegressionMarkers))
+	if markersJSON == "" {
+		return ""
+	}
+
+	var markers map[string]string
+	if err := json.Unmarshal([]byte(markersJSON), &markers); err != nil {
+		return ""
+	}
+	for connector, path := range markers {
+		if strings.EqualFold(connector, connectorType) {
+			return strings.TrimSpace(path)
+		}
+	}
+	return ""
+}
+
+// IsStateRegressed reports whether the marker at path in newState is older than in prevState.
+// Numbers compare numerically, Postgres LSNs ("16/B374D848") by position and anything else
+// lexically, which orders RFC 3339 timestamps correctly. A marker missing from either state
+// cannot be compared and is not treated as a regression.
+func IsStateRegressed(prevState, newState, path string) (bool, error) {
+	prev, prevOk, err := stateMarker(prevState, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read marker from prior state: %s", err)
+	}
+	next, nextOk, err := stateMarker(newState, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read marker from new state: %s", err)
+	}
+	if !prevOk || !nextOk {
+		return false, nil
+	}
+	return compareMarkers(prev, next) > 0, nil
+}
+
+func stateMarker(state, path string) (any, bool, error) {
+	if IsStateEmpty(state) {
+		return nil, false, nil
+	}
+
+	var current any
+	decoder := json.NewDecoder(strings.NewReader(state))
+	decoder.UseNumber()
+	if err := decoder.Decode(&current); err != nil {
+		return nil, false, err
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		if current, ok = object[key]; !ok || current == nil {
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+// compareMarkers returns -1, 0 or 1 as a is older than, equal to or newer than b
+func compareMarkers(a, b any) int {
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+
+	if af, err := strconv.ParseFloat(as, 64); err == nil {
+		if bf, err := strconv.ParseFloat(bs, 64); err == nil {
+			return compareOrdered(af, bf)
+		}
+	}
+	if al, ok := parseLSN(as); ok {
+		if bl, ok := parseLSN(bs); ok {
+			return compareOrdered(al, bl)
+		}
+	}
+	return strings.Compare(as, bs)
+}
+
+func compareOrdered[T int | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseLSN parses a Postgres log sequence number of the form "XXXXXXXX/XXXXXXXX"
+func parseLSN(lsn string) (uint64, bool) {
+	hi, lo, found := strings.Cut(lsn, "/")
+	if !found {
+		return 0, false
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return h<<32 | l, true
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStateMarkerPath(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvStateRegressionMarkers, nil) })
+
+	viper.Set(constants.EnvStateRegressionMarkers, `{"Postgres":" global.state.lsn "}`)
+	require.Equal(t, "global.state.lsn", GetStateMarkerPath("postgres"))
+	require.Empty(t, GetStateMarkerPath("mysql"))
+
+	viper.Set(constants.EnvStateRegressionMarkers, `{"postgres":`)
+	require.Empty(t, GetStateMarkerPath("postgres"))
+}
+
+func TestIsStateRegressed(t *testing.T) {
+	tests := []struct {
+		name      string
+		prevState string
+		newState  string
+		want      bool
+		wantErr   string
+	}{
+		{name: "lsn moved forward", prevState: `{"global":{"lsn":"16/B374D848"}}`, newState: `{"global":{"lsn":"17/0"}}`},
+		{name: "lsn moved backwards", prevState: `{"global":{"lsn":"16/B374D848"}}`, newState: `{"global":{"lsn":"16/B374D847"}}`, want: true},
+		{name: "lsn compared by position", prevState: `{"global":{"lsn":"9/0"}}`, newState: `{"global":{"lsn":"10/0"}}`},
+		{name: "numbers compared numerically", prevState: `{"global":{"lsn":900}}`, newState: `{"global":{"lsn":1000}}`},
+		{name: "number moved backwards", prevState: `{"global":{"lsn":1000}}`, newState: `{"global":{"lsn":999}}`, want: true},
+		{name: "timestamps", prevState: `{"global":{"lsn":"2024-05-02T00:00:00Z"}}`, newState: `{"global":{"lsn":"2024-05-01T00:00:00Z"}}`, want: true},
+		{name: "unchanged", prevState: `{"global":{"lsn":"16/B374D848"}}`, newState: `{"global":{"lsn":"16/B374D848"}}`},
+		{name: "first run", prevState: "", newState: `{"global":{"lsn":"16/B374D848"}}`},
+		{name: "marker missing", prevState: `{"global":{"lsn":"16/B374D848"}}`, newState: `{"streams":[]}`},
+		{name: "unreadable new state", prevState: `{"global":{"lsn":"16/B374D848"}}`, newState: `{"global":`, wantErr: "failed to read marker from new state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regressed, err := IsStateRegressed(tt.prevState, tt.newState, "global.lsn")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, regressed)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
+)
+
+// FileStore keeps state in the workflow directories on the shared volume, where connectors
+// read and write it
+type FileStore struct {
+	Root string
+}
+
+// NewFileStore returns the store of the job directories of the executor environment
+func NewFileStore() *FileStore {
+	return &FileStore{Root: utils.GetConfigDir()}
+}
+
+func (f *FileStore) ReadState(_ context.Context, subdir string) (string, error) {
+	path := filepath.Join(f.Root, subdir, stateFileName)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrStateNotFound, path)
+	}
+
+	state, err := utils.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read state file: %s", err)
+	}
+	return state, nil
+}
+
+// WriteState writes the state file, leaving it untouched when it already holds this state
+func (f *FileStore) WriteState(_ context.Context, subdir, state string) error {
+	path := filepath.Join(f.Root, subdir, stateFileName)
+	if current, err := os.ReadFile(path); err == nil && string(current) == state {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(state), constants.DefaultFilePermissions); err != nil {
+		return fmt.Errorf("failed to write state file: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package statestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+func TestFileStoreReadWrite(t *testing.T) {
+	ctx := context.Background()
+	store := &FileStore{Root: t.TempDir()}
+	require.NoError(t, os.MkdirAll(filepath.Join(store.Root, "sync-1"), 0o755))
+
+	_, err := store.ReadState(ctx, "sync-1")
+	require.ErrorIs(t, err, ErrStateNotFound)
+
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"1"}`))
+	state, err := store.ReadState(ctx, "sync-1")
+	require.NoError(t, err)
+	require.Equal(t, `{"lsn":"1"}`, state)
+}
+
+func TestFileStoreWriteSameState(t *testing.T) {
+	ctx := context.Background()
+	store := &FileStore{Root: t.TempDir()}
+	path := filepath.Join(store.Root, "sync-1", stateFileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"1"}`))
+
+	// an unchanged state leaves the file as the connector wrote it
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, past, past))
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"1"}`))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(past))
+
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"2"}`))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().After(past))
+}
+
+func TestNewSelectsBackend(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvStateStore, nil) })
+
+	for _, backend := range []string{"", BackendFilesystem, " Filesystem "} {
+		viper.Set(constants.EnvStateStore, backend)
+		store, err := New(context.Background())
+		require.NoError(t, err)
+		require.IsType(t, &FileStore{}, store)
+	}
+
+	viper.Set(constants.EnvStateStore, "azure")
+	_, err := New(context.Background())
+	require.ErrorContains(t, err, "invalid STATE_STORE: azure")
+}
@@ -0,0 +1,110 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+)
+
+// S3Store keeps state as objects in an S3-compatible bucket. Any service speaking the S3 API
+// works through STATE_STORE_ENDPOINT, e.g. MinIO, or Google Cloud Storage with HMAC keys at
+// https://storage.googleapis.com.
+type S3Store struct {
+	bucket string
+	prefix string
+	client *s3.Client
+}
+
+// NewS3Store configures an S3Store from STATE_STORE_* and the default AWS credential chain
+func NewS3Store(ctx context.Context) (*S3Store, error) {
+	bucket := viper.GetString(constants.EnvStateStoreBucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("%s is not set", constants.EnvStateStoreBucket)
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %s", err)
+	}
+	if region := viper.GetString(constants.EnvStateStoreRegion); region != "" {
+		cfg.Region = region
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("%s is not set and no AWS region is configured", constants.EnvStateStoreRegion)
+	}
+
+	endpoint := strings.TrimSuffix(viper.GetString(constants.EnvStateStoreEndpoint), "/")
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.HTTPClient = &http.Client{Timeout: viper.GetDuration(constants.EnvHTTPClientTimeout)}
+		// S3-compatible services, GCS among them, reject the checksum trailers the SDK
+		// otherwise sends with every upload
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		// path style on custom endpoints, which S3-compatible services support more widely
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Store{
+		bucket: bucket,
+		prefix: strings.Trim(viper.GetString(constants.EnvStateStorePrefix), "/"),
+		client: client,
+	}, nil
+}
+
+func (s *S3Store) ReadState(ctx context.Context, subdir string) (string, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(subdir)),
+	})
+	if isNotFound(err) {
+		return "", fmt.Errorf("%w: s3://%s/%s", ErrStateNotFound, s.bucket, s.key(subdir))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get state object: %s", err)
+	}
+	defer out.Body.Close()
+
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read state object: %s", err)
+	}
+	return string(body), nil
+}
+
+func (s *S3Store) WriteState(ctx context.Context, subdir, state string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key(subdir)),
+		Body:        strings.NewReader(state),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put state object: %s", err)
+	}
+	return nil
+}
+
+// key returns the object key of a workflow's state
+func (s *S3Store) key(subdir string) string {
+	return path.Join(s.prefix, subdir, stateFileName)
+}
+
+// isNotFound matches NoSuchKey as well as the bare 404 some S3-compatible services answer with
+func isNotFound(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,106 @@
+package statestore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+)
+
+// fakeS3 is a path-style S3 endpoint keeping objects in memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string // "/bucket/key" -> body
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = string(body)
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newTestS3Store points an S3Store at a fakeS3 with static credentials
+func newTestS3Store(t *testing.T, prefix string) (*S3Store, *fakeS3) {
+	fake := &fakeS3{objects: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	for key, value := range map[string]string{
+		constants.EnvStateStoreBucket:   "olake-state",
+		constants.EnvStateStorePrefix:   prefix,
+		constants.EnvStateStoreRegion:   "us-east-1",
+		constants.EnvStateStoreEndpoint: server.URL + "/",
+	} {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	store, err := NewS3Store(context.Background())
+	require.NoError(t, err)
+	return store, fake
+}
+
+func TestS3StoreReadWrite(t *testing.T) {
+	ctx := context.Background()
+	store, fake := newTestS3Store(t, "/olake/prod/")
+
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"1"}`))
+	require.Equal(t, map[string]string{"/olake-state/olake/prod/sync-1/state.json": `{"lsn":"1"}`}, fake.objects)
+
+	state, err := store.ReadState(ctx, "sync-1")
+	require.NoError(t, err)
+	require.Equal(t, `{"lsn":"1"}`, state)
+
+	// the latest write is the state of record
+	require.NoError(t, store.WriteState(ctx, "sync-1", `{"lsn":"2"}`))
+	state, err = store.ReadState(ctx, "sync-1")
+	require.NoError(t, err)
+	require.Equal(t, `{"lsn":"2"}`, state)
+}
+
+func TestS3StoreStateNotFound(t *testing.T) {
+	store, _ := newTestS3Store(t, "")
+
+	_, err := store.ReadState(context.Background(), "sync-missing")
+	require.ErrorIs(t, err, ErrStateNotFound)
+	require.ErrorContains(t, err, "s3://olake-state/sync-missing/state.json")
+}
+
+func TestNewS3StoreRequiresBucket(t *testing.T) {
+	viper.Set(constants.EnvStateStoreBucket, "")
+	t.Cleanup(func() { viper.Set(constants.EnvStateStoreBucket, nil) })
+
+	_, err := NewS3Store(context.Background())
+	require.ErrorContains(t, err, constants.EnvStateStoreBucket)
+}
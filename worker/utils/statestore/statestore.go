@@ -0,0 +1,52 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+)
+
+// Store backends selected by STATE_STORE
+const (
+	BackendFilesystem = "filesystem"
+	BackendS3         = "s3"
+)
+
+// stateFileName is the state file inside a workflow directory
+const stateFileName = "state.json"
+
+// ErrStateNotFound is returned when a workflow has no state in the store
+var ErrStateNotFound = errors.New("state not found")
+
+// Store reads and writes the state file of a workflow. subdir is the workflow directory name
+// (see utils.GetWorkflowDirectory), which keys the state in every backend.
+type Store interface {
+	ReadState(ctx context.Context, subdir string) (string, error)
+	WriteState(ctx context.Context, subdir, state string) error
+}
+
+// New returns the store selected by STATE_STORE, which holds the state of record of every
+// workflow. Connectors always read and write the state file in the job directory, so with
+// object storage that file is only a scratch copy: the executor stages the stored state into
+// it before the connector starts and saves the connector's final state back to the store.
+func New(ctx context.Context) (Store, error) {
+	backend := strings.ToLower(strings.TrimSpace(viper.GetString(constants.EnvStateStore)))
+	switch backend {
+	case "", BackendFilesystem:
+		return NewFileStore(), nil
+	case BackendS3:
+		store, err := NewS3Store(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 state store: %s", err)
+		}
+		logger.Infof("keeping workflow state in s3 bucket %s", store.bucket)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("invalid %s: %s", constants.EnvStateStore, backend)
+	}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Streams validation modes for STREAMS_VALIDATION
+const (
+	StreamsValidationOff  = "off"
+	StreamsValidationWarn = "warn"
+	StreamsValidationFail = "fail"
+)
+
+// CanonicalizeStreams cleans up a streams.json catalog before it is handed to the connector.
+// Catalog streams without a name and duplicates of an earlier stream are dropped, as are
+// selected streams without a stream_name or selected twice. Selected streams missing from the
+// catalog are kept but reported, the connector decides what to do with them. The result is
+// re-encoded with sorted keys; fields this function does not know about are preserved. An
+// error is returned only when the catalog cannot be parsed at all.
+func CanonicalizeStreams(data string) (string, []string, error) {
+	var catalog map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &catalog); err != nil {
+		return "", nil, fmt.Errorf("failed to parse streams config: %s", err)
+	}
+
+	var problems []string
+	var streams []map[string]any
+	if raw, found := catalog["streams"]; found {
+		if err := decodeKeepingNumbers(raw, &streams); err != nil {
+			return "", nil, fmt.Errorf("failed to parse streams list: %s", err)
+		}
+	}
+
+	known := make(map[string]bool, len(streams))
+	keptStreams := make([]map[string]any, 0, len(streams))
+	for i, entry := range streams {
+		name, namespace := streamIdentity(entry)
+		if name == "" {
+			problems = append(problems, fmt.Sprintf("streams[%d] has no stream name, dropped", i))
+			continue
+		}
+		key := namespace + "." + name
+		if known[key] {
+			problems = append(problems, fmt.Sprintf("duplicate stream %s, dropped", key))
+			continue
+		}
+		known[key] = true
+		keptStreams = append(keptStreams, entry)
+	}
+
+	var selected map[string][]map[string]any
+	if raw, found := catalog["selected_streams"]; found {
+		if err := decodeKeepingNumbers(raw, &selected); err != nil {
+			return "", nil, fmt.Errorf("failed to parse selected streams: %s", err)
+		}
+	}
+
+	namespaces := make([]string, 0, len(selected))
+	for namespace := range selected {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		seen := make(map[string]bool, len(selected[namespace]))
+		kept := make([]map[string]any, 0, len(selected[namespace]))
+		for i, entry := range selected[namespace] {
+			name, _ := entry["stream_name"].(string)
+			if name == "" {
+				problems = append(problems, fmt.Sprintf("selected_streams[%s][%d] has no stream_name, dropped", namespace, i))
+				continue
+			}
+			key := namespace + "." + name
+			if seen[name] {
+				problems = append(problems, fmt.Sprintf("stream %s selected more than once, dropped", key))
+				continue
+			}
+			seen[name] = true
+			if len(known) > 0 && !known[key] {
+				problems = append(problems, fmt.Sprintf("selected stream %s is not in the catalog", key))
+			}
+			kept = append(kept, entry)
+		}
+		selected[namespace] = kept
+	}
+
+	if streams != nil {
+		encoded, err := json.Marshal(keptStreams)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode streams list: %s", err)
+		}
+		catalog["streams"] = encoded
+	}
+	if selected != nil {
+		encoded, err := json.Marshal(selected)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode selected streams: %s", err)
+		}
+		catalog["selected_streams"] = encoded
+	}
+
+	canonical, err := json.Marshal(catalog)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode streams config: %s", err)
+	}
+	return string(canonical), problems, nil
+}
+
+// streamIdentity returns the name and namespace of a catalog stream entry
+func streamIdentity(entry map[string]any) (string, string) {
+	stream, _ := entry["stream"].(map[string]any)
+	name, _ := stream["name"].(string)
+	namespace, _ := stream["namespace"].(string)
+	return name, namespace
+}
+
+// decodeKeepingNumbers decodes raw into v without turning numbers into float64, so large
+// integers in the catalog survive the round trip unchanged
+func decodeKeepingNumbers(raw json.RawMessage, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeStreams(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		want         string
+		wantProblems []string
+		wantErr      string
+	}{
+		{
+			name: "clean catalog",
+			// keys are sorted and large numbers survive the round trip
+			data: `{"sync_mode":"cdc","streams":[{"stream":{"namespace":"public","name":"orders","cursor":12345678901234567890}}],"selected_streams":{"public":[{"stream_name":"orders","partition_regex":""}]}}`,
+			want: `{"selected_streams":{"public":[{"partition_regex":"","stream_name":"orders"}]},"streams":[{"stream":{"cursor":12345678901234567890,"name":"orders","namespace":"public"}}],"sync_mode":"cdc"}`,
+		},
+		{
+			name: "unnamed and duplicate streams",
+			data: `{"streams":[{"stream":{"name":"orders","namespace":"public"}},{"stream":{"name":"orders","namespace":"public"}},{"stream":{}}],"selected_streams":{"public":[{"stream_name":"orders"},{"stream_name":"orders"},{},{"stream_name":"users"}]}}`,
+			want: `{"selected_streams":{"public":[{"stream_name":"orders"},{"stream_name":"users"}]},"streams":[{"stream":{"name":"orders","namespace":"public"}}]}`,
+			wantProblems: []string{
+				"duplicate stream public.orders, dropped",
+				"streams[2] has no stream name, dropped",
+				"stream public.orders selected more than once, dropped",
+				"selected_streams[public][2] has no stream_name, dropped",
+				"selected stream public.users is not in the catalog",
+			},
+		},
+		{
+			name: "selection without a catalog",
+			data: `{"selected_streams":{"public":[{"stream_name":"users"}]}}`,
+			want: `{"selected_streams":{"public":[{"stream_name":"users"}]}}`,
+		},
+		{name: "unreadable catalog", data: `{"streams":`, wantErr: "failed to parse streams config"},
+		{name: "unreadable streams list", data: `{"streams":{}}`, wantErr: "failed to parse streams list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, problems, err := CanonicalizeStreams(tt.data)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantProblems, problems)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// connectorStatsFile is written by the connector next to olake.log in logs/<command>_<timestamp>/
+const connectorStatsFile = "stats.json"
+
+// ReadSyncSummary fills the record count and duration a sync's connector reported in its
+// stats.json, and the streams of its final state from output.json. Whatever can't be read is
+// left empty rather than failing, as the summary only feeds the post-sync hook.
+func ReadSyncSummary(workdir string, payload *types.PostSyncHookPayload) error {
+	var errs []string
+
+	if stats, err := readConnectorStats(workdir); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		if records, ok := statNumber(stats["Synced Records"]); ok {
+			count := int64(records)
+			payload.SyncedRecords = &count
+		}
+		if seconds, ok := statNumber(stats["Seconds Elapsed"]); ok {
+			payload.DurationSeconds = seconds
+		}
+	}
+
+	streams, err := readStateStreams(filepath.Join(workdir, constants.OutputFileName))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	payload.Streams = streams
+
+	if len(errs) > 0 {
+		return fmt.Errorf("incomplete sync summary: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// readConnectorStats reads stats.json of the latest run in the workflow's logs directory
+func readConnectorStats(workdir string) (map[string]any, error) {
+	matches, err := filepath.Glob(filepath.Join(workdir, "logs", "*", connectorStatsFile))
+	if err != nil || len(matches) == 0 {
+		return nil, fmt.Errorf("no %s found", connectorStatsFile)
+	}
+	// run directories are suffixed with their start time, so the last one is the latest run
+	sort.Strings(matches)
+
+	data, err := os.ReadFile(matches[len(matches)-1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", connectorStatsFile, err)
+	}
+	var stats map[string]any
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", connectorStatsFile, err)
+	}
+	return stats, nil
+}
+
+// statNumber reads a stats value written either as a number or as a string such as "12.5 s"
+func statNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			return 0, false
+		}
+		number, err := strconv.ParseFloat(fields[0], 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// readStateStreams lists the streams in a sync's final state, either the bare state of older
+// connectors or the state inside a typed STATE message
+func readStateStreams(outputPath string) ([]types.PostSyncHookStream, error) {
+	streams := []types.PostSyncHookStream{}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return streams, fmt.Errorf("failed to read final state: %s", err)
+	}
+
+	type streamList struct {
+		Streams []types.PostSyncHookStream `json:"streams"`
+	}
+	var state struct {
+		streamList
+		State *streamList `json:"state"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return streams, fmt.Errorf("failed to parse final state: %s", err)
+	}
+	found := state.Streams
+	if len(found) == 0 && state.State != nil {
+		found = state.State.Streams
+	}
+	for _, stream := range found {
+		if stream.Stream != "" {
+			streams = append(streams, stream)
+		}
+	}
+	return streams, nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSyncSummary(t *testing.T) {
+	writeFile := func(t *testing.T, path, data string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		workdir := t.TempDir()
+		// only the latest run's stats count
+		writeFile(t, filepath.Join(workdir, "logs", "sync_2026-03-01_10-00-00", "stats.json"), `{"Synced Records": 5}`)
+		writeFile(t, filepath.Join(workdir, "logs", "sync_2026-03-01_12-00-00", "stats.json"), `{"Synced Records": 1200, "Seconds Elapsed": "12.50 s"}`)
+		writeFile(t, filepath.Join(workdir, constants.OutputFileName), `{"type":"STATE","state":{"streams":[{"namespace":"public","stream":"orders"},{"stream":""}]}}`)
+
+		var payload types.PostSyncHookPayload
+		require.NoError(t, ReadSyncSummary(workdir, &payload))
+		require.NotNil(t, payload.SyncedRecords)
+		require.EqualValues(t, 1200, *payload.SyncedRecords)
+		require.Equal(t, 12.5, payload.DurationSeconds)
+		require.Equal(t, []types.PostSyncHookStream{{Namespace: "public", Stream: "orders"}}, payload.Streams)
+	})
+
+	t.Run("legacy state", func(t *testing.T) {
+		workdir := t.TempDir()
+		writeFile(t, filepath.Join(workdir, constants.OutputFileName), `{"streams":[{"stream":"users"}]}`)
+
+		// the missing stats leave the counts empty
+		var payload types.PostSyncHookPayload
+		require.ErrorContains(t, ReadSyncSummary(workdir, &payload), "no stats.json found")
+		require.Nil(t, payload.SyncedRecords)
+		require.Equal(t, []types.PostSyncHookStream{{Stream: "users"}}, payload.Streams)
+	})
+
+	t.Run("removed workdir", func(t *testing.T) {
+		var payload types.PostSyncHookPayload
+		err := ReadSyncSummary(filepath.Join(t.TempDir(), "gone"), &payload)
+		require.ErrorContains(t, err, "failed to read final state")
+		require.Empty(t, payload.Streams)
+		require.NotNil(t, payload.Streams)
+	})
+}
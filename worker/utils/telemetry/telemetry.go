@@ -1,13 +1,13 @@
 package telemetry
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/utils"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 	"github.com/spf13/viper"
 )
@@ -18,13 +18,27 @@ const (
 	TelemetryEventStarted   TelemetryEvent = "started"
 	TelemetryEventCompleted TelemetryEvent = "completed"
 	TelemetryEventFailed    TelemetryEvent = "failed"
+	TelemetryEventSkipped   TelemetryEvent = "skipped"
 )
 
-// event = "started" | "completed" | "failed"
+// LogStatus logs once at startup when telemetry is disabled
+func LogStatus() {
+	if viper.GetBool(constants.EnvTelemetryDisabled) {
+		logger.Infof("telemetry disabled, no sync telemetry events will be sent")
+	}
+}
+
+// SendEvent reports a sync event to olake-ui, unless TELEMETRY_DISABLED (or
+// OLAKE_TELEMETRY_OPT_OUT) is set, in which case nothing is sent.
+// event = "started" | "completed" | "failed" | "skipped"
 func SendEvent(jobId int, executionEnvironment, workflowId string, event TelemetryEvent) {
+	if viper.GetBool(constants.EnvTelemetryDisabled) {
+		return
+	}
+
 	go func() {
 		switch event {
-		case TelemetryEventStarted, TelemetryEventCompleted, TelemetryEventFailed:
+		case TelemetryEventStarted, TelemetryEventCompleted, TelemetryEventFailed, TelemetryEventSkipped:
 		default:
 			logger.Warnf("invalid telemetry event: %s", event)
 			return
@@ -47,20 +61,14 @@ func SendEvent(jobId int, executionEnvironment, workflowId string, event Telemet
 			return
 		}
 
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+		resp, err := utils.PostJSONWithHeaders(context.Background(), url, jsonData, utils.CallbackHeaders())
 		if err != nil {
 			logger.Warnf("failed to update sync telemetry: %s", err)
 			return
 		}
-		defer func() {
-			if cerr := resp.Body.Close(); cerr != nil {
-				logger.Warnf("failed to close response body: %s", cerr)
-			}
-		}()
 
 		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			logger.Debugf("sync telemetry update failed: %d %s", resp.StatusCode, string(body))
+			logger.Debugf("sync telemetry update failed: %d %s", resp.StatusCode, string(resp.Body))
 		}
 	}()
 }
@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendEvent(t *testing.T) {
+	events := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sync-telemetry", r.URL.Path)
+		// the callback is authenticated with OLAKE_CALLBACK_TOKEN
+		require.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		var event map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+	viper.Set(constants.EnvCallbackURL, server.URL)
+	viper.Set(constants.EnvCallbackToken, "s3cret")
+	t.Cleanup(func() {
+		viper.Set(constants.EnvCallbackURL, nil)
+		viper.Set(constants.EnvCallbackToken, nil)
+		viper.Set(constants.EnvTelemetryDisabled, nil)
+	})
+
+	SendEvent(7, "kubernetes", "sync-7-abc", TelemetryEventStarted)
+	select {
+	case event := <-events:
+		require.Equal(t, map[string]any{"job_id": float64(7), "workflow_id": "sync-7-abc", "environment": "kubernetes", "event": "started"}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("telemetry event not sent")
+	}
+
+	// nothing leaves the worker once telemetry is disabled
+	viper.Set(constants.EnvTelemetryDisabled, true)
+	SendEvent(7, "kubernetes", "sync-7-abc", TelemetryEventCompleted)
+	select {
+	case event := <-events:
+		t.Fatalf("telemetry event sent while disabled: %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// temporalHeaderKey is the Temporal header carrying the span context between the client,
+// workflows and activities
+const temporalHeaderKey = "_tracer-data"
+
+type temporalSpanContextKey struct{}
+
+// NewTemporalInterceptor traces workflow starts, workflows and activities. Set on the client,
+// it also applies to the workers created from it.
+func NewTemporalInterceptor() interceptor.Interceptor {
+	return interceptor.NewTracingInterceptor(&temporalTracer{
+		tracer:     otel.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	})
+}
+
+// temporalTracer adapts OpenTelemetry to the SDK's tracing interceptor
+type temporalTracer struct {
+	interceptor.BaseTracer
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type temporalSpanRef struct {
+	trace.SpanContext
+}
+
+type temporalSpan struct {
+	trace.Span
+}
+
+func (s *temporalSpan) Finish(opts *interceptor.TracerFinishSpanOptions) {
+	End(s.Span, opts.Error)
+}
+
+func (t *temporalTracer) Options() interceptor.TracerOptions {
+	return interceptor.TracerOptions{
+		SpanContextKey:          temporalSpanContextKey{},
+		HeaderKey:               temporalHeaderKey,
+		AllowInvalidParentSpans: true,
+	}
+}
+
+func (t *temporalTracer) UnmarshalSpan(data map[string]string) (interceptor.TracerSpanRef, error) {
+	spanContext := trace.SpanContextFromContext(t.propagator.Extract(context.Background(), propagation.MapCarrier(data)))
+	if !spanContext.IsValid() {
+		return nil, fmt.Errorf("no valid span context in Temporal header")
+	}
+	return &temporalSpanRef{SpanContext: spanContext}, nil
+}
+
+func (t *temporalTracer) MarshalSpan(span interceptor.TracerSpan) (map[string]string, error) {
+	data := make(map[string]string)
+	t.propagator.Inject(trace.ContextWithSpan(context.Background(), span.(*temporalSpan).Span), propagation.MapCarrier(data))
+	return data, nil
+}
+
+func (t *temporalTracer) SpanFromContext(ctx context.Context) interceptor.TracerSpan {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return &temporalSpan{Span: span}
+}
+
+func (t *temporalTracer) ContextWithSpan(ctx context.Context, span interceptor.TracerSpan) context.Context {
+	return trace.ContextWithSpan(ctx, span.(*temporalSpan).Span)
+}
+
+func (t *temporalTracer) StartSpan(opts *interceptor.TracerStartSpanOptions) (interceptor.TracerSpan, error) {
+	ctx := context.Background()
+	switch parent := opts.Parent.(type) {
+	case nil:
+	case *temporalSpan:
+		ctx = trace.ContextWithSpan(ctx, parent.Span)
+	case *temporalSpanRef:
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent.SpanContext)
+	default:
+		return nil, fmt.Errorf("unrecognized parent span type %T", parent)
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(opts.Tags)+1)
+	for key, value := range opts.Tags {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+	if workflowID, ok := opts.Tags["temporalWorkflowID"]; ok {
+		attrs = append(attrs, AttrWorkflowID.String(workflowID))
+	}
+
+	_, span := t.tracer.Start(ctx, t.SpanName(opts), trace.WithTimestamp(opts.Time), trace.WithAttributes(attrs...))
+	return &temporalSpan{Span: span}, nil
+}
@@ -0,0 +1,105 @@
+// Package tracing exports OpenTelemetry traces of workflows, activities and connector runs to
+// an OTLP/HTTP collector. Tracing is off unless OTEL_EXPORTER_OTLP_ENDPOINT is set; the spans
+// started meanwhile go to the no-op global tracer.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+)
+
+const instrumentationName = "github.com/datazip-inc/olake-helm/worker"
+
+// Span attribute keys shared by the worker's spans
+const (
+	AttrWorkflowID    = attribute.Key("olake.workflow_id")
+	AttrJobID         = attribute.Key("olake.job_id")
+	AttrCommand       = attribute.Key("olake.command")
+	AttrConnectorType = attribute.Key("olake.connector_type")
+)
+
+// Enabled reports whether traces are exported
+func Enabled() bool {
+	return viper.GetString(constants.EnvOTLPEndpoint) != ""
+}
+
+// Init installs the global tracer provider exporting to OTEL_EXPORTER_OTLP_ENDPOINT. The
+// returned function flushes buffered spans and must be called on shutdown.
+func Init() (func(context.Context) error, error) {
+	if !Enabled() {
+		logger.Infof("tracing disabled, %s is not set", constants.EnvOTLPEndpoint)
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := tracesURL(viper.GetString(constants.EnvOTLPEndpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %s", err)
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", viper.GetString(constants.EnvOTelServiceName)))
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	logger.Infof("tracing enabled, exporting spans to %s", endpoint)
+	return provider.Shutdown, nil
+}
+
+// tracesURL appends the OTLP/HTTP traces path to the collector base URL, as the OTLP
+// exporters do for OTEL_EXPORTER_OTLP_ENDPOINT
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: expected a URL such as http://otel-collector:4318", constants.EnvOTLPEndpoint, endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+	return u.String(), nil
+}
+
+// Start starts a span as a child of the one in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RequestAttributes identifies the run of an execution request on a span
+func RequestAttributes(req *types.ExecutionRequest) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrWorkflowID.String(req.WorkflowID),
+		AttrJobID.Int(req.JobID),
+		AttrCommand.String(string(req.Command)),
+		AttrConnectorType.String(req.ConnectorType),
+	}
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+)
+
+// recordSpans installs a tracer provider keeping the ended spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := recordSpans(t)
+	req := &types.ExecutionRequest{WorkflowID: "sync-7-abc", JobID: 7, Command: types.Sync, ConnectorType: "postgres"}
+
+	ctx, parent := Start(context.Background(), "workflow", RequestAttributes(req)...)
+	_, child := Start(ctx, "connector")
+	End(child, errors.New("connector exited with code 1"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	connector, workflow := spans[0], spans[1]
+
+	require.Equal(t, "connector", connector.Name())
+	require.Equal(t, workflow.SpanContext().SpanID(), connector.Parent().SpanID())
+	require.Equal(t, workflow.SpanContext().TraceID(), connector.SpanContext().TraceID())
+	require.Equal(t, codes.Error, connector.Status().Code)
+	require.Equal(t, "connector exited with code 1", connector.Status().Description)
+	require.Len(t, connector.Events(), 1)
+	require.Equal(t, "exception", connector.Events()[0].Name)
+
+	require.Equal(t, codes.Unset, workflow.Status().Code)
+	require.ElementsMatch(t, RequestAttributes(req), workflow.Attributes())
+}
+
+func TestTracesURL(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"http://otel-collector:4318":     "http://otel-collector:4318/v1/traces",
+		"http://otel-collector:4318/":    "http://otel-collector:4318/v1/traces",
+		"https://collector.example/otlp": "https://collector.example/otlp/v1/traces",
+		"otel-collector:4318":            "",
+	} {
+		got, err := tracesURL(endpoint)
+		if want == "" {
+			require.Error(t, err, endpoint)
+			continue
+		}
+		require.NoError(t, err, endpoint)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestInitExportsToCollector(t *testing.T) {
+	var exported atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" && r.Method == http.MethodPost {
+			exported.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	viper.Set(constants.EnvOTLPEndpoint, collector.URL)
+	t.Cleanup(func() { viper.Set(constants.EnvOTLPEndpoint, "") })
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Init()
+	require.NoError(t, err)
+	_, span := Start(context.Background(), "activity")
+	End(span, nil)
+
+	require.NoError(t, shutdown(context.Background()))
+	require.EqualValues(t, 1, exported.Load())
+}
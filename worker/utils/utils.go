@@ -5,16 +5,22 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/datazip-inc/olake-helm/worker/constants"
 	"github.com/datazip-inc/olake-helm/worker/types"
 	"github.com/datazip-inc/olake-helm/worker/utils/logger"
 	"github.com/spf13/viper"
+	"go.temporal.io/sdk/activity"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Ternary returns trueValue if condition is true, otherwise returns falseValue
@@ -41,56 +47,344 @@ func Unmarshal(from, object any) error {
 
 // RetryWithBackoff retries a function with exponential backoff
 func RetryWithBackoff(fn func() error, maxRetries int, initialDelay time.Duration) error {
-	delay := initialDelay
+	return RetryWithBackoffJittered(fn, BackoffOptions{MaxRetries: maxRetries, InitialDelay: initialDelay})
+}
+
+// BackoffOptions configures RetryWithBackoffJittered
+type BackoffOptions struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration // caps the doubling delay, 0 for no cap
+	Timeout      time.Duration // stops retrying once this long has passed since the first attempt, 0 for no limit
+	// Jitter sleeps a random duration between half and all of the delay (equal jitter), so
+	// workers retrying against the same recovering service don't retry in lockstep
+	Jitter bool
+}
+
+// RetryWithBackoffJittered retries a function with exponential backoff, optionally capped
+// and jittered
+func RetryWithBackoffJittered(fn func() error, opts BackoffOptions) error {
+	delay := opts.InitialDelay
+	start := time.Now()
 	var errMsg error
 
-	for retry := 0; retry < maxRetries; retry++ {
+	for retry := 0; retry < opts.MaxRetries; retry++ {
 		if err := fn(); err != nil {
 			errMsg = err
-			if retry < maxRetries-1 {
-				logger.Warnf("retry attempt %d/%d failed: %s. retrying in %v...", retry+1, maxRetries, err, delay)
-				time.Sleep(delay)
+			if retry < opts.MaxRetries-1 {
+				sleep := backoffSleep(delay, opts.Jitter)
+				if opts.Timeout > 0 && time.Since(start)+sleep > opts.Timeout {
+					return fmt.Errorf("failed after %d retries in %s: %s", retry+1, time.Since(start).Round(time.Second), errMsg)
+				}
+				logger.Warnf("retry attempt %d/%d failed: %s. retrying in %v...", retry+1, opts.MaxRetries, err, sleep)
+				time.Sleep(sleep)
 				delay *= 2
+				if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+					delay = opts.MaxDelay
+				}
 				continue
 			}
 		} else {
 			return nil
 		}
 	}
-	return fmt.Errorf("failed after %d retries: %s", maxRetries, errMsg)
+	return fmt.Errorf("failed after %d retries: %s", opts.MaxRetries, errMsg)
+}
+
+// backoffSleep returns delay, or with jitter a random duration in [delay/2, delay]
+func backoffSleep(delay time.Duration, jitter bool) time.Duration {
+	if !jitter || delay <= 0 {
+		return delay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
 }
 
+// GetDockerImageName returns the connector image for the source type and version, built for
+// the architecture from ConnectorImageArch
 func GetDockerImageName(sourceType, version string) string {
-	registryBase := strings.TrimRight(viper.GetString(constants.ContainerRegistryBase), "/")
+	return GetDockerImageNameForArch(sourceType, version, ConnectorImageArch())
+}
+
+// GetDockerImageNameForArch returns the connector image for the source type and version. An entry for
+// the type in CONNECTOR_IMAGE_OVERRIDES is used as the full image, with {version} substituted;
+// otherwise the default olakego/source-<type> image is used under CONTAINER_REGISTRY_BASE.
+// The version is a tag (v0.2.0), a pinned tag (v0.2.0@sha256:...) or a bare digest (sha256:...).
+// Connectors in CONNECTOR_IMAGE_ARCH_SUFFIXES get the arch suffix appended to plain tags.
+func GetDockerImageNameForArch(sourceType, version, arch string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "@")
+	version = withArchSuffix(sourceType, version, arch)
+	if template, found := getConnectorImageOverride(sourceType); found {
+		if isImageDigest(version) {
+			template = strings.ReplaceAll(template, ":{version}", "@{version}")
+		}
+		return strings.ReplaceAll(template, "{version}", version)
+	}
+
+	registryBase := normalizeRegistryBase(viper.GetString(constants.ContainerRegistryBase))
 	imageName := fmt.Sprintf("%s-%s:%s", constants.DefaultDockerImagePrefix, sourceType, version)
+	if isImageDigest(version) {
+		imageName = fmt.Sprintf("%s-%s@%s", constants.DefaultDockerImagePrefix, sourceType, version)
+	}
 
-	if registryBase == "" || registryBase == "registry-1.docker.io" {
+	// Docker Hub images are pulled by their short name; never prefix the base twice
+	if registryBase == "" || strings.HasPrefix(imageName, registryBase+"/") {
 		return imageName
 	}
 
 	return fmt.Sprintf("%s/%s", registryBase, imageName)
 }
 
+// dockerHubRegistries are the spellings of the default registry, under which images keep their short name
+var dockerHubRegistries = []string{"registry-1.docker.io", "docker.io", "index.docker.io"}
+
+// normalizeRegistryBase strips the scheme and trailing slashes from a registry base and
+// returns "" for Docker Hub
+func normalizeRegistryBase(registryBase string) string {
+	registryBase = strings.TrimSpace(registryBase)
+	registryBase = strings.TrimPrefix(strings.TrimPrefix(registryBase, "https://"), "http://")
+	registryBase = strings.TrimRight(registryBase, "/")
+	if slices.Contains(dockerHubRegistries, registryBase) {
+		return ""
+	}
+	return registryBase
+}
+
+// getConnectorImageOverride returns the image template configured for the source type in the
+// CONNECTOR_IMAGE_OVERRIDES JSON map, if any
+func getConnectorImageOverride(sourceType string) (string, bool) {
+	return getConnectorImageSetting(constants.EnvConnectorImageOverrides, sourceType)
+}
+
+// getConnectorImageSetting returns the source type's entry in the JSON map of source type to
+// value held by the given env
+func getConnectorImageSetting(key, sourceType string) (string, bool) {
+	settingsJSON := strings.TrimSpace(viper.GetString(key))
+	if settingsJSON == "" {
+		return "", false
+	}
+
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+		logger.Warnf("failed to parse %s: %s, using default connector images", key, err)
+		return "", false
+	}
+	for connector, value := range settings {
+		if strings.EqualFold(connector, sourceType) && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// ConnectorImageArch returns the architecture per-architecture connector images are chosen
+// for: CONNECTOR_IMAGE_ARCH, or the worker's own architecture
+func ConnectorImageArch() string {
+	if arch := strings.TrimSpace(viper.GetString(constants.EnvConnectorImageArch)); arch != "" {
+		return arch
+	}
+	return runtime.GOARCH
+}
+
+// UsesArchImages reports whether the source type's connector publishes per-architecture tags
+func UsesArchImages(sourceType string) bool {
+	_, found := getConnectorImageSetting(constants.EnvConnectorImageArchSuffixes, sourceType)
+	return found
+}
+
+// withArchSuffix appends the source type's arch suffix, with {arch} substituted, to a plain
+// tag. Versions pinned to a digest already name exact content and are left as they are.
+func withArchSuffix(sourceType, version, arch string) string {
+	suffix, found := getConnectorImageSetting(constants.EnvConnectorImageArchSuffixes, sourceType)
+	if !found || arch == "" || strings.Contains(version, "@") || isImageDigest(version) {
+		return version
+	}
+	return version + strings.ReplaceAll(suffix, "{arch}", arch)
+}
+
+// imageDigestPattern matches the content digest a connector image can be pinned to
+var imageDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+func isImageDigest(value string) bool {
+	return imageDigestPattern.MatchString(value)
+}
+
+// GetExpectedImageDigest returns the digest the source type's connector image must have from
+// the CONNECTOR_IMAGE_DIGESTS JSON map, or "" when none is configured. An unreadable map or
+// digest is an error rather than skipping the check.
+func GetExpectedImageDigest(sourceType string) (string, error) {
+	digestsJSON := strings.TrimSpace(viper.GetString(constants.EnvConnectorImageDigests))
+	if digestsJSON == "" {
+		return "", nil
+	}
+
+	var digests map[string]string
+	if err := json.Unmarshal([]byte(digestsJSON), &digests); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %s", constants.EnvConnectorImageDigests, err)
+	}
+	for connector, digest := range digests {
+		if !strings.EqualFold(connector, sourceType) {
+			continue
+		}
+		digest = strings.ToLower(strings.TrimSpace(digest))
+		if !isImageDigest(digest) {
+			return "", fmt.Errorf("invalid %s digest %q for %s, expected sha256:<64 hex characters>", constants.EnvConnectorImageDigests, digest, connector)
+		}
+		return digest, nil
+	}
+	return "", nil
+}
+
+// PinImageDigest pins the image reference to the expected digest so the container runtime
+// refuses any other content. A reference already pinned to another digest fails.
+func PinImageDigest(imageName, expected string) (string, error) {
+	if expected == "" {
+		return imageName, nil
+	}
+	if _, digest, pinned := strings.Cut(imageName, "@"); pinned {
+		if digest != expected {
+			return "", fmt.Errorf("%w: image %s is pinned to a digest other than the expected %s", constants.ErrExecutionFailed, imageName, expected)
+		}
+		return imageName, nil
+	}
+	return imageName + "@" + expected, nil
+}
+
+// GetSyncTimeout returns the sync timeout for the connector type from SYNC_TIMEOUT_OVERRIDES,
+// a JSON map of connector type to duration (e.g. {"mongodb":"1440h"}), or the default sync
+// timeout when the type isn't listed
+func GetSyncTimeout(connectorType string) time.Duration {
+	overridesJSON := strings.TrimSpace(viper.GetString(constants.EnvSyncTimeoutOverrides))
+	if overridesJSON == "" || connectorType == "" {
+		return constants.DefaultSyncTimeout
+	}
+
+	var overrides map[string]string
+	if err := json.Unmarshal([]byte(overridesJSON), &overrides); err != nil {
+		logger.Warnf("failed to parse %s: %s, using default sync timeout", constants.EnvSyncTimeoutOverrides, err)
+		return constants.DefaultSyncTimeout
+	}
+	for connector, value := range overrides {
+		if !strings.EqualFold(connector, connectorType) {
+			continue
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			logger.Warnf("invalid sync timeout %q for %s, using default sync timeout", value, connectorType)
+			return constants.DefaultSyncTimeout
+		}
+		return timeout
+	}
+	return constants.DefaultSyncTimeout
+}
+
+// heartbeatIntervalRatio keeps polling well inside the heartbeat timeout, so a slow status
+// call doesn't make a healthy activity miss its heartbeat
+const heartbeatIntervalRatio = 0.8
+
+// heartbeatIntervalWarning reports a too long HEARTBEAT_INTERVAL once rather than on every poll
+var heartbeatIntervalWarning sync.Once
+
+// GetHeartbeatInterval returns how often the executors poll the connector and heartbeat:
+// HEARTBEAT_INTERVAL, capped at 0.8x the heartbeat timeout the activity was scheduled with.
+// Outside an activity with a heartbeat timeout, SYNC_HEARTBEAT_TIMEOUT is the cap.
+func GetHeartbeatInterval(ctx context.Context) time.Duration {
+	interval := viper.GetDuration(constants.EnvHeartbeatInterval)
+	if interval <= 0 {
+		interval = constants.DefaultHeartbeatInterval
+	}
+
+	// the workflow records the timeout when it schedules the activity, so a sync scheduled
+	// before SYNC_HEARTBEAT_TIMEOUT changed keeps its own
+	var timeout time.Duration
+	if activity.IsActivity(ctx) {
+		timeout = activity.GetInfo(ctx).HeartbeatTimeout
+	}
+	if timeout <= 0 {
+		timeout = viper.GetDuration(constants.EnvSyncHeartbeatTimeout)
+	}
+	if timeout <= 0 {
+		timeout = constants.DefaultHeartbeatTimeout
+	}
+	if limit := time.Duration(float64(timeout) * heartbeatIntervalRatio); interval > limit {
+		heartbeatIntervalWarning.Do(func() {
+			logger.Warnf("%s %s is too close to the heartbeat timeout %s, polling every %s instead",
+				constants.EnvHeartbeatInterval, interval, timeout, limit)
+		})
+		return limit
+	}
+	return interval
+}
+
+// GetTriggerEnvVars returns the OLAKE_TRIGGER_* variables describing what started the run,
+// or nil when CONNECTOR_TRIGGER_ENV is disabled or the run has no trigger context
+func GetTriggerEnvVars(req *types.ExecutionRequest) map[string]string {
+	if req.Trigger == nil || !viper.GetBool(constants.EnvConnectorTriggerEnv) {
+		return nil
+	}
+
+	vars := map[string]string{"OLAKE_TRIGGER_TYPE": req.Trigger.Type}
+	if req.Trigger.ScheduleID != "" {
+		vars["OLAKE_TRIGGER_SCHEDULE_ID"] = req.Trigger.ScheduleID
+	}
+	if !req.Trigger.ScheduledTime.IsZero() {
+		vars["OLAKE_TRIGGER_SCHEDULED_TIME"] = req.Trigger.ScheduledTime.UTC().Format(time.RFC3339)
+	}
+	if req.Trigger.Cron != "" {
+		vars["OLAKE_TRIGGER_CRON"] = req.Trigger.Cron
+	}
+	return vars
+}
+
+// IsReservedConnectorEnv reports whether the worker sets the variable on every connector run,
+// so job env can't override it
+func IsReservedConnectorEnv(name string) bool {
+	return name == "OLAKE_WORKFLOW_ID" || strings.HasPrefix(name, "OLAKE_SECRET_KEY") || strings.HasPrefix(name, "OLAKE_TRIGGER_")
+}
+
+// GetJobEnvVars merges job env maps, later ones winning, dropping invalid and reserved names
+func GetJobEnvVars(layers ...map[string]string) map[string]string {
+	vars := make(map[string]string)
+	for _, layer := range layers {
+		for name, value := range layer {
+			if errs := validation.IsEnvVarName(name); len(errs) > 0 || IsReservedConnectorEnv(name) {
+				logger.Warnf("ignoring invalid or reserved connector env variable '%s'", name)
+				continue
+			}
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
 // GetWorkerEnvVars returns the environment variables from the worker container.
 func GetWorkerEnvVars() map[string]string {
 	// ignoredWorkerEnv is a map of environment variables that are ignored from the worker container.
 	var ignoredWorkerEnv = map[string]any{
-		"HOSTNAME":                nil,
-		"PATH":                    nil,
-		"PWD":                     nil,
-		"HOME":                    nil,
-		"SHLVL":                   nil,
-		"TERM":                    nil,
-		"PERSISTENT_DIR":          nil,
-		"CONTAINER_REGISTRY_BASE": nil,
-		"TEMPORAL_ADDRESS":        nil,
-		"TEMPORAL_API_KEY":        nil,
-		"TEMPORAL_EXTERNAL":       nil,
-		"TEMPORAL_ENABLE_TLS":     nil,
-		"TEMPORAL_NAMESPACE":      nil,
-		"TEMPORAL_TASK_QUEUE":     nil,
-		"OLAKE_SECRET_KEY":        nil,
-		"_":                       nil,
+		"HOSTNAME":                  nil,
+		"PATH":                      nil,
+		"PWD":                       nil,
+		"HOME":                      nil,
+		"SHLVL":                     nil,
+		"TERM":                      nil,
+		"PERSISTENT_DIR":            nil,
+		"CONTAINER_REGISTRY_BASE":   nil,
+		"IMAGE_REGISTRY":            nil,
+		"TEMPORAL_ADDRESS":          nil,
+		"TEMPORAL_API_KEY":          nil,
+		"TEMPORAL_EXTERNAL":         nil,
+		"TEMPORAL_ENABLE_TLS":       nil,
+		"TEMPORAL_NAMESPACE":        nil,
+		"TEMPORAL_TASK_QUEUE":       nil,
+		"OLAKE_SECRET_KEY":          nil,
+		"OLAKE_SECRET_KEY_PREVIOUS": nil,
+		"PAGERDUTY_ROUTING_KEY":     nil,
+		"FALLBACK_WEBHOOK_URL":      nil,
+		"WORKER_ADMIN_TOKEN":        nil,
+		"SMTP_PASSWORD":             nil,
+		"OLAKE_CALLBACK_TOKEN":      nil,
+		"DB_READ_URL":               nil,
+		"_":                         nil,
 	}
 
 	vars := make(map[string]string)
@@ -129,16 +423,24 @@ func applyConfigUpdates(req *types.ExecutionRequest, updates map[string]string,
 func UpdateConfigWithJobDetails(jobData types.JobData, req *types.ExecutionRequest) {
 	req.Version = jobData.Version
 
+	// a full refresh starts from an empty state; the saved one is kept until the run completes
+	state := jobData.State
+	if req.Options.UseEmptyState {
+		state = "{}"
+	}
+
 	updates := map[string]string{
 		"source.json":      jobData.Source,
 		"destination.json": jobData.Destination,
 		"streams.json":     jobData.Streams,
-		"state.json":       jobData.State,
+		"state.json":       state,
 	}
 
 	addIfMissing := make(map[string]string)
 	if !viper.GetBool(constants.EnvTelemetryDisabled) {
-		addIfMissing["user_id.txt"] = GetTelemetryUserID()
+		if userID := GetTelemetryUserID(); userID != "" {
+			addIfMissing["user_id.txt"] = userID
+		}
 	}
 
 	applyConfigUpdates(req, updates, addIfMissing)
@@ -194,16 +496,37 @@ func GetConfigDir() string {
 	}
 }
 
+// missingTelemetryIDWarning makes a missing telemetry user ID file a one-time warning
+var missingTelemetryIDWarning sync.Once
+
+// GetTelemetryUserID returns the telemetry user ID written by the UI, or "" when the file is
+// missing or empty. That is expected when the UI has not generated an ID, so it is warned
+// about once, unless TELEMETRY_USER_ID_REQUIRED makes it an error on every run. With telemetry
+// disabled the file is never read.
 func GetTelemetryUserID() string {
+	if viper.GetBool(constants.EnvTelemetryDisabled) {
+		return ""
+	}
+
 	root := GetConfigDir()
 	telemetryPath := filepath.Join(root, "telemetry", "user_id")
 
 	userID, err := os.ReadFile(telemetryPath)
-	if err != nil {
+	if err == nil && strings.TrimSpace(string(userID)) != "" {
+		return string(userID)
+	}
+	if err == nil {
+		err = fmt.Errorf("file is empty")
+	}
+
+	if viper.GetBool(constants.EnvTelemetryUserIDRequired) {
 		logger.Errorf("failed to read telemetry user ID from file %s: %s", telemetryPath, err)
-		return ""
+	} else {
+		missingTelemetryIDWarning.Do(func() {
+			logger.Warnf("telemetry user ID not available from %s (%s), syncs run without user_id.txt", telemetryPath, err)
+		})
 	}
-	return string(userID)
+	return ""
 }
 
 // getHostOutputDir returns the host output directory
@@ -266,6 +589,29 @@ func GetTemporalTaskQueue() string {
 	return constants.TaskQueue
 }
 
+// GetTaskQueueForConnector returns the task queue that workflows of the given connector type
+// should run on. TEMPORAL_CONNECTOR_TASK_QUEUES maps connector types to dedicated queues
+// (e.g. {"mongodb":"olake-heavy"}); unmapped connectors use the default task queue.
+func GetTaskQueueForConnector(connectorType string) string {
+	routesJSON := strings.TrimSpace(viper.GetString(constants.EnvConnectorTaskQueues))
+	if routesJSON == "" {
+		return GetTemporalTaskQueue()
+	}
+
+	var routes map[string]string
+	if err := json.Unmarshal([]byte(routesJSON), &routes); err != nil {
+		logger.Warnf("failed to parse %s: %s. using default task queue.", constants.EnvConnectorTaskQueues, err)
+		return GetTemporalTaskQueue()
+	}
+
+	for connector, queue := range routes {
+		if strings.EqualFold(connector, connectorType) && strings.TrimSpace(queue) != "" {
+			return strings.TrimSpace(queue)
+		}
+	}
+	return GetTemporalTaskQueue()
+}
+
 func IsTemporalCloud() bool {
 	return viper.GetBool(constants.EnvTemporalExternal) && viper.GetString(constants.EnvTemporalAPIKey) != ""
 }
@@ -295,20 +641,28 @@ func RevertUpdatesInSchedule(req *types.ExecutionRequest) {
 
 	req.Command = types.Sync
 	req.Args = args
+	req.DryRun = false
 }
 
-// ExtractJSONAndMarshal extracts and returns the last valid JSON block from output
+// ExtractJSONAndMarshal extracts and returns the last valid JSON block from output, as it
+// appears in the output rather than re-serialized.
+// Lines are walked backwards in place rather than splitting the whole output, and when
+// OUTPUT_SCAN_MAX_BYTES is set only that many trailing bytes are examined.
 func ExtractJSONAndMarshal(output string) ([]byte, error) {
 	outputStr := strings.TrimSpace(output)
 	if outputStr == "" {
 		return nil, fmt.Errorf("empty output")
 	}
 
-	lines := strings.Split(outputStr, "\n")
+	if maxBytes := viper.GetInt(constants.EnvOutputScanMaxBytes); maxBytes > 0 && len(outputStr) > maxBytes {
+		outputStr = outputStr[len(outputStr)-maxBytes:]
+	}
 
 	// Find the last non-empty line with valid JSON
-	for i := len(lines) - 1; i >= 0; i-- {
-		line := strings.TrimSpace(lines[i])
+	for lineEnd := len(outputStr); lineEnd > 0; {
+		lineStart := strings.LastIndexByte(outputStr[:lineEnd], '\n') + 1
+		line := strings.TrimSpace(outputStr[lineStart:lineEnd])
+		lineEnd = lineStart - 1
 		if line == "" {
 			continue
 		}
@@ -317,11 +671,10 @@ func ExtractJSONAndMarshal(output string) ([]byte, error) {
 		end := strings.LastIndex(line, "}")
 		if start != -1 && end != -1 && end > start {
 			jsonPart := line[start : end+1]
-			var result map[string]interface{}
-			if err := json.Unmarshal([]byte(jsonPart), &result); err != nil {
+			if !json.Valid([]byte(jsonPart)) {
 				continue // Skip invalid JSON
 			}
-			return json.Marshal(result)
+			return []byte(jsonPart), nil
 		}
 	}
 
@@ -0,0 +1,502 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake-helm/worker/constants"
+	"github.com/datazip-inc/olake-helm/worker/types"
+	"github.com/datazip-inc/olake-helm/worker/utils/logger"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestUpdateConfigWithJobDetails(t *testing.T) {
+	viper.Set(constants.EnvTelemetryDisabled, true)
+	t.Cleanup(func() { viper.Set(constants.EnvTelemetryDisabled, nil) })
+
+	jobData := types.JobData{
+		Source:      `{"host":"db"}`,
+		Destination: `{"type":"iceberg"}`,
+		Streams:     `{"selected_streams":{}}`,
+		State:       `{"lsn":"1"}`,
+		Version:     "v0.2.0",
+	}
+
+	tests := []struct {
+		name      string
+		options   types.ExecutionOptions
+		wantState string
+	}{
+		{name: "saved state", wantState: `{"lsn":"1"}`},
+		{name: "full refresh", options: types.ExecutionOptions{UseEmptyState: true}, wantState: "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &types.ExecutionRequest{
+				Command: types.Sync,
+				Options: tt.options,
+				// configs sent with the request are replaced by the job's
+				Configs: []types.JobConfig{{Name: "state.json", Data: `{"lsn":"0"}`}},
+			}
+			UpdateConfigWithJobDetails(jobData, req)
+
+			configs := make(map[string]string)
+			for _, config := range req.Configs {
+				configs[config.Name] = config.Data
+			}
+			require.Equal(t, map[string]string{
+				"source.json":      jobData.Source,
+				"destination.json": jobData.Destination,
+				"streams.json":     jobData.Streams,
+				"state.json":       tt.wantState,
+			}, configs)
+			require.Equal(t, "v0.2.0", req.Version)
+		})
+	}
+}
+
+func TestGetExpectedImageDigest(t *testing.T) {
+	const digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorImageDigests, nil) })
+
+	tests := []struct {
+		name    string
+		digests string
+		want    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "pinned", digests: `{"Postgres":" SHA256:1111111111111111111111111111111111111111111111111111111111111111 "}`, want: digest},
+		{name: "other source type", digests: `{"mysql":"` + digest + `"}`},
+		{name: "short digest", digests: `{"postgres":"sha256:1234"}`, wantErr: `invalid CONNECTOR_IMAGE_DIGESTS digest "sha256:1234" for postgres`},
+		{name: "tag instead of digest", digests: `{"postgres":"v0.2.0"}`, wantErr: "expected sha256:<64 hex characters>"},
+		{name: "unreadable map", digests: `{"postgres":`, wantErr: "failed to parse CONNECTOR_IMAGE_DIGESTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvConnectorImageDigests, tt.digests)
+			got, err := GetExpectedImageDigest("postgres")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPinImageDigest(t *testing.T) {
+	const (
+		pinned = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		moved  = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	got, err := PinImageDigest("olakego/source-postgres:v0.2.0", "")
+	require.NoError(t, err)
+	require.Equal(t, "olakego/source-postgres:v0.2.0", got)
+
+	got, err = PinImageDigest("olakego/source-postgres:v0.2.0", pinned)
+	require.NoError(t, err)
+	require.Equal(t, "olakego/source-postgres:v0.2.0@"+pinned, got)
+
+	got, err = PinImageDigest("olakego/source-postgres@"+pinned, pinned)
+	require.NoError(t, err)
+	require.Equal(t, "olakego/source-postgres@"+pinned, got)
+
+	_, err = PinImageDigest("olakego/source-postgres@"+moved, pinned)
+	require.ErrorIs(t, err, constants.ErrExecutionFailed)
+}
+
+func TestGetDockerImageName(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.ContainerRegistryBase, nil) })
+
+	tests := []struct {
+		name         string
+		registryBase string
+		want         string
+	}{
+		{name: "unset", want: "olakego/source-postgres:v0.2.0"},
+		{name: "docker hub", registryBase: "registry-1.docker.io", want: "olakego/source-postgres:v0.2.0"},
+		{name: "docker hub short name", registryBase: "https://docker.io/", want: "olakego/source-postgres:v0.2.0"},
+		{name: "docker hub index", registryBase: "index.docker.io", want: "olakego/source-postgres:v0.2.0"},
+		{name: "private registry", registryBase: " https://123456789012.dkr.ecr.us-east-1.amazonaws.com// ", want: "123456789012.dkr.ecr.us-east-1.amazonaws.com/olakego/source-postgres:v0.2.0"},
+		{name: "registry with a path", registryBase: "ghcr.io/acme/", want: "ghcr.io/acme/olakego/source-postgres:v0.2.0"},
+		{name: "base already in the image", registryBase: "olakego", want: "olakego/source-postgres:v0.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.ContainerRegistryBase, tt.registryBase)
+			require.Equal(t, tt.want, GetDockerImageName("postgres", "v0.2.0"))
+		})
+	}
+}
+
+func TestGetDockerImageNameForArch(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	viper.Set(constants.EnvConnectorImageArchSuffixes, `{"Oracle":"-{arch}"}`)
+	t.Cleanup(func() {
+		viper.Set(constants.EnvConnectorImageArchSuffixes, nil)
+		viper.Set(constants.EnvConnectorImageOverrides, nil)
+	})
+
+	tests := []struct {
+		name       string
+		sourceType string
+		version    string
+		arch       string
+		want       string
+	}{
+		{name: "suffixed tag", sourceType: "oracle", version: "v0.2.0", arch: "arm64", want: "olakego/source-oracle:v0.2.0-arm64"},
+		{name: "multi-arch connector", sourceType: "postgres", version: "v0.2.0", arch: "arm64", want: "olakego/source-postgres:v0.2.0"},
+		{name: "pinned tag", sourceType: "oracle", version: "v0.2.0@" + digest, arch: "arm64", want: "olakego/source-oracle:v0.2.0@" + digest},
+		{name: "digest", sourceType: "oracle", version: digest, arch: "arm64", want: "olakego/source-oracle@" + digest},
+		{name: "no arch", sourceType: "oracle", version: "v0.2.0", want: "olakego/source-oracle:v0.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetDockerImageNameForArch(tt.sourceType, tt.version, tt.arch))
+		})
+	}
+
+	// the suffix is applied before an override's {version} is substituted
+	viper.Set(constants.EnvConnectorImageOverrides, `{"oracle":"registry.internal/oracle:{version}"}`)
+	require.Equal(t, "registry.internal/oracle:v0.2.0-amd64", GetDockerImageNameForArch("oracle", "v0.2.0", "amd64"))
+	require.True(t, UsesArchImages("oracle"))
+	require.False(t, UsesArchImages("postgres"))
+}
+
+func TestConnectorImageArch(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorImageArch, nil) })
+
+	require.Equal(t, runtime.GOARCH, ConnectorImageArch())
+	viper.Set(constants.EnvConnectorImageArch, " arm64 ")
+	require.Equal(t, "arm64", ConnectorImageArch())
+}
+
+func TestGetHeartbeatInterval(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set(constants.EnvHeartbeatInterval, nil)
+		viper.Set(constants.EnvSyncHeartbeatTimeout, nil)
+	})
+
+	// the interval the activity polls with, scheduled with the given heartbeat timeout
+	intervalIn := func(t *testing.T, heartbeatTimeout time.Duration) time.Duration {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions(func(ctx context.Context) (time.Duration, error) {
+			return GetHeartbeatInterval(ctx), nil
+		}, activity.RegisterOptions{Name: "poll"})
+
+		env.ExecuteWorkflow(func(ctx workflow.Context) (time.Duration, error) {
+			ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Hour, HeartbeatTimeout: heartbeatTimeout})
+			var interval time.Duration
+			err := workflow.ExecuteActivity(ctx, "poll").Get(ctx, &interval)
+			return interval, err
+		})
+		require.NoError(t, env.GetWorkflowError())
+		var interval time.Duration
+		require.NoError(t, env.GetWorkflowResult(&interval))
+		return interval
+	}
+
+	tests := []struct {
+		name             string
+		interval         time.Duration
+		envTimeout       time.Duration
+		heartbeatTimeout time.Duration
+		want             time.Duration
+	}{
+		{name: "default interval", heartbeatTimeout: 30 * time.Second, want: constants.DefaultHeartbeatInterval},
+		{name: "within the timeout", interval: 20 * time.Second, heartbeatTimeout: 30 * time.Second, want: 20 * time.Second},
+		{name: "capped at 0.8x", interval: 30 * time.Second, heartbeatTimeout: 30 * time.Second, want: 24 * time.Second},
+		// the activity's timeout wins over a setting changed since it was scheduled
+		{name: "scheduled timeout", interval: 50 * time.Second, envTimeout: 5 * time.Minute, heartbeatTimeout: 30 * time.Second, want: 24 * time.Second},
+		{name: "longer scheduled timeout", interval: 50 * time.Second, envTimeout: 30 * time.Second, heartbeatTimeout: 5 * time.Minute, want: 50 * time.Second},
+		{name: "no heartbeat timeout", interval: 50 * time.Second, envTimeout: time.Minute, want: 48 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvHeartbeatInterval, tt.interval)
+			viper.Set(constants.EnvSyncHeartbeatTimeout, tt.envTimeout)
+			require.Equal(t, tt.want, intervalIn(t, tt.heartbeatTimeout))
+		})
+	}
+
+	// outside an activity, SYNC_HEARTBEAT_TIMEOUT caps the interval
+	viper.Set(constants.EnvHeartbeatInterval, time.Minute)
+	viper.Set(constants.EnvSyncHeartbeatTimeout, 10*time.Second)
+	require.Equal(t, 8*time.Second, GetHeartbeatInterval(context.Background()))
+}
+
+func TestExtractJSONAndMarshal(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvOutputScanMaxBytes, nil) })
+
+	const result = `{"status":"SUCCEEDED"}`
+	tests := []struct {
+		name     string
+		output   string
+		maxBytes int
+		want     string
+		wantErr  string
+	}{
+		{name: "last json line", output: `{"status":"RUNNING"}` + "\n" + "INFO sync done\n" + "2024-01-01 " + result + "\n\n", want: result},
+		{name: "skips invalid json", output: result + "\n" + `{"status":` + "}\n", want: result},
+		{name: "single line", output: result, want: result},
+		// returned as the connector wrote it, not re-serialized
+		{name: "kept as emitted", output: `INFO done {"b":1,"a":"x \u003c y"}`, want: `{"b":1,"a":"x \u003c y"}`},
+		{name: "no json", output: "INFO starting\nINFO done", wantErr: "no valid JSON block found in output"},
+		{name: "empty", output: " \n ", wantErr: "empty output"},
+		{name: "result within the scanned tail", output: "INFO starting\n" + result + "\nINFO done", maxBytes: len(result) + 10, want: result},
+		{name: "result before the scanned tail", output: result + "\nINFO a long closing log line", maxBytes: 20, wantErr: "no valid JSON block found in output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvOutputScanMaxBytes, tt.maxBytes)
+			got, err := ExtractJSONAndMarshal(tt.output)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestGetTaskQueueForConnector(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorTaskQueues, nil) })
+
+	tests := []struct {
+		name   string
+		routes string
+		want   string
+	}{
+		{name: "unset", want: constants.TaskQueue},
+		{name: "routed", routes: `{"MongoDB":" olake-heavy "}`, want: "olake-heavy"},
+		{name: "unmapped connector", routes: `{"oracle":"olake-heavy"}`, want: constants.TaskQueue},
+		{name: "empty queue", routes: `{"mongodb":" "}`, want: constants.TaskQueue},
+		{name: "unreadable map", routes: `{"mongodb":`, want: constants.TaskQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvConnectorTaskQueues, tt.routes)
+			require.Equal(t, tt.want, GetTaskQueueForConnector("mongodb"))
+		})
+	}
+}
+
+func TestGetTriggerEnvVars(t *testing.T) {
+	t.Cleanup(func() { viper.Set(constants.EnvConnectorTriggerEnv, nil) })
+
+	scheduled := &types.TriggerContext{
+		Type:          types.TriggerScheduled,
+		ScheduleID:    "schedule-sync-7",
+		ScheduledTime: time.Date(2024, 5, 1, 2, 0, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+		Cron:          "0 2 * * *",
+	}
+	tests := []struct {
+		name    string
+		trigger *types.TriggerContext
+		enabled bool
+		want    map[string]string
+	}{
+		{
+			name: "scheduled", trigger: scheduled, enabled: true,
+			want: map[string]string{
+				"OLAKE_TRIGGER_TYPE":           types.TriggerScheduled,
+				"OLAKE_TRIGGER_SCHEDULE_ID":    "schedule-sync-7",
+				"OLAKE_TRIGGER_SCHEDULED_TIME": "2024-04-30T20:30:00Z",
+				"OLAKE_TRIGGER_CRON":           "0 2 * * *",
+			},
+		},
+		{name: "manual", trigger: &types.TriggerContext{Type: types.TriggerManual}, enabled: true, want: map[string]string{"OLAKE_TRIGGER_TYPE": types.TriggerManual}},
+		{name: "unknown trigger", enabled: true},
+		{name: "disabled", trigger: scheduled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvConnectorTriggerEnv, tt.enabled)
+			require.Equal(t, tt.want, GetTriggerEnvVars(&types.ExecutionRequest{Trigger: tt.trigger}))
+		})
+	}
+}
+
+func TestGetJobEnvVars(t *testing.T) {
+	profile := map[string]string{"AWS_REGION": "us-east-1", "BATCH_SIZE": "100"}
+	request := map[string]string{"BATCH_SIZE": "500", "1BAD": "x", "OLAKE_SECRET_KEY": "x", "OLAKE_TRIGGER_TYPE": "x"}
+
+	// later layers win; invalid and reserved names are dropped
+	require.Equal(t, map[string]string{"AWS_REGION": "us-east-1", "BATCH_SIZE": "500"}, GetJobEnvVars(profile, request))
+	require.Empty(t, GetJobEnvVars())
+}
+
+// retrySleeps returns the sleeps between attempts logged by the retry helpers
+func retrySleeps(t *testing.T, logs string) []time.Duration {
+	var sleeps []time.Duration
+	for _, match := range regexp.MustCompile(`retrying in ([^ ]+)\.\.\.`).FindAllStringSubmatch(logs, -1) {
+		sleep, err := time.ParseDuration(match[1])
+		require.NoError(t, err)
+		sleeps = append(sleeps, sleep)
+	}
+	return sleeps
+}
+
+func TestRetryWithBackoffJittered(t *testing.T) {
+	failing := func(attempts *int) func() error {
+		return func() error {
+			*attempts++
+			return errors.New("connection refused")
+		}
+	}
+
+	t.Run("capped", func(t *testing.T) {
+		logs := captureLogs(t)
+		var attempts int
+		err := RetryWithBackoffJittered(failing(&attempts), BackoffOptions{MaxRetries: 5, InitialDelay: time.Millisecond, MaxDelay: 3 * time.Millisecond})
+		require.EqualError(t, err, "failed after 5 retries: connection refused")
+		require.Equal(t, 5, attempts)
+		require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond}, retrySleeps(t, logs()))
+	})
+
+	t.Run("jittered", func(t *testing.T) {
+		logs := captureLogs(t)
+		var attempts int
+		err := RetryWithBackoffJittered(failing(&attempts), BackoffOptions{MaxRetries: 4, InitialDelay: 2 * time.Millisecond, MaxDelay: 4 * time.Millisecond, Jitter: true})
+		require.Error(t, err)
+
+		// each sleep falls between half and all of the capped delay
+		sleeps := retrySleeps(t, logs())
+		require.Len(t, sleeps, 3)
+		for i, delay := range []time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond} {
+			require.GreaterOrEqual(t, sleeps[i], delay/2)
+			require.LessOrEqual(t, sleeps[i], delay)
+		}
+	})
+
+	t.Run("recovers", func(t *testing.T) {
+		var attempts int
+		err := RetryWithBackoffJittered(func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}, BackoffOptions{MaxRetries: 5, InitialDelay: time.Millisecond, Jitter: true})
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		logs := captureLogs(t)
+		var attempts int
+		// the third sleep would pass the timeout, so retrying stops before the attempts run out
+		err := RetryWithBackoffJittered(failing(&attempts), BackoffOptions{MaxRetries: 60, InitialDelay: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+		require.ErrorContains(t, err, "failed after 3 retries in")
+		require.ErrorContains(t, err, "connection refused")
+		require.Equal(t, 3, attempts)
+		require.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, retrySleeps(t, logs()))
+	})
+
+	// the original helper keeps doubling without jitter
+	logs := captureLogs(t)
+	var attempts int
+	require.Error(t, RetryWithBackoff(failing(&attempts), 4, time.Millisecond))
+	require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}, retrySleeps(t, logs()))
+}
+
+func TestBackoffSleep(t *testing.T) {
+	require.Equal(t, time.Second, backoffSleep(time.Second, false))
+	require.Zero(t, backoffSleep(0, true))
+	for range 100 {
+		sleep := backoffSleep(time.Second, true)
+		require.GreaterOrEqual(t, sleep, 500*time.Millisecond)
+		require.LessOrEqual(t, sleep, time.Second)
+	}
+}
+
+// captureLogs sends the root logger to a file for the rest of the test and returns a reader of
+// what it wrote
+func captureLogs(t *testing.T) func() string {
+	out, err := os.Create(filepath.Join(t.TempDir(), "worker.log"))
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = out
+	viper.Set(constants.EnvLogFormat, "json")
+	logger.Init()
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		viper.Set(constants.EnvLogFormat, nil)
+		logger.Init()
+		out.Close()
+	})
+
+	return func() string {
+		logs, err := os.ReadFile(out.Name())
+		require.NoError(t, err)
+		return string(logs)
+	}
+}
+
+func TestGetTelemetryUserIDMissing(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set(constants.EnvTelemetryDisabled, nil)
+		viper.Set(constants.EnvTelemetryUserIDRequired, nil)
+	})
+	// no UI has written an ID into the test config directory
+	require.NoFileExists(t, filepath.Join(GetConfigDir(), "telemetry", "user_id"))
+
+	tests := []struct {
+		name       string
+		disabled   bool
+		required   bool
+		wantWarns  int
+		wantErrors int
+	}{
+		{name: "optional", wantWarns: 1},
+		{name: "required", required: true, wantErrors: 3},
+		{name: "telemetry disabled", disabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EnvTelemetryDisabled, tt.disabled)
+			viper.Set(constants.EnvTelemetryUserIDRequired, tt.required)
+			missingTelemetryIDWarning = sync.Once{}
+			logs := captureLogs(t)
+
+			for range 3 {
+				require.Empty(t, GetTelemetryUserID())
+			}
+			require.Equal(t, tt.wantWarns, strings.Count(logs(), `"level":"warn"`))
+			require.Equal(t, tt.wantErrors, strings.Count(logs(), `"level":"error"`))
+
+			// syncs run without the user ID file
+			req := &types.ExecutionRequest{Command: types.Sync}
+			UpdateConfigWithJobDetails(types.JobData{}, req)
+			for _, config := range req.Configs {
+				require.NotEqual(t, "user_id.txt", config.Name)
+			}
+		})
+	}
+}